@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"time"
 	"vegeta-server/internal/dispatcher"
 	"vegeta-server/internal/endpoints"
 	"vegeta-server/internal/reporter"
@@ -30,8 +31,28 @@ var (
 	ip        = kingpin.Flag("ip", "Server IP Address.").Default("0.0.0.0").String()
 	port      = kingpin.Flag("port", "Server Port.").Default("80").String()
 	redisHost = kingpin.Flag("redis", "Redis Server Address.").String()
-	v         = kingpin.Flag("version", "Version Info").Short('v').Bool()
-	debug     = kingpin.Flag("debug", "Enabled Debug").Bool()
+
+	resultsDir  = kingpin.Flag("results-dir", "Directory to store attack results in, instead of redis or memory.").String()
+	s3Endpoint  = kingpin.Flag("s3-endpoint", "S3 compatible endpoint to store attack results in.").String()
+	s3Bucket    = kingpin.Flag("s3-bucket", "S3 bucket for attack results.").Default("vegeta-results").String()
+	s3Prefix    = kingpin.Flag("s3-prefix", "S3 key prefix for attack results.").String()
+	s3Region    = kingpin.Flag("s3-region", "S3 region for attack results.").Default("us-east-1").String()
+	s3AccessKey = kingpin.Flag("s3-access-key", "S3 access key.").Envar("S3_ACCESS_KEY").String()
+	s3SecretKey = kingpin.Flag("s3-secret-key", "S3 secret key.").Envar("S3_SECRET_KEY").String()
+	compression = kingpin.Flag("compression", "Compression for stored attack results (none/gzip/zstd).").
+			Default("none").Enum("none", "gzip", "zstd")
+
+	retentionMaxAge         = kingpin.Flag("retention-max-age", "Remove attacks not updated for longer than this.").Duration()
+	retentionStatusMaxAge   = kingpin.Flag("retention-status-max-age", "Max age per status, e.g. failed=24h.").StringMap()
+	retentionMaxCount       = kingpin.Flag("retention-max-count", "Number of attacks to keep.").Int()
+	retentionMaxResultBytes = kingpin.Flag("retention-max-result-bytes", "Stored result, sample and upload bytes to keep.").Int64()
+	retentionStatuses       = kingpin.Flag("retention-status", "Attack status eligible for removal (repeatable).").
+				Enums("completed", "canceled", "failed")
+	retentionInterval = kingpin.Flag("retention-interval", "Interval between retention policy runs.").
+				Default("1m").Duration()
+
+	v     = kingpin.Flag("version", "Version Info").Short('v').Bool()
+	debug = kingpin.Flag("debug", "Enabled Debug").Bool()
 )
 
 func main() {
@@ -59,26 +80,36 @@ func main() {
 	quit := make(chan struct{})
 	defer close(quit)
 
+	retention, err := newRetentionPolicy()
+	if err != nil {
+		log.WithError(err).Fatal("Invalid retention policy")
+	}
+
 	var db models.IAttackStore
 
 	if redisHost != nil && *redisHost != "" {
-		db = models.NewRedis(func() redis.Conn {
-			conn, err := redis.Dial("tcp", *redisHost)
-			if err != nil {
-				log.Fatalf("Failed to connect to redis-server @ %s", *redisHost)
-			}
-			return conn
-		})
+		db = models.NewRedis(redisConn, retention)
 	} else {
 		db = models.NewTaskMap()
 	}
 
+	results, err := newResultStore()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up result store")
+	}
+
 	d := dispatcher.NewDispatcher(
 		db,
+		results,
+		dispatcher.Config{
+			Compression:  vegeta.Compression(*compression),
+			Retention:    retention,
+			ReapInterval: *retentionInterval,
+		},
 		vegeta.Attack,
 	)
 
-	r := reporter.NewReporter(db)
+	r := reporter.NewReporter(db, results)
 
 	go d.Run(quit)
 
@@ -106,3 +137,57 @@ func main() {
 	// start server
 	log.Fatal(engine.Run(fmt.Sprintf("%s:%s", *ip, *port)))
 }
+
+// redisConn connects to the redis server set by the command line flags
+func redisConn() redis.Conn {
+	conn, err := redis.Dial("tcp", *redisHost)
+	if err != nil {
+		log.Fatalf("Failed to connect to redis-server @ %s", *redisHost)
+	}
+	return conn
+}
+
+// newResultStore returns the result store selected by the command line flags,
+// falling back to the redis database attacks are kept in, then to memory
+func newResultStore() (models.IResultStore, error) {
+	switch {
+	case *s3Endpoint != "":
+		return models.NewS3Store(models.S3Config{
+			Endpoint:  *s3Endpoint,
+			Region:    *s3Region,
+			Bucket:    *s3Bucket,
+			Prefix:    *s3Prefix,
+			AccessKey: *s3AccessKey,
+			SecretKey: *s3SecretKey,
+		})
+	case *resultsDir != "":
+		return models.NewFileStore(*resultsDir)
+	case *redisHost != "":
+		return models.NewRedisResultStore(redisConn), nil
+	}
+	return models.NewResultMap(), nil
+}
+
+// newRetentionPolicy returns the retention policy configured by the command line flags
+func newRetentionPolicy() (models.RetentionPolicy, error) {
+	policy := models.RetentionPolicy{
+		MaxAge:         *retentionMaxAge,
+		StatusMaxAge:   make(map[models.AttackStatus]time.Duration),
+		MaxCount:       *retentionMaxCount,
+		MaxResultBytes: *retentionMaxResultBytes,
+	}
+
+	for _, status := range *retentionStatuses {
+		policy.Statuses = append(policy.Statuses, models.AttackStatus(status))
+	}
+
+	for status, age := range *retentionStatusMaxAge {
+		d, err := time.ParseDuration(age)
+		if err != nil {
+			return policy, fmt.Errorf("invalid max age %s for status %s", age, status)
+		}
+		policy.StatusMaxAge[models.AttackStatus(status)] = d
+	}
+
+	return policy, nil
+}
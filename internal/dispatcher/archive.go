@@ -0,0 +1,364 @@
+package dispatcher
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// archiveVersion is bumped on incompatible changes to the archive layout
+	archiveVersion = 1
+
+	archiveManifest   = "manifest.json"
+	archiveAttacksDir = "attacks/"
+	archiveResultsDir = "results/"
+	archiveSamplesDir = "samples/"
+	archiveTargetsDir = "targets/"
+	archiveReplaysDir = "replays/"
+)
+
+// manifest is the first entry of an archive
+type manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Attacks    []string  `json:"attacks"`
+}
+
+// Export writes the attacks matching the filters as a gzipped tar archive.
+// Each attack is stored as attacks/<id>.json, followed by the files it
+// refers to as stored: its result in results/<id>, its samples in
+// samples/<id>, and its uploaded target file and access log in targets/<id>
+// and replays/<id>. Scheduled and running attacks are left out.
+func (d *dispatcher) Export(w io.Writer, filters models.FilterParams) error {
+	d.log(nil).Info("exporting attacks")
+
+	all, _ := d.db.GetAll(filters, models.ListOptions{})
+	attacks := make([]models.AttackDetails, 0, len(all))
+	m := manifest{
+		Version:    archiveVersion,
+		ExportedAt: time.Now(),
+		Attacks:    make([]string, 0, len(all)),
+	}
+	for _, attack := range all {
+		if isActive(attack.Status) {
+			continue
+		}
+		attacks = append(attacks, attack)
+		m.Attacks = append(m.Attacks, attack.ID)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	if err := writeJSONEntry(tw, archiveManifest, m); err != nil {
+		return err
+	}
+	for _, attack := range attacks {
+		if err := writeJSONEntry(tw, archiveAttacksDir+attack.ID+".json", attack); err != nil {
+			return err
+		}
+		for _, f := range archiveFiles(attack) {
+			if err := d.exportFile(tw, f.dir+attack.ID, f.key, f.size); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to export attack with ID %s", attack.ID))
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to write archive")
+	}
+	return gw.Close()
+}
+
+// archiveFile is a stored file of an attack, exported in dir
+type archiveFile struct {
+	dir  string
+	key  string
+	size int64
+}
+
+// archiveFiles lists the stored files an attack refers to
+func archiveFiles(attack models.AttackDetails) []archiveFile {
+	files := make([]archiveFile, 0)
+	if attack.Result != nil {
+		files = append(files, archiveFile{archiveResultsDir, attack.Result.Key, attack.Result.Size})
+	}
+	if attack.Samples != nil {
+		files = append(files, archiveFile{archiveSamplesDir, attack.Samples.Key, attack.Samples.Size})
+	}
+	if f := attack.Params.TargetFile; f != nil && f.Key != "" {
+		files = append(files, archiveFile{archiveTargetsDir, f.Key, f.Size})
+	}
+	if r := attack.Params.Replay; r != nil && r.Key != "" {
+		files = append(files, archiveFile{archiveReplaysDir, r.Key, r.Size})
+	}
+	return files
+}
+
+// exportFile copies a stored file into the archive entry of the given name
+func (d *dispatcher) exportFile(tw *tar.Writer, name, key string, size int64) error {
+	rc, err := d.results.Get(key)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to get %s", name))
+	}
+	defer rc.Close() // nolint: errcheck
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0640,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return errors.Wrap(err, "failed to write archive")
+	}
+	if _, err := io.Copy(tw, rc); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to copy %s", name))
+	}
+	return nil
+}
+
+// pendingImport is an imported attack waiting for the files it refers to
+type pendingImport struct {
+	attack models.AttackDetails
+	// missing holds the archive dirs of the files still to be read
+	missing map[string]bool
+	// stored holds the keys of the files stored so far
+	stored []string
+}
+
+// Import adds the attacks of an archive written by Export, storing their
+// results, samples and uploads in this dispatcher's result store. Attacks
+// that already exist are skipped unless overwrite is set; active attacks are
+// never overwritten. Attacks missing files in the archive are skipped.
+func (d *dispatcher) Import(r io.Reader, overwrite bool) (*models.AttackImportResponse, error) {
+	d.log(log.Fields{"Overwrite": overwrite}).Info("importing attacks")
+
+	resp := &models.AttackImportResponse{
+		Imported: make([]string, 0),
+		Skipped:  make([]string, 0),
+	}
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return resp, errors.Wrap(err, "failed to read archive")
+	}
+	tr := tar.NewReader(gr)
+
+	if err := readManifest(tr); err != nil {
+		return resp, err
+	}
+
+	// Attacks wait here until their files have been stored
+	pending := make(map[string]*pendingImport)
+	fail := func(err error) (*models.AttackImportResponse, error) {
+		for _, p := range pending {
+			d.removeImported(p)
+		}
+		return resp, err
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(errors.Wrap(err, "failed to read archive"))
+		}
+
+		if strings.HasPrefix(hdr.Name, archiveAttacksDir) {
+			var attack models.AttackDetails
+			if err := json.NewDecoder(tr).Decode(&attack); err != nil {
+				return fail(errors.Wrap(err, fmt.Sprintf("failed to decode %s", hdr.Name)))
+			}
+			if attack.ID == "" || hdr.Name != archiveAttacksDir+attack.ID+".json" || isActive(attack.Status) {
+				return fail(fmt.Errorf("invalid attack in %s", hdr.Name))
+			}
+
+			if !d.importable(attack.ID, overwrite) {
+				resp.Skipped = append(resp.Skipped, attack.ID)
+				continue
+			}
+			if files := archiveFiles(attack); len(files) > 0 {
+				p := &pendingImport{attack: attack, missing: make(map[string]bool)}
+				for _, f := range files {
+					p.missing[f.dir] = true
+				}
+				pending[attack.ID] = p
+				continue
+			}
+			if err := d.importAttack(attack); err != nil {
+				return fail(err)
+			}
+			resp.Imported = append(resp.Imported, attack.ID)
+			continue
+		}
+
+		dir, id := path.Dir(hdr.Name)+"/", path.Base(hdr.Name)
+		p, ok := pending[id]
+		if !ok {
+			continue
+		}
+		if err := d.importFile(p, dir, tr); err != nil {
+			return fail(err)
+		}
+		if len(p.missing) > 0 {
+			continue
+		}
+		delete(pending, id)
+
+		if err := d.importAttack(p.attack); err != nil {
+			d.removeImported(p)
+			return fail(err)
+		}
+		resp.Imported = append(resp.Imported, id)
+	}
+
+	for id, p := range pending {
+		d.log(log.Fields{"ID": id}).Warning("archive is missing files of the attack")
+		d.removeImported(p)
+		resp.Skipped = append(resp.Skipped, id)
+	}
+	return resp, nil
+}
+
+// importFile stores a file of a pending attack read from the archive entry
+// in dir, pointing the attack to it. Results and samples are verified
+// against their checksums, uploads against their sizes.
+func (d *dispatcher) importFile(p *pendingImport, dir string, r io.Reader) error {
+	if !p.missing[dir] {
+		return nil
+	}
+
+	attack := &p.attack
+	// Files are staged under new keys, so the files of an attack being
+	// replaced are kept until the import succeeds
+	var key string
+	switch dir {
+	case archiveResultsDir:
+		key = attack.ID + "-" + uuid.NewV4().String()
+	case archiveSamplesDir:
+		key = models.SamplesKey(attack.ID + "-" + uuid.NewV4().String())
+	case archiveTargetsDir:
+		key = models.TargetFileKey(uuid.NewV4().String())
+	case archiveReplaysDir:
+		key = models.ReplayLogKey(uuid.NewV4().String())
+	}
+
+	ref, err := d.results.Put(key, r)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to store %s for attack with ID %s", strings.TrimSuffix(dir, "/"), attack.ID))
+	}
+	p.stored = append(p.stored, key)
+
+	var valid bool
+	switch dir {
+	case archiveResultsDir:
+		if valid = attack.Result != nil && ref.Checksum == attack.Result.Checksum; valid {
+			ref.Encoding, ref.RawSize = attack.Result.Encoding, attack.Result.RawSize
+			attack.Result = &ref
+		}
+	case archiveSamplesDir:
+		if valid = attack.Samples != nil && ref.Checksum == attack.Samples.Checksum; valid {
+			ref.Encoding, ref.RawSize = attack.Samples.Encoding, attack.Samples.RawSize
+			attack.Samples = &ref
+		}
+	case archiveTargetsDir:
+		if f := attack.Params.TargetFile; f != nil && ref.Size == f.Size {
+			valid = true
+			f.Key = key
+		}
+	case archiveReplaysDir:
+		if r := attack.Params.Replay; r != nil && ref.Size == r.Size {
+			valid = true
+			r.Key = key
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%s mismatch for attack with ID %s", strings.TrimSuffix(dir, "/"), attack.ID)
+	}
+	delete(p.missing, dir)
+	return nil
+}
+
+// removeImported deletes the files stored for a pending attack
+func (d *dispatcher) removeImported(p *pendingImport) {
+	for _, key := range p.stored {
+		d.removeUpload(key)
+	}
+}
+
+// importable reports whether an attack with the ID may be imported
+func (d *dispatcher) importable(id string, overwrite bool) bool {
+	stored, err := d.db.GetByID(id)
+	if err != nil {
+		return true
+	}
+	return overwrite && !isActive(stored.Status)
+}
+
+func (d *dispatcher) importAttack(attack models.AttackDetails) error {
+	attack.Events = append(attack.Events, models.AttackEvent{
+		Type:   models.AttackEventImported,
+		Time:   time.Now(),
+		Actor:  models.ActorUser,
+		Reason: "imported from archive",
+	})
+	replaced, replacing := d.db.GetByID(attack.ID)
+	if err := d.db.Add(attack); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to add attack with ID %s", attack.ID))
+	}
+	// Files are stored under new keys, drop those of the replaced attack
+	if replacing == nil {
+		d.removeFiles(replaced)
+	}
+	return nil
+}
+
+func readManifest(tr *tar.Reader) error {
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != archiveManifest {
+		return fmt.Errorf("archive does not start with %s", archiveManifest)
+	}
+
+	var m manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return errors.Wrap(err, "failed to decode manifest")
+	}
+	if m.Version != archiveVersion {
+		return fmt.Errorf("unsupported archive version %d", m.Version)
+	}
+	return nil
+}
+
+func writeJSONEntry(tw *tar.Writer, name string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0640,
+		Size:    int64(len(b)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return errors.Wrap(err, "failed to write archive")
+	}
+	_, err = tw.Write(b)
+	return errors.Wrap(err, "failed to write archive")
+}
+
+func isActive(status models.AttackStatus) bool {
+	return status == models.AttackResponseStatusScheduled || status == models.AttackResponseStatusRunning
+}
@@ -0,0 +1,196 @@
+package dispatcher
+
+import (
+	"fmt"
+	"time"
+	"vegeta-server/models"
+	"vegeta-server/pkg/vegeta"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrBaselineNotCompleted is returned when marking an attack that did
+	// not complete as a baseline
+	ErrBaselineNotCompleted = errors.New("only completed attacks can be baselines")
+	// ErrInvalidBaseline is returned for baselines whose labels are not set
+	// on the baseline attack
+	ErrInvalidBaseline = errors.New("invalid baseline")
+	// ErrNoBaseline is returned for the verdict of attacks no baseline matches
+	ErrNoBaseline = errors.New("no baseline matches the attack")
+)
+
+// SetBaseline marks a completed attack as the baseline of the attacks with
+// matching labels, replacing the baseline of the same labels. A nil baseline
+// unmarks the attack.
+func (d *dispatcher) SetBaseline(id string, baseline *models.Baseline, revision int64) error {
+	fields := log.Fields{
+		"ID":       id,
+		"Baseline": baseline != nil,
+	}
+
+	d.log(fields).Info("setting attack baseline")
+
+	if baseline == nil {
+		return d.setBaseline(id, revision, nil, "")
+	}
+
+	attackDetails, err := d.db.GetByID(id)
+	if err != nil {
+		return errors.Wrap(err, "failed to get item by ID")
+	}
+	if attackDetails.Status != models.AttackResponseStatusCompleted {
+		return ErrBaselineNotCompleted
+	}
+
+	b := *baseline
+	if len(b.Labels) == 0 {
+		b.Labels = attackDetails.Params.Labels
+	}
+	if len(b.Labels) == 0 {
+		return errors.Wrap(ErrInvalidBaseline, "baseline needs labels")
+	}
+	if !b.Matches(attackDetails.Params.Labels) {
+		return errors.Wrap(ErrInvalidBaseline, "baseline labels are not all set on the attack")
+	}
+
+	// Mark the attack first, so a failed precondition replaces nothing
+	if err := d.setBaseline(id, revision, &b, ""); err != nil {
+		return err
+	}
+
+	labels := models.FormatLabels(b.Labels)
+	for _, other := range d.db.GetBaselines() {
+		if other.ID == id || models.FormatLabels(other.Baseline.Labels) != labels {
+			continue
+		}
+		if err := d.setBaseline(other.ID, 0, nil, fmt.Sprintf("replaced by %s", id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dispatcher) setBaseline(id string, revision int64, baseline *models.Baseline, reason string) error {
+	event := models.AttackEventUnbaselined
+	if baseline != nil {
+		event = models.AttackEventBaselined
+	}
+
+	return d.updateAt(id, revision, func(attackDetails *models.AttackDetails) {
+		attackDetails.Baseline = baseline
+		attackDetails.Events = append(attackDetails.Events, models.AttackEvent{
+			Type:   event,
+			Time:   time.Now(),
+			Actor:  models.ActorUser,
+			Reason: reason,
+		})
+	})
+}
+
+// Verdict returns the verdict of an attack against the baseline matching its
+// labels. Attacks that are still active, or yet to be evaluated, get a
+// pending verdict.
+func (d *dispatcher) Verdict(id string) (*models.BaselineVerdict, error) {
+	d.log(log.Fields{"ID": id}).Debug("getting attack verdict")
+
+	attackDetails, err := d.db.GetByID(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get item by ID")
+	}
+	if attackDetails.Verdict != nil {
+		return attackDetails.Verdict, nil
+	}
+
+	// The baseline of active attacks is only settled once they finish
+	if isActive(attackDetails.Status) {
+		if baseline := d.baselineFor(id, attackDetails.Params.Labels); baseline != nil {
+			return models.PendingBaselineVerdict(baseline.ID, *baseline.Baseline), nil
+		}
+	}
+	return nil, ErrNoBaseline
+}
+
+// baselineFor returns the baseline attack matching the labels of an attack.
+// If several match, the one with the most labels wins, then the oldest.
+func (d *dispatcher) baselineFor(id string, labels map[string]string) *models.AttackDetails {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	var match *models.AttackDetails
+	attacks := models.ListOptions{}.Apply(d.db.GetBaselines())
+	for i := range attacks {
+		attack := &attacks[i]
+		if attack.ID == id || !attack.Baseline.Matches(labels) {
+			continue
+		}
+		if match == nil || len(attack.Baseline.Labels) > len(match.Baseline.Labels) {
+			match = attack
+		}
+	}
+	return match
+}
+
+// evaluate compares a finished attack against the baseline attack and
+// stores the verdict
+func (d *dispatcher) evaluate(id string, baseline models.AttackDetails) {
+	fields := log.Fields{
+		"ID":       id,
+		"Baseline": baseline.ID,
+	}
+
+	verdict := d.verdict(id, baseline)
+	err := d.update(id, func(attackDetails *models.AttackDetails) {
+		attackDetails.Verdict = verdict
+		attackDetails.Events = append(attackDetails.Events, models.AttackEvent{
+			Type:   models.AttackEventEvaluated,
+			Time:   *verdict.EvaluatedAt,
+			Actor:  models.ActorDispatcher,
+			Reason: verdict.Status,
+		})
+	})
+	if err != nil {
+		d.log(fields).WithError(err).Error("failed to store verdict")
+		return
+	}
+	d.log(fields).WithField("Verdict", verdict.Status).Info("evaluated attack against baseline")
+}
+
+// verdict returns the verdict of an attack against the baseline attack.
+// Attacks that did not complete fail.
+func (d *dispatcher) verdict(id string, baseline models.AttackDetails) *models.BaselineVerdict {
+	fail := func(err error) *models.BaselineVerdict {
+		return models.NewBaselineVerdict(baseline.ID, *baseline.Baseline, nil, err)
+	}
+
+	attackDetails, err := d.db.GetByID(id)
+	if err != nil {
+		return fail(errors.Wrap(err, "failed to get item by ID"))
+	}
+	if attackDetails.Status != models.AttackResponseStatusCompleted {
+		return fail(fmt.Errorf("attack is %s", attackDetails.Status))
+	}
+	if attackDetails.Result == nil || baseline.Result == nil {
+		return fail(fmt.Errorf("attack or baseline has no result"))
+	}
+
+	base, err := d.results.Get(baseline.Result.Key)
+	if err != nil {
+		return fail(errors.Wrap(err, "failed to get baseline result"))
+	}
+	defer base.Close() // nolint: errcheck
+
+	candidate, err := d.results.Get(attackDetails.Result.Key)
+	if err != nil {
+		return fail(errors.Wrap(err, "failed to get result"))
+	}
+	defer candidate.Close() // nolint: errcheck
+
+	cmp, err := vegeta.CompareResults(baseline.ID, base, id, candidate, models.DefaultComparisonAlpha)
+	if err != nil {
+		return fail(err)
+	}
+	return models.NewBaselineVerdict(baseline.ID, *baseline.Baseline, baseline.Baseline.Evaluate(cmp), nil)
+}
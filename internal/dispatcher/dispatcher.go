@@ -2,6 +2,8 @@ package dispatcher
 
 import (
 	"fmt"
+	"io"
+	"time"
 	"vegeta-server/pkg/vegeta"
 
 	log "github.com/sirupsen/logrus"
@@ -14,6 +16,7 @@ import (
 
 var (
 	defaultDB       = models.NewTaskMap()
+	defaultResults  = models.NewResultMap()
 	defaultAttackFn = vegeta.Attack
 )
 
@@ -23,17 +26,77 @@ type IDispatcher interface {
 	Run(chan struct{})
 	// Dispatch an attack. Used by the client/handler
 	Dispatch(models.AttackParams) (*models.AttackResponse, error)
-	// Cancel a scheduled/on-going attack
-	Cancel(string, bool) error
+	// Cancel a scheduled/on-going attack. A non-zero revision is the
+	// revision the attack must be at, otherwise models.ErrRevisionConflict
+	// is returned.
+	Cancel(string, bool, int64) error
 
 	// Get the attack status, params and ID for a single attack
 	Get(string) (*models.AttackResponse, error)
-	// List the attack status, params and ID for all submitted attacks.
-	List(models.FilterParams) []*models.AttackResponse
+	// List the attack status, params and ID for submitted attacks, sorted and
+	// paginated by the list options, along with the total number of matches
+	List(models.FilterParams, models.ListOptions) ([]*models.AttackResponse, int)
 	// List Ids and parameters from all completed attacks (prometheus endpoint)
 	ListIds(models.FilterParams) []*models.AttackBaseInfo
+	// Pin or unpin an attack, pinned attacks are exempt from the retention
+	// policy. A non-zero revision is the revision the attack must be at.
+	Pin(string, bool, int64) error
+	// Delete an attack along with its result. Scheduled or running
+	// attacks are only deleted when forced, which cancels them first.
+	// A non-zero revision is the revision the attack must be at.
+	Delete(string, bool, int64) error
+	// DeleteAll attacks matching the filters, returning the deleted and skipped IDs
+	DeleteAll(models.FilterParams, bool) *models.AttackDeleteResponse
+	// Events returns the state transition log of an attack, oldest first
+	Events(string) ([]models.AttackEvent, error)
+	// Samples returns a page of the sampled requests of a completed attack,
+	// along with the total number of samples
+	Samples(string, models.ListOptions) ([]models.Sample, int, error)
+	// SetBaseline marks a completed attack as the baseline of the attacks
+	// with matching labels, a nil baseline unmarks it. A non-zero revision
+	// is the revision the attack must be at.
+	SetBaseline(string, *models.Baseline, int64) error
+	// Verdict returns the verdict of an attack against the baseline
+	// matching its labels
+	Verdict(string) (*models.BaselineVerdict, error)
+	// Export attacks matching the filters, along with their results, as an archive
+	Export(io.Writer, models.FilterParams) error
+	// Import attacks from an archive written by Export, optionally overwriting existing ones
+	Import(io.Reader, bool) (*models.AttackImportResponse, error)
+
+	// PutFeeder parses a data feeder in the given format and stores it under the name
+	PutFeeder(string, string, io.Reader) (*models.FeederInfo, error)
+	// GetFeeder returns the records of a stored data feeder
+	GetFeeder(string) (models.Feed, error)
+	// DeleteFeeder removes a stored data feeder
+	DeleteFeeder(string) error
+
+	// PutTargets stores a target file in the given format for an attack to
+	// be dispatched, returning its reference
+	PutTargets(string, io.Reader) (models.ResultRef, error)
+	// PutReplayLog stores the access log of a replay for an attack to be
+	// dispatched, returning its reference
+	PutReplayLog(models.ReplayParams, io.Reader) (models.ResultRef, error)
 }
 
+// ErrAttackActive is returned when deleting a scheduled or running attack without force
+var ErrAttackActive = errors.New("attack is scheduled or running")
+
+// Config captures the optional dispatcher settings
+type Config struct {
+	// Compression applied to stored results
+	Compression vegeta.Compression
+	// Retention policy enforced by the reaper
+	Retention models.RetentionPolicy
+	// ReapInterval between retention policy runs, defaults to a minute
+	ReapInterval time.Duration
+}
+
+const defaultReapInterval = time.Minute
+
+// maxUpdateAttempts bounds the retries of an update on revision conflicts
+const maxUpdateAttempts = 5
+
 type dispatcher struct {
 	mu       *sync.RWMutex
 	tasks    map[string]ITask
@@ -41,18 +104,37 @@ type dispatcher struct {
 	submitCh chan ITask
 	updateCh chan UpdateMessage
 	db       models.IAttackStore
+	results  models.IResultStore
+	cfg      Config
 }
 
 // NewDispatcher constructs a new instance of the dispatcher object.
-func NewDispatcher(db models.IAttackStore, fn AttackFunc) *dispatcher { // nolint: golint
+func NewDispatcher(
+	db models.IAttackStore,
+	results models.IResultStore,
+	cfg Config,
+	fn AttackFunc,
+) *dispatcher { // nolint: golint
 	if db == nil {
 		db = defaultDB
 	}
 
+	if results == nil {
+		results = defaultResults
+	}
+
 	if fn == nil {
 		fn = defaultAttackFn
 	}
 
+	if cfg.Compression == "" {
+		cfg.Compression = vegeta.CompressionNone
+	}
+
+	if cfg.ReapInterval <= 0 {
+		cfg.ReapInterval = defaultReapInterval
+	}
+
 	d := &dispatcher{
 		&sync.RWMutex{},
 		make(map[string]ITask),
@@ -60,6 +142,8 @@ func NewDispatcher(db models.IAttackStore, fn AttackFunc) *dispatcher { // nolin
 		make(chan ITask, 10),
 		make(chan UpdateMessage, 20),
 		db,
+		results,
+		cfg,
 	}
 	d.log(nil).Info("creating new dispatcher")
 	return d
@@ -67,7 +151,26 @@ func NewDispatcher(db models.IAttackStore, fn AttackFunc) *dispatcher { // nolin
 
 // Dispatch implements the attack dispatcher method, used by the client to schedule new attacks
 func (d *dispatcher) Dispatch(params models.AttackParams) (*models.AttackResponse, error) {
-	task := NewTask(d.updateCh, params)
+	var inputs models.AttackInputs
+	if f := params.TargetFile; f != nil && f.Key != "" {
+		inputs.Targets = func() (io.ReadCloser, error) {
+			return d.results.Get(f.Key)
+		}
+	}
+	if r := params.Replay; r != nil && r.Key != "" {
+		inputs.Log = func() (io.ReadCloser, error) {
+			return d.results.Get(r.Key)
+		}
+	}
+	if params.Feeder != nil {
+		var err error
+		if inputs.Feed, err = d.GetFeeder(params.Feeder.Name); err != nil {
+			d.removeUploads(params)
+			return nil, err
+		}
+	}
+
+	task := NewTask(d.updateCh, params, inputs, d.results, d.cfg.Compression)
 	id := task.ID()
 	status := task.Status()
 	fields := log.Fields{
@@ -81,7 +184,13 @@ func (d *dispatcher) Dispatch(params models.AttackParams) (*models.AttackRespons
 	d.mu.Unlock()
 
 	// Add to database
-	_ = d.db.Add(attackDetailFromTask(task))
+	details := attackDetailFromTask(task)
+	details.Events = []models.AttackEvent{{
+		Type:  models.AttackEventScheduled,
+		Time:  task.CreatedAt(),
+		Actor: models.ActorUser,
+	}}
+	_ = d.db.Add(details)
 
 	d.log(fields).Info("dispatching new attack")
 	d.submitCh <- task
@@ -100,6 +209,15 @@ func (d *dispatcher) Dispatch(params models.AttackParams) (*models.AttackRespons
 func (d *dispatcher) Run(quit chan struct{}) {
 	defer close(d.submitCh)
 	d.log(nil).Info("starting dispatcher")
+
+	// A nil channel blocks forever, leaving the reaper disabled
+	var reap <-chan time.Time
+	if d.cfg.Retention.Enabled() {
+		ticker := time.NewTicker(d.cfg.ReapInterval)
+		defer ticker.Stop()
+		reap = ticker.C
+	}
+
 	for {
 		select {
 		case task := <-d.submitCh:
@@ -121,17 +239,48 @@ func (d *dispatcher) Run(quit chan struct{}) {
 			}
 
 			d.mu.RLock()
-			task := d.tasks[update.ID]
+			task, ok := d.tasks[update.ID]
 			d.mu.RUnlock()
 
-			if err := d.db.Update(task.ID(), attackDetailFromTask(task)); err != nil {
+			// The attack was deleted in the meantime
+			if !ok {
+				continue
+			}
+
+			// Finished attacks are evaluated against the baseline matching
+			// their labels, if any
+			var baseline *models.AttackDetails
+			if !isActive(update.Status) {
+				baseline = d.baselineFor(task.ID(), task.Params().Labels)
+			}
+
+			// Only overwrite the fields owned by the task
+			err := d.update(task.ID(), func(stored *models.AttackDetails) {
+				details := attackDetailFromTask(task)
+				details.Pinned = stored.Pinned
+				details.Baseline = stored.Baseline
+				details.Verdict = stored.Verdict
+				details.Revision = stored.Revision
+				details.Events = append(stored.Events, update.Event)
+				if baseline != nil {
+					details.Verdict = models.PendingBaselineVerdict(baseline.ID, *baseline.Baseline)
+				}
+				*stored = details
+			})
+			if err != nil {
 				d.log(fields).WithError(err).Error("attack update error")
 				continue
 			}
 			d.log(fields).Debug("received update for attack")
+
+			if baseline != nil {
+				go d.evaluate(task.ID(), *baseline)
+			}
+		case now := <-reap:
+			d.reap(now)
 		case <-quit:
 			for _, task := range d.tasks {
-				_ = task.Cancel()
+				_ = task.Cancel(models.ActorDispatcher, "dispatcher shutting down")
 			}
 			d.log(nil).Warning("gracefully shutting down the dispatcher")
 			return
@@ -140,7 +289,7 @@ func (d *dispatcher) Run(quit chan struct{}) {
 }
 
 // Cancel an attack by ID.
-func (d *dispatcher) Cancel(id string, cancel bool) error {
+func (d *dispatcher) Cancel(id string, cancel bool, revision int64) error {
 	fields := log.Fields{
 		"ID":       id,
 		"ToCancel": cancel,
@@ -157,8 +306,12 @@ func (d *dispatcher) Cancel(id string, cancel bool) error {
 	}
 	d.mu.RUnlock()
 
+	if _, err := d.claim(id, revision); err != nil {
+		return err
+	}
+
 	if cancel {
-		err := t.Cancel()
+		err := t.Cancel(models.ActorUser, "canceled by request")
 		if err != nil {
 			d.log(fields).WithError(err).Error("failed to cancel task")
 			return errors.Wrap(err, "failed to cancel task")
@@ -184,17 +337,18 @@ func (d *dispatcher) Get(id string) (*models.AttackResponse, error) {
 	return &resp, nil
 }
 
-// List all submitted attacks
-func (d *dispatcher) List(filters models.FilterParams) []*models.AttackResponse {
+// List submitted attacks
+func (d *dispatcher) List(filters models.FilterParams, opts models.ListOptions) ([]*models.AttackResponse, int) {
 	d.log(nil).Debug("getting attack list")
 
 	responses := make([]*models.AttackResponse, 0)
 
-	for _, attackDetails := range d.db.GetAll(filters) {
+	attacks, total := d.db.GetAll(filters, opts)
+	for _, attackDetails := range attacks {
 		resp := models.AttackResponse(attackDetails.AttackInfo)
 		responses = append(responses, &resp)
 	}
-	return responses
+	return responses, total
 }
 
 // List all submitted attacks (ID : Params)
@@ -203,16 +357,220 @@ func (d *dispatcher) ListIds(filters models.FilterParams) []*models.AttackBaseIn
 
 	responses := make([]*models.AttackBaseInfo, 0)
 
-	for _, attackDetails := range d.db.GetAll(filters) {
+	attacks, _ := d.db.GetAll(filters, models.ListOptions{})
+	for _, attackDetails := range attacks {
 		resp := models.AttackBaseInfo{
 			ID:     attackDetails.AttackInfo.ID,
 			Params: attackDetails.AttackInfo.Params,
+			Result: attackDetails.Result,
 		}
 		responses = append(responses, &resp)
 	}
 	return responses
 }
 
+// Events returns the event log of an attack by ID
+func (d *dispatcher) Events(id string) ([]models.AttackEvent, error) {
+	d.log(log.Fields{"ID": id}).Debug("getting attack events")
+
+	attackDetails, err := d.db.GetByID(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get item by ID")
+	}
+
+	events := attackDetails.Events
+	if events == nil {
+		events = make([]models.AttackEvent, 0)
+	}
+	return events, nil
+}
+
+// Pin an attack by ID, exempting it from the retention policy
+func (d *dispatcher) Pin(id string, pinned bool, revision int64) error {
+	fields := log.Fields{
+		"ID":     id,
+		"Pinned": pinned,
+	}
+
+	d.log(fields).Info("pinning attack")
+
+	event := models.AttackEventUnpinned
+	if pinned {
+		event = models.AttackEventPinned
+	}
+
+	return d.updateAt(id, revision, func(attackDetails *models.AttackDetails) {
+		attackDetails.Pinned = pinned
+		attackDetails.Events = append(attackDetails.Events, models.AttackEvent{
+			Type:  event,
+			Time:  time.Now(),
+			Actor: models.ActorUser,
+		})
+	})
+}
+
+// update applies fn to the stored attack and writes it back, retrying with
+// a fresh copy when the attack was modified concurrently
+func (d *dispatcher) update(id string, fn func(*models.AttackDetails)) error {
+	return d.updateAt(id, 0, fn)
+}
+
+// updateAt is update for a non-zero revision the attack must be at. The
+// attack is then not retried, but fails with models.ErrRevisionConflict
+// when modified since the revision, as the store compares and swaps it.
+func (d *dispatcher) updateAt(id string, revision int64, fn func(*models.AttackDetails)) error {
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		var attackDetails models.AttackDetails
+		attackDetails, err = d.db.GetByID(id)
+		if err != nil {
+			return errors.Wrap(err, "failed to get item by ID")
+		}
+		if revision != 0 && attackDetails.Revision != revision {
+			err = models.ErrRevisionConflict
+			break
+		}
+
+		fn(&attackDetails)
+
+		err = d.db.Update(id, attackDetails)
+		if revision != 0 || errors.Cause(err) != models.ErrRevisionConflict {
+			break
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to update item")
+	}
+	return nil
+}
+
+// Delete an attack by ID along with its stored result
+func (d *dispatcher) Delete(id string, force bool, revision int64) error {
+	fields := log.Fields{
+		"ID":    id,
+		"Force": force,
+	}
+
+	d.log(fields).Info("deleting attack")
+
+	attackDetails, err := d.claim(id, revision)
+	if err != nil {
+		return err
+	}
+
+	return d.delete(attackDetails, force)
+}
+
+// claim gets an attack, checking it is at a non-zero revision with a compare
+// and swap of the stored attack. Operations which do not update the attack
+// themselves thus fail with models.ErrRevisionConflict, rather than act on
+// an attack modified since the revision.
+func (d *dispatcher) claim(id string, revision int64) (models.AttackDetails, error) {
+	attackDetails, err := d.db.GetByID(id)
+	if err != nil {
+		return attackDetails, errors.Wrap(err, "failed to get item by ID")
+	}
+	if revision == 0 {
+		return attackDetails, nil
+	}
+	if attackDetails.Revision != revision {
+		return attackDetails, errors.Wrap(models.ErrRevisionConflict, "failed to claim item")
+	}
+	if err := d.db.Update(id, attackDetails); err != nil {
+		return attackDetails, errors.Wrap(err, "failed to claim item")
+	}
+	return attackDetails, nil
+}
+
+// DeleteAll deletes all attacks matching the filters. Scheduled or running
+// attacks are skipped unless forced.
+func (d *dispatcher) DeleteAll(filters models.FilterParams, force bool) *models.AttackDeleteResponse {
+	d.log(log.Fields{"Force": force}).Info("deleting attacks")
+
+	resp := &models.AttackDeleteResponse{
+		Deleted: make([]string, 0),
+		Skipped: make([]string, 0),
+	}
+
+	attacks, _ := d.db.GetAll(filters, models.ListOptions{})
+	for _, attackDetails := range attacks {
+		if err := d.delete(attackDetails, force); err != nil {
+			d.log(log.Fields{"ID": attackDetails.ID}).WithError(err).Warning("failed to delete attack")
+			resp.Skipped = append(resp.Skipped, attackDetails.ID)
+			continue
+		}
+		resp.Deleted = append(resp.Deleted, attackDetails.ID)
+	}
+	return resp
+}
+
+func (d *dispatcher) delete(attack models.AttackDetails, force bool) error {
+	if isActive(attack.Status) {
+		if !force {
+			return ErrAttackActive
+		}
+
+		d.mu.RLock()
+		t, ok := d.tasks[attack.ID]
+		d.mu.RUnlock()
+
+		if ok {
+			if err := t.Cancel(models.ActorUser, "deleted while active"); err != nil {
+				return errors.Wrap(err, "failed to cancel task")
+			}
+		}
+	}
+
+	return d.remove(attack)
+}
+
+// reap removes all attacks that expired under the retention policy
+func (d *dispatcher) reap(now time.Time) {
+	attacks, _ := d.db.GetAll(make(models.FilterParams), models.ListOptions{})
+	expired := d.cfg.Retention.Expired(attacks, now)
+	for _, attack := range expired {
+		fields := log.Fields{
+			"ID":     attack.ID,
+			"Status": attack.Status,
+		}
+
+		if err := d.remove(attack); err != nil {
+			d.log(fields).WithError(err).Error("failed to remove expired attack")
+			continue
+		}
+		d.log(fields).Info("removed expired attack")
+	}
+}
+
+// remove deletes an attack along with its stored result and samples. A
+// result that cannot be deleted is logged but does not keep the attack around.
+func (d *dispatcher) remove(attack models.AttackDetails) error {
+	d.removeFiles(attack)
+
+	// Stop tracking the task first, so late updates do not store it again
+	d.mu.Lock()
+	delete(d.tasks, attack.ID)
+	d.mu.Unlock()
+
+	if err := d.db.Delete(attack.ID); err != nil {
+		return errors.Wrap(err, "failed to delete item")
+	}
+
+	return nil
+}
+
+// removeFiles deletes the stored result, samples and uploads of an attack,
+// logging failures
+func (d *dispatcher) removeFiles(attack models.AttackDetails) {
+	if attack.Result != nil {
+		if err := d.results.Delete(attack.Result.Key); err != nil {
+			d.log(log.Fields{"ID": attack.ID}).WithError(err).Warning("failed to delete result")
+		}
+	}
+	d.removeUploads(attack.Params)
+	d.removeSamples(attack)
+}
+
 func (d *dispatcher) log(fields map[string]interface{}) *log.Entry {
 	l := log.WithField("component", "dispatcher")
 
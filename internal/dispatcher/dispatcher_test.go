@@ -1,17 +1,24 @@
 package dispatcher
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 	"vegeta-server/models"
 	smocks "vegeta-server/models/mocks"
+	"vegeta-server/pkg/vegeta"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
+	lib "github.com/tsenart/vegeta/lib"
 )
 
 func TestNewDispatcher(t *testing.T) {
@@ -28,8 +35,9 @@ func TestNewDispatcher(t *testing.T) {
 			name: "OK",
 			args: args{
 				db: &smocks.IAttackStore{},
-				fn: func(s string, params models.AttackParams, i chan struct{}) (reader io.Reader, e error) {
-					return strings.NewReader("hello world"), nil
+				fn: func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+					_, err := io.WriteString(w, "hello world")
+					return err
 				},
 			},
 			wantNil: false,
@@ -37,8 +45,9 @@ func TestNewDispatcher(t *testing.T) {
 		{
 			name: "OK - defaults db",
 			args: args{
-				fn: func(s string, params models.AttackParams, i chan struct{}) (reader io.Reader, e error) {
-					return strings.NewReader("hello world"), nil
+				fn: func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+					_, err := io.WriteString(w, "hello world")
+					return err
 				},
 			},
 			wantNil: false,
@@ -53,7 +62,7 @@ func TestNewDispatcher(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewDispatcher(tt.args.db, tt.args.fn)
+			got := NewDispatcher(tt.args.db, nil, Config{}, tt.args.fn)
 			if tt.wantNil && got != nil {
 				t.Errorf("NewDispatcher() = %v, wantNit %v", got, tt.wantNil)
 			}
@@ -65,12 +74,14 @@ func setupDispatcher(db models.IAttackStore) *dispatcher {
 	d := &dispatcher{
 		mu:    new(sync.RWMutex),
 		tasks: make(map[string]ITask),
-		attackFn: func(s string, params models.AttackParams, i chan struct{}) (reader io.Reader, e error) {
-			return strings.NewReader("hello world"), nil
+		attackFn: func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+			_, err := io.WriteString(w, "hello world")
+			return err
 		},
 		submitCh: make(chan ITask),
 		updateCh: make(chan UpdateMessage),
 		db:       db,
+		results:  models.NewResultMap(),
 	}
 
 	go func() {
@@ -200,9 +211,9 @@ func Test_dispatcher_Cancel(t *testing.T) {
 	mockStore.On("Add", mock.Anything).Return(nil)
 	mockStore.On("GetByID", mock.Anything).Return(models.AttackDetails{}, nil)
 
-	d := NewDispatcher(mockStore, func(s string, params models.AttackParams, i chan struct{}) (reader io.Reader, e error) {
+	d := NewDispatcher(mockStore, nil, Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
 		<-i
-		return nil, nil
+		return nil
 	})
 
 	quit := make(chan struct{})
@@ -222,7 +233,7 @@ func Test_dispatcher_Cancel(t *testing.T) {
 
 	for _, task := range d.tasks {
 		id := task.ID()
-		err = d.Cancel(id, true)
+		err = d.Cancel(id, true, 0)
 		if err != nil {
 			t.Fail()
 		}
@@ -236,8 +247,9 @@ func Test_dispatcher_Cancel_Error_completed(t *testing.T) {
 	mockStore.On("Add", mock.Anything).Return(nil)
 	mockStore.On("GetByID", mock.Anything).Return(models.AttackDetails{}, nil)
 
-	d := NewDispatcher(mockStore, func(s string, params models.AttackParams, i chan struct{}) (reader io.Reader, e error) {
-		return strings.NewReader("hello world"), nil
+	d := NewDispatcher(mockStore, nil, Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		_, err := io.WriteString(w, "hello world")
+		return err
 	})
 
 	quit := make(chan struct{})
@@ -254,7 +266,7 @@ func Test_dispatcher_Cancel_Error_completed(t *testing.T) {
 
 	for _, task := range d.tasks {
 		id := task.ID()
-		err := d.Cancel(id, true)
+		err := d.Cancel(id, true, 0)
 		if err == nil {
 			t.Fail()
 		}
@@ -268,8 +280,8 @@ func Test_dispatcher_Cancel_Error_not_found(t *testing.T) {
 	mockStore.On("Add", mock.Anything).Return(nil)
 	mockStore.On("GetByID", mock.Anything).Return(models.AttackDetails{}, nil)
 
-	d := NewDispatcher(mockStore, func(s string, params models.AttackParams, i chan struct{}) (reader io.Reader, e error) {
-		return nil, nil
+	d := NewDispatcher(mockStore, nil, Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		return nil
 	})
 
 	quit := make(chan struct{})
@@ -279,7 +291,7 @@ func Test_dispatcher_Cancel_Error_not_found(t *testing.T) {
 
 	go d.Run(quit)
 
-	err := d.Cancel("123", true)
+	err := d.Cancel("123", true, 0)
 	if err == nil {
 		t.Fail()
 	}
@@ -314,11 +326,11 @@ func Test_dispatcher_Get_Error_GetByID(t *testing.T) {
 func Test_dispatcher_List(t *testing.T) {
 	mockStore := &smocks.IAttackStore{}
 
-	mockStore.On("GetAll", make(models.FilterParams)).Return([]models.AttackDetails{{}})
+	mockStore.On("GetAll", make(models.FilterParams), models.ListOptions{}).Return([]models.AttackDetails{{}}, 1)
 
 	d := setupDispatcher(mockStore)
 
-	got := d.List(make(models.FilterParams))
+	got, _ := d.List(make(models.FilterParams), models.ListOptions{})
 	if len(got) == 0 {
 		t.Fail()
 	}
@@ -327,12 +339,849 @@ func Test_dispatcher_List(t *testing.T) {
 func Test_dispatcher_List_Empty(t *testing.T) {
 	mockStore := &smocks.IAttackStore{}
 
-	mockStore.On("GetAll", make(models.FilterParams)).Return([]models.AttackDetails{})
+	mockStore.On("GetAll", make(models.FilterParams), models.ListOptions{}).Return([]models.AttackDetails{}, 0)
 
 	d := setupDispatcher(mockStore)
 
-	got := d.List(make(models.FilterParams))
+	got, _ := d.List(make(models.FilterParams), models.ListOptions{})
 	if len(got) != 0 {
 		t.Fail()
 	}
 }
+
+func Test_run_StreamsResult(t *testing.T) {
+	results := models.NewResultMap()
+	updateCh := make(chan UpdateMessage, 10)
+	task := NewTask(updateCh, models.AttackParams{}, models.AttackInputs{}, results, vegeta.CompressionNone)
+	task.status = models.AttackResponseStatusRunning
+
+	run(task, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		_, err := io.WriteString(w, "hello world")
+		return err
+	})
+
+	ref := task.Result()
+	if task.Status() != models.AttackResponseStatusCompleted || ref == nil || ref.Size != 11 {
+		t.Fatalf("run() status = %s, result = %v", task.Status(), ref)
+	}
+	if _, err := results.Get(ref.Key); err != nil {
+		t.Errorf("result not stored: %v", err)
+	}
+}
+
+func Test_run_CanceledDiscardsResult(t *testing.T) {
+	results := models.NewResultMap()
+	updateCh := make(chan UpdateMessage, 10)
+	task := NewTask(updateCh, models.AttackParams{}, models.AttackInputs{}, results, vegeta.CompressionNone)
+	task.status = models.AttackResponseStatusRunning
+
+	run(task, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		_, err := io.WriteString(w, "partial")
+		task.status = models.AttackResponseStatusCanceled
+		return err
+	})
+
+	if task.Result() != nil {
+		t.Errorf("run() result = %v, want nil", task.Result())
+	}
+	if _, err := results.Get(task.ID()); err == nil {
+		t.Errorf("partial result stored for canceled attack")
+	}
+}
+
+// blockingStore holds results back once they were read, until released
+type blockingStore struct {
+	*models.ResultMap
+	read, release chan struct{}
+}
+
+func (s *blockingStore) Put(key string, r io.Reader) (models.ResultRef, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return models.ResultRef{}, err
+	}
+	close(s.read)
+	<-s.release
+	return s.ResultMap.Put(key, bytes.NewReader(b))
+}
+
+func Test_task_CancelAfterAttack(t *testing.T) {
+	results := &blockingStore{models.NewResultMap(), make(chan struct{}), make(chan struct{})}
+	updateCh := make(chan UpdateMessage, 10)
+	task := NewTask(updateCh, models.AttackParams{}, models.AttackInputs{}, results, vegeta.CompressionNone)
+
+	err := task.Run(func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		_, err := io.WriteString(w, "result")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The attack returned and its result is being stored, so the cancel is
+	// refused without blocking
+	<-results.read
+	canceled := make(chan error, 1)
+	go func() { canceled <- task.Cancel(models.ActorUser, "too late") }()
+	select {
+	case err := <-canceled:
+		if err == nil {
+			t.Error("Cancel() error = nil, want an error once the attack finished")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Cancel() blocked after the attack returned")
+	}
+
+	close(results.release)
+	for i := 0; i < 10 && task.Status() != models.AttackResponseStatusCompleted; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if task.Status() != models.AttackResponseStatusCompleted || task.Result() == nil {
+		t.Errorf("task status = %s, result = %v, want completed", task.Status(), task.Result())
+	}
+
+	// Canceling a finished task neither blocks nor changes it
+	if err := task.Cancel(models.ActorUser, "too late"); err == nil {
+		t.Error("Cancel() of a completed task error = nil")
+	}
+}
+
+func Test_task_CancelScheduled(t *testing.T) {
+	updateCh := make(chan UpdateMessage, 10)
+	task := NewTask(updateCh, models.AttackParams{}, models.AttackInputs{}, models.NewResultMap(), vegeta.CompressionNone)
+
+	canceled := make(chan error, 1)
+	go func() { canceled <- task.Cancel(models.ActorUser, "not needed") }()
+	select {
+	case err := <-canceled:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Cancel() blocked on a scheduled task")
+	}
+	if task.Status() != models.AttackResponseStatusCanceled {
+		t.Errorf("task status = %s, want canceled", task.Status())
+	}
+}
+
+func Test_run_CompressesResult(t *testing.T) {
+	results := models.NewResultMap()
+	updateCh := make(chan UpdateMessage, 10)
+	task := NewTask(updateCh, models.AttackParams{}, models.AttackInputs{}, results, vegeta.CompressionGzip)
+	task.status = models.AttackResponseStatusRunning
+
+	run(task, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		_, err := io.WriteString(w, strings.Repeat("hello world", 100))
+		return err
+	})
+
+	ref := task.Result()
+	if ref == nil || ref.Encoding != "gzip" || ref.RawSize != 1100 || ref.CompressionRatio() <= 1 {
+		t.Fatalf("run() result = %+v", ref)
+	}
+}
+
+func Test_dispatcher_reap(t *testing.T) {
+	db := models.NewTaskMap()
+	results := models.NewResultMap()
+	old := time.Now().Add(-2 * time.Hour).Format(time.RFC1123)
+
+	ref, _ := results.Put("expired", strings.NewReader("result"))
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "expired", Status: models.AttackResponseStatusCompleted, UpdatedAt: old},
+		Result:     &ref,
+	})
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "pinned", Status: models.AttackResponseStatusCompleted, UpdatedAt: old, Pinned: true},
+	})
+
+	d := NewDispatcher(db, results, Config{Retention: models.RetentionPolicy{MaxAge: time.Hour}}, nil)
+	d.reap(time.Now())
+
+	if _, err := db.GetByID("expired"); err == nil {
+		t.Errorf("expired attack not removed")
+	}
+	if _, err := results.Get("expired"); err == nil {
+		t.Errorf("expired result not removed")
+	}
+	if _, err := db.GetByID("pinned"); err != nil {
+		t.Errorf("pinned attack removed")
+	}
+}
+
+func Test_dispatcher_Pin(t *testing.T) {
+	mockStore := &smocks.IAttackStore{}
+
+	mockStore.On("GetByID", "123").Return(models.AttackDetails{AttackInfo: models.AttackInfo{ID: "123"}}, nil)
+	mockStore.On("Update", "123", mock.MatchedBy(func(a models.AttackDetails) bool {
+		return a.Pinned && len(a.Events) == 1 && a.Events[0].Type == models.AttackEventPinned
+	})).Return(nil)
+
+	d := setupDispatcher(mockStore)
+
+	if err := d.Pin("123", true, 0); err != nil {
+		t.Fatal(err)
+	}
+	mockStore.AssertExpectations(t)
+}
+
+func Test_dispatcher_Revision(t *testing.T) {
+	db := models.NewTaskMap()
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "123", Status: models.AttackResponseStatusCompleted},
+	})
+
+	d := NewDispatcher(db, models.NewResultMap(), Config{}, nil)
+
+	// The attack is checked at its revision, then modified before the write
+	checked, _ := db.GetByID("123")
+	if err := d.Pin("123", true, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Pin("123", false, checked.Revision); errors.Cause(err) != models.ErrRevisionConflict {
+		t.Errorf("Pin() error = %v, want %v", err, models.ErrRevisionConflict)
+	}
+	if err := d.Delete("123", false, checked.Revision); errors.Cause(err) != models.ErrRevisionConflict {
+		t.Errorf("Delete() error = %v, want %v", err, models.ErrRevisionConflict)
+	}
+	stored, err := db.GetByID("123")
+	if err != nil {
+		t.Fatalf("stale Delete() removed attack: %v", err)
+	}
+	if !stored.Pinned {
+		t.Errorf("stale Pin() overwrote the concurrent write")
+	}
+
+	if err := d.Pin("123", false, stored.Revision); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_dispatcher_Delete(t *testing.T) {
+	db := models.NewTaskMap()
+	results := models.NewResultMap()
+
+	ref, _ := results.Put("completed", strings.NewReader("result"))
+	samples, _ := results.Put(models.SamplesKey("completed"), strings.NewReader("samples"))
+	targets, _ := results.Put(models.TargetFileKey("1"), strings.NewReader("GET http://localhost\n"))
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{
+			ID:     "completed",
+			Status: models.AttackResponseStatusCompleted,
+			Params: models.AttackParams{
+				TargetFile: &models.TargetFile{Key: targets.Key, Size: targets.Size},
+			},
+		},
+		Result:  &ref,
+		Samples: &samples,
+	})
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "running", Status: models.AttackResponseStatusRunning},
+	})
+
+	d := NewDispatcher(db, results, Config{}, nil)
+
+	if err := d.Delete("completed", false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := results.Get("completed"); err == nil {
+		t.Errorf("result not removed")
+	}
+
+	if err := d.Delete("running", false, 0); errors.Cause(err) != ErrAttackActive {
+		t.Errorf("Delete() error = %v, want %v", err, ErrAttackActive)
+	}
+	if err := d.Delete("running", true, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetByID("running"); err == nil {
+		t.Errorf("forced delete did not remove attack")
+	}
+}
+
+func Test_dispatcher_DeleteAll(t *testing.T) {
+	db := models.NewTaskMap()
+	_ = db.Add(models.AttackDetails{AttackInfo: models.AttackInfo{ID: "1", Status: models.AttackResponseStatusCompleted}})
+	_ = db.Add(models.AttackDetails{AttackInfo: models.AttackInfo{ID: "2", Status: models.AttackResponseStatusRunning}})
+
+	d := NewDispatcher(db, models.NewResultMap(), Config{}, nil)
+
+	resp := d.DeleteAll(make(models.FilterParams), false)
+	if len(resp.Deleted) != 1 || resp.Deleted[0] != "1" || len(resp.Skipped) != 1 || resp.Skipped[0] != "2" {
+		t.Errorf("DeleteAll() = %+v", resp)
+	}
+}
+
+func Test_dispatcher_Events(t *testing.T) {
+	db := models.NewTaskMap()
+	d := NewDispatcher(db, models.NewResultMap(), Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		<-i
+		return nil
+	})
+
+	quit := make(chan struct{})
+	defer func() {
+		quit <- struct{}{}
+	}()
+
+	go d.Run(quit)
+
+	resp, err := d.Dispatch(models.AttackParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wait := func(status models.AttackStatus) {
+		for i := 0; i < 100; i++ {
+			if attack, _ := db.GetByID(resp.ID); attack.Status == status {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("attack never became %s", status)
+	}
+
+	wait(models.AttackResponseStatusRunning)
+	if err := d.Cancel(resp.ID, true, 0); err != nil {
+		t.Fatal(err)
+	}
+	wait(models.AttackResponseStatusCanceled)
+
+	events, err := d.Events(resp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.AttackEventType{models.AttackEventScheduled, models.AttackEventRunning, models.AttackEventCanceled}
+	got := make([]models.AttackEventType, 0)
+	for _, event := range events {
+		got = append(got, event.Type)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Events() = %v, want %v", got, want)
+	}
+
+	canceled := events[2]
+	if canceled.Actor != models.ActorUser || canceled.Reason == "" || canceled.Time.Before(events[1].Time) {
+		t.Errorf("canceled event = %+v", canceled)
+	}
+}
+
+func Test_dispatcher_ExportImport(t *testing.T) {
+	db := models.NewTaskMap()
+	results := models.NewResultMap()
+
+	ref, _ := results.Put("completed", strings.NewReader("result"))
+	samples, _ := results.Put(models.SamplesKey("completed"), strings.NewReader("samples"))
+	targets, _ := results.Put(models.TargetFileKey("1"), strings.NewReader("GET http://localhost\n"))
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{
+			ID:     "completed",
+			Status: models.AttackResponseStatusCompleted,
+			Params: models.AttackParams{
+				TargetFile: &models.TargetFile{Key: targets.Key, Size: targets.Size},
+			},
+		},
+		Result:  &ref,
+		Samples: &samples,
+	})
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "canceled", Status: models.AttackResponseStatusCanceled},
+	})
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "running", Status: models.AttackResponseStatusRunning},
+	})
+
+	var archive bytes.Buffer
+	if err := NewDispatcher(db, results, Config{}, nil).Export(&archive, make(models.FilterParams)); err != nil {
+		t.Fatal(err)
+	}
+
+	importDB := models.NewTaskMap()
+	importResults := models.NewResultMap()
+	d := NewDispatcher(importDB, importResults, Config{}, nil)
+
+	resp, err := d.Import(bytes.NewReader(archive.Bytes()), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(resp.Imported)
+	if !reflect.DeepEqual(resp.Imported, []string{"canceled", "completed"}) || len(resp.Skipped) != 0 {
+		t.Fatalf("Import() = %+v", resp)
+	}
+
+	attack, _ := importDB.GetByID("completed")
+	if attack.Result == nil || attack.Result.Checksum != ref.Checksum {
+		t.Fatalf("imported result = %+v, want %+v", attack.Result, ref)
+	}
+	rc, _ := importResults.Get(attack.Result.Key)
+	got, _ := ioutil.ReadAll(rc)
+	if string(got) != "result" {
+		t.Errorf("imported result = %s", got)
+	}
+	if n := len(attack.Events); n == 0 || attack.Events[n-1].Type != models.AttackEventImported {
+		t.Errorf("imported events = %+v", attack.Events)
+	}
+	if attack.Samples == nil || attack.Samples.Checksum != samples.Checksum {
+		t.Errorf("imported samples = %+v, want %+v", attack.Samples, samples)
+	} else if rc, err := importResults.Get(attack.Samples.Key); err != nil {
+		t.Errorf("imported samples not stored: %v", err)
+	} else {
+		rc.Close() // nolint: errcheck
+	}
+	f := attack.Params.TargetFile
+	if f == nil || !models.IsTargetFileKey(f.Key) || f.Key == targets.Key || f.Size != targets.Size {
+		t.Fatalf("imported target file = %+v, want a new upload of %+v", f, targets)
+	}
+	rc, _ = importResults.Get(f.Key)
+	got, _ = ioutil.ReadAll(rc)
+	if string(got) != "GET http://localhost\n" {
+		t.Errorf("imported target file = %s", got)
+	}
+
+	resp, err = d.Import(bytes.NewReader(archive.Bytes()), false)
+	if err != nil || len(resp.Imported) != 0 || len(resp.Skipped) != 2 {
+		t.Errorf("Import() again = %+v, %v", resp, err)
+	}
+
+	resp, err = d.Import(bytes.NewReader(archive.Bytes()), true)
+	if err != nil || len(resp.Imported) != 2 {
+		t.Errorf("Import() overwrite = %+v, %v", resp, err)
+	}
+	// The uploads of the replaced attack are dropped
+	if rc, err := importResults.Get(f.Key); err == nil {
+		rc.Close() // nolint: errcheck
+		t.Errorf("upload %s of the replaced attack was kept", f.Key)
+	}
+}
+
+func Test_dispatcher_Import_Mismatch(t *testing.T) {
+	db := models.NewTaskMap()
+	results := models.NewResultMap()
+
+	ref, _ := results.Put("completed", strings.NewReader("result"))
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "completed", Status: models.AttackResponseStatusCompleted},
+		Result:     &ref,
+		Samples:    &models.ResultRef{Key: models.SamplesKey("completed"), Size: 7},
+	})
+	_, _ = results.Put(models.SamplesKey("completed"), strings.NewReader("samples"))
+
+	var archive bytes.Buffer
+	if err := NewDispatcher(db, results, Config{}, nil).Export(&archive, make(models.FilterParams)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The samples do not match their checksum
+	importResults := &recordingStore{models.NewResultMap(), nil}
+	d := NewDispatcher(models.NewTaskMap(), importResults, Config{}, nil)
+	if _, err := d.Import(bytes.NewReader(archive.Bytes()), false); err == nil {
+		t.Fatal("Import() error = nil")
+	}
+	for _, key := range importResults.keys {
+		if rc, err := importResults.Get(key); err == nil {
+			rc.Close() // nolint: errcheck
+			t.Errorf("file %s of the failed attack was kept", key)
+		}
+	}
+}
+
+// recordingStore records the keys stored in it
+type recordingStore struct {
+	*models.ResultMap
+	keys []string
+}
+
+func (s *recordingStore) Put(key string, r io.Reader) (models.ResultRef, error) {
+	s.keys = append(s.keys, key)
+	return s.ResultMap.Put(key, r)
+}
+
+func Test_dispatcher_Import_MismatchOverwrite(t *testing.T) {
+	exportDB := models.NewTaskMap()
+	exportResults := models.NewResultMap()
+	ref, _ := exportResults.Put("completed", strings.NewReader("new result"))
+	_ = exportDB.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "completed", Status: models.AttackResponseStatusCompleted},
+		Result:     &ref,
+		Samples:    &models.ResultRef{Key: models.SamplesKey("completed"), Size: 11},
+	})
+	_, _ = exportResults.Put(models.SamplesKey("completed"), strings.NewReader("new samples"))
+
+	var archive bytes.Buffer
+	if err := NewDispatcher(exportDB, exportResults, Config{}, nil).Export(&archive, make(models.FilterParams)); err != nil {
+		t.Fatal(err)
+	}
+
+	db := models.NewTaskMap()
+	results := models.NewResultMap()
+	oldRef, _ := results.Put("completed", strings.NewReader("old result"))
+	oldSamples, _ := results.Put(models.SamplesKey("completed"), strings.NewReader("old samples"))
+	_ = db.Add(models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "completed", Status: models.AttackResponseStatusCompleted},
+		Result:     &oldRef,
+		Samples:    &oldSamples,
+	})
+
+	// The imported samples do not match their checksum, the stored attack
+	// and its files are left as they were
+	d := NewDispatcher(db, results, Config{}, nil)
+	if _, err := d.Import(bytes.NewReader(archive.Bytes()), true); err == nil {
+		t.Fatal("Import() error = nil")
+	}
+
+	attack, _ := db.GetByID("completed")
+	if attack.Result == nil || *attack.Result != oldRef || attack.Samples == nil || *attack.Samples != oldSamples {
+		t.Fatalf("stored attack = %+v, want it unchanged", attack)
+	}
+	for key, want := range map[string]string{oldRef.Key: "old result", oldSamples.Key: "old samples"} {
+		rc, err := results.Get(key)
+		if err != nil {
+			t.Fatalf("file %s of the stored attack was deleted: %v", key, err)
+		}
+		got, _ := ioutil.ReadAll(rc)
+		rc.Close() // nolint: errcheck
+		if string(got) != want {
+			t.Errorf("file %s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func Test_dispatcher_Import_Invalid(t *testing.T) {
+	d := NewDispatcher(models.NewTaskMap(), models.NewResultMap(), Config{}, nil)
+
+	if _, err := d.Import(strings.NewReader("not an archive"), false); err == nil {
+		t.Error("Import() error = nil")
+	}
+}
+
+func Test_dispatcher_Feeder(t *testing.T) {
+	var gotFeed models.Feed
+	fed := make(chan struct{})
+	d := NewDispatcher(models.NewTaskMap(), models.NewResultMap(), Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		gotFeed = inputs.Feed
+		close(fed)
+		return nil
+	})
+	quit := make(chan struct{})
+	defer close(quit)
+	go d.Run(quit)
+
+	info, err := d.PutFeeder("users", models.FeederFormatCSV, strings.NewReader("user_id,name\n1,alice\n2,bob\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Records != 2 || !reflect.DeepEqual(info.Fields, []string{"name", "user_id"}) {
+		t.Errorf("PutFeeder() = %+v", info)
+	}
+
+	want := models.Feed{{"user_id": "1", "name": "alice"}, {"user_id": "2", "name": "bob"}}
+	if feed, err := d.GetFeeder("users"); err != nil || !reflect.DeepEqual(feed, want) {
+		t.Errorf("GetFeeder() = %v, %v, want %v", feed, err, want)
+	}
+
+	// Attacks are run with the records of their feeder
+	_, err = d.Dispatch(models.AttackParams{Feeder: &models.FeederParams{Name: "users"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-fed:
+	case <-time.After(time.Second):
+		t.Fatal("attack did not run")
+	}
+	if !reflect.DeepEqual(gotFeed, want) {
+		t.Errorf("attack feed = %v, want %v", gotFeed, want)
+	}
+
+	if err := d.DeleteFeeder("users"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Dispatch(models.AttackParams{Feeder: &models.FeederParams{Name: "users"}}); errors.Cause(err) != ErrFeederNotFound {
+		t.Errorf("Dispatch() error = %v, want %v", err, ErrFeederNotFound)
+	}
+	if err := d.DeleteFeeder("users"); errors.Cause(err) != ErrFeederNotFound {
+		t.Errorf("DeleteFeeder() error = %v, want %v", err, ErrFeederNotFound)
+	}
+}
+
+func Test_dispatcher_Targets(t *testing.T) {
+	results := models.NewResultMap()
+	var got []byte
+	done := make(chan struct{})
+	d := NewDispatcher(models.NewTaskMap(), results, Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		defer close(done)
+		rc, err := inputs.Targets()
+		if err != nil {
+			return err
+		}
+		defer rc.Close() // nolint: errcheck
+		got, err = ioutil.ReadAll(rc)
+		return err
+	})
+	quit := make(chan struct{})
+	defer close(quit)
+	go d.Run(quit)
+
+	if _, err := d.PutTargets(models.TargetFormatHTTP, strings.NewReader("GET http://localhost\n@/etc/passwd\n")); errors.Cause(err) != ErrInvalidTargets {
+		t.Errorf("PutTargets() error = %v, want %v", err, ErrInvalidTargets)
+	}
+
+	targets := "GET http://localhost/a\nX-Header: 1\n\nPOST http://localhost/b\n"
+	ref, err := d.PutTargets(models.TargetFormatHTTP, strings.NewReader(targets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Size != int64(len(targets)) {
+		t.Errorf("PutTargets() size = %d, want %d", ref.Size, len(targets))
+	}
+
+	// Attacks read their uploaded target file from the result store
+	resp, err := d.Dispatch(models.AttackParams{TargetFile: &models.TargetFile{Key: ref.Key, Size: ref.Size}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("attack did not run")
+	}
+	if string(got) != targets {
+		t.Errorf("attack targets = %q, want %q", got, targets)
+	}
+
+	// The target file is removed along with the attack
+	for i := 0; i < 100; i++ {
+		if attack, _ := d.Get(resp.ID); attack.Status == models.AttackResponseStatusCompleted {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := d.Delete(resp.ID, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := results.Get(ref.Key); err == nil {
+		t.Errorf("target file %s was not removed", ref.Key)
+	}
+}
+
+func Test_dispatcher_ReplayLog(t *testing.T) {
+	results := models.NewResultMap()
+	var got []byte
+	done := make(chan struct{})
+	d := NewDispatcher(models.NewTaskMap(), results, Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		defer close(done)
+		rc, err := inputs.Log()
+		if err != nil {
+			return err
+		}
+		defer rc.Close() // nolint: errcheck
+		got, err = ioutil.ReadAll(rc)
+		return err
+	})
+	quit := make(chan struct{})
+	defer close(quit)
+	go d.Run(quit)
+
+	replay := models.ReplayParams{BaseURL: "http://localhost"}
+	if _, err := d.PutReplayLog(replay, strings.NewReader("not an access log\n")); errors.Cause(err) != ErrInvalidReplayLog {
+		t.Errorf("PutReplayLog() error = %v, want %v", err, ErrInvalidReplayLog)
+	}
+
+	accessLog := `127.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "GET /items HTTP/1.1" 200 2326 "-" "curl/7.64.1"` + "\n"
+	ref, err := d.PutReplayLog(replay, strings.NewReader(accessLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Attacks read their uploaded access log from the result store
+	replay.Key, replay.Size = ref.Key, ref.Size
+	resp, err := d.Dispatch(models.AttackParams{Replay: &replay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("attack did not run")
+	}
+	if string(got) != accessLog {
+		t.Errorf("attack log = %q, want %q", got, accessLog)
+	}
+
+	// The access log is removed along with the attack
+	for i := 0; i < 100; i++ {
+		if attack, _ := d.Get(resp.ID); attack.Status == models.AttackResponseStatusCompleted {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := d.Delete(resp.ID, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := results.Get(ref.Key); err == nil {
+		t.Errorf("access log %s was not removed", ref.Key)
+	}
+}
+
+func Test_dispatcher_Samples(t *testing.T) {
+	db := models.NewTaskMap()
+	results := models.NewResultMap()
+	d := NewDispatcher(db, results, Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		if inputs.Samples == nil {
+			return nil
+		}
+		enc := json.NewEncoder(inputs.Samples)
+		for seq := uint64(0); seq < 3; seq++ {
+			if err := enc.Encode(models.Sample{Seq: seq, Code: 500}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	quit := make(chan struct{})
+	defer close(quit)
+	go d.Run(quit)
+
+	wait := func(id string) {
+		for i := 0; i < 100; i++ {
+			if attack, _ := d.Get(id); attack.Status == models.AttackResponseStatusCompleted {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("attack %s did not complete", id)
+	}
+
+	// Attacks are only sampled when opted in
+	plain, err := d.Dispatch(models.AttackParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(plain.ID)
+	if _, _, err := d.Samples(plain.ID, models.ListOptions{}); errors.Cause(err) != ErrNoSamples {
+		t.Errorf("Samples() error = %v, want %v", err, ErrNoSamples)
+	}
+
+	resp, err := d.Dispatch(models.AttackParams{Samples: &models.SampleParams{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(resp.ID)
+
+	samples, total, err := d.Samples(resp.ID, models.ListOptions{Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(samples) != 1 || samples[0].Seq != 1 {
+		t.Errorf("Samples() = %v, %d, want the second of 3 samples", samples, total)
+	}
+	if stored, _ := db.GetByID(resp.ID); stored.Samples == nil || stored.Samples.Size == 0 {
+		t.Errorf("stored samples = %v, want their reference", stored.Samples)
+	}
+
+	// The samples are removed along with the attack
+	if err := d.Delete(resp.ID, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := results.Get(models.SamplesKey(resp.ID)); err == nil {
+		t.Error("samples were not removed with the attack")
+	}
+}
+
+func Test_dispatcher_Baseline(t *testing.T) {
+	// Attacks labeled build=slow take ten times as long
+	d := NewDispatcher(models.NewTaskMap(), models.NewResultMap(), Config{}, func(s string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, i chan struct{}) error {
+		latency := 10 * time.Millisecond
+		if params.Labels["build"] == "slow" {
+			latency *= 10
+		}
+		enc := lib.NewEncoder(w)
+		began := time.Now()
+		for seq := uint64(0); seq < 20; seq++ {
+			r := lib.Result{
+				Seq:       seq,
+				Code:      200,
+				Timestamp: began.Add(time.Duration(seq) * time.Millisecond),
+				Latency:   latency + time.Duration(seq)*time.Microsecond,
+			}
+			if err := enc.Encode(&r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	quit := make(chan struct{})
+	defer close(quit)
+	go d.Run(quit)
+
+	dispatch := func(labels map[string]string) string {
+		resp, err := d.Dispatch(models.AttackParams{Labels: labels})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.ID
+	}
+	verdict := func(id string) *models.BaselineVerdict {
+		for i := 0; i < 100; i++ {
+			if v, err := d.Verdict(id); err == nil && v.Status != models.BaselinePending {
+				return v
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("attack %s was not evaluated", id)
+		return nil
+	}
+	wait := func(id string) {
+		for i := 0; i < 100; i++ {
+			if attack, _ := d.Get(id); attack.Status == models.AttackResponseStatusCompleted {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("attack %s did not complete", id)
+	}
+
+	maxRegression := 0.1
+	rules := []models.BaselineRule{{Metric: models.BaselineMetricP99, MaxRegression: &maxRegression}}
+
+	base := dispatch(map[string]string{"service": "checkout", "build": "fast"})
+	wait(base)
+
+	// Baseline labels must be set on the attack
+	err := d.SetBaseline(base, &models.Baseline{Labels: map[string]string{"service": "cart"}, Rules: rules}, 0)
+	if errors.Cause(err) != ErrInvalidBaseline {
+		t.Errorf("SetBaseline() error = %v, want %v", err, ErrInvalidBaseline)
+	}
+	if err := d.SetBaseline(base, &models.Baseline{Labels: map[string]string{"service": "checkout"}, Rules: rules}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Attacks without a matching baseline have no verdict
+	other := dispatch(map[string]string{"service": "cart"})
+	wait(other)
+	if _, err := d.Verdict(other); errors.Cause(err) != ErrNoBaseline {
+		t.Errorf("Verdict() error = %v, want %v", err, ErrNoBaseline)
+	}
+
+	if v := verdict(dispatch(map[string]string{"service": "checkout", "build": "fast"})); v.Status != models.BaselinePassed || v.Baseline != base {
+		t.Errorf("Verdict() = %+v, want passed against %s", v, base)
+	}
+	slow := dispatch(map[string]string{"service": "checkout", "build": "slow"})
+	if v := verdict(slow); v.Status != models.BaselineFailed || len(v.Checks) != 1 || v.Checks[0].Passed {
+		t.Errorf("Verdict() = %+v, want a failed p99 check", v)
+	}
+
+	// Marking another baseline of the same labels replaces the first one
+	if err := d.SetBaseline(slow, &models.Baseline{Labels: map[string]string{"service": "checkout"}, Rules: rules}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if attack, _ := d.db.GetByID(base); attack.Baseline != nil {
+		t.Errorf("baseline %s was not replaced", base)
+	}
+	if v := verdict(dispatch(map[string]string{"service": "checkout", "build": "fast"})); v.Status != models.BaselinePassed || v.Baseline != slow {
+		t.Errorf("Verdict() = %+v, want passed against %s", v, slow)
+	}
+}
@@ -0,0 +1,74 @@
+package dispatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+)
+
+// ErrFeederNotFound is returned when an attack or request refers to a data
+// feeder that was not uploaded
+var ErrFeederNotFound = errors.New("feeder not found")
+
+// PutFeeder parses a data feeder and stores its records in the result
+// store as JSON Lines, replacing any feeder of the same name
+func (d *dispatcher) PutFeeder(name, format string, r io.Reader) (*models.FeederInfo, error) {
+	if err := models.ValidateFeederName(name); err != nil {
+		return nil, err
+	}
+
+	feed, err := models.ParseFeed(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, record := range feed {
+		if err := enc.Encode(record); err != nil {
+			return nil, errors.Wrap(err, "failed to encode feeder record")
+		}
+	}
+	if _, err := d.results.Put(models.FeederKey(name), &buf); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to store feeder %s", name))
+	}
+
+	info := feed.Info(name)
+	d.log(nil).WithField("feeder", name).Infof("stored feeder with %d records", info.Records)
+	return &info, nil
+}
+
+// GetFeeder returns the records of a stored data feeder
+func (d *dispatcher) GetFeeder(name string) (models.Feed, error) {
+	if err := models.ValidateFeederName(name); err != nil {
+		return nil, err
+	}
+
+	r, err := d.results.Get(models.FeederKey(name))
+	if err != nil {
+		return nil, errors.Wrap(ErrFeederNotFound, name)
+	}
+	defer r.Close() // nolint: errcheck
+
+	feed, err := models.ParseFeed(r, models.FeederFormatJSONL)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to read feeder %s", name))
+	}
+	return feed, nil
+}
+
+// DeleteFeeder removes a stored data feeder
+func (d *dispatcher) DeleteFeeder(name string) error {
+	if err := models.ValidateFeederName(name); err != nil {
+		return err
+	}
+
+	if err := d.results.Delete(models.FeederKey(name)); err != nil {
+		return errors.Wrap(ErrFeederNotFound, name)
+	}
+	return nil
+}
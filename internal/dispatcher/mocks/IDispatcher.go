@@ -2,6 +2,7 @@
 
 package mocks
 
+import io "io"
 import mock "github.com/stretchr/testify/mock"
 import models "vegeta-server/models"
 
@@ -10,13 +11,57 @@ type IDispatcher struct {
 	mock.Mock
 }
 
-// Cancel provides a mock function with given fields: _a0, _a1
-func (_m *IDispatcher) Cancel(_a0 string, _a1 bool) error {
-	ret := _m.Called(_a0, _a1)
+// Cancel provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IDispatcher) Cancel(_a0 string, _a1 bool, _a2 int64) error {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, bool) error); ok {
+	if rf, ok := ret.Get(0).(func(string, bool, int64) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IDispatcher) Delete(_a0 string, _a1 bool, _a2 int64) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, bool, int64) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteAll provides a mock function with given fields: _a0, _a1
+func (_m *IDispatcher) DeleteAll(_a0 models.FilterParams, _a1 bool) *models.AttackDeleteResponse {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *models.AttackDeleteResponse
+	if rf, ok := ret.Get(0).(func(models.FilterParams, bool) *models.AttackDeleteResponse); ok {
 		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.AttackDeleteResponse)
+		}
+	}
+
+	return r0
+}
+
+// DeleteFeeder provides a mock function with given fields: _a0
+func (_m *IDispatcher) DeleteFeeder(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -47,6 +92,43 @@ func (_m *IDispatcher) Dispatch(_a0 models.AttackParams) (*models.AttackResponse
 	return r0, r1
 }
 
+// Events provides a mock function with given fields: _a0
+func (_m *IDispatcher) Events(_a0 string) ([]models.AttackEvent, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []models.AttackEvent
+	if rf, ok := ret.Get(0).(func(string) []models.AttackEvent); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AttackEvent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Export provides a mock function with given fields: _a0, _a1
+func (_m *IDispatcher) Export(_a0 io.Writer, _a1 models.FilterParams) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(io.Writer, models.FilterParams) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: _a0
 func (_m *IDispatcher) Get(_a0 string) (*models.AttackResponse, error) {
 	ret := _m.Called(_a0)
@@ -70,23 +152,238 @@ func (_m *IDispatcher) Get(_a0 string) (*models.AttackResponse, error) {
 	return r0, r1
 }
 
-// List provides a mock function with given fields: _a0
-func (_m *IDispatcher) List(_a0 models.FilterParams) []*models.AttackResponse {
+// GetFeeder provides a mock function with given fields: _a0
+func (_m *IDispatcher) GetFeeder(_a0 string) (models.Feed, error) {
 	ret := _m.Called(_a0)
 
-	var r0 []*models.AttackResponse
-	if rf, ok := ret.Get(0).(func(models.FilterParams) []*models.AttackResponse); ok {
+	var r0 models.Feed
+	if rf, ok := ret.Get(0).(func(string) models.Feed); ok {
 		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(models.Feed)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: _a0, _a1
+func (_m *IDispatcher) Import(_a0 io.Reader, _a1 bool) (*models.AttackImportResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *models.AttackImportResponse
+	if rf, ok := ret.Get(0).(func(io.Reader, bool) *models.AttackImportResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.AttackImportResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(io.Reader, bool) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: _a0, _a1
+func (_m *IDispatcher) List(_a0 models.FilterParams, _a1 models.ListOptions) ([]*models.AttackResponse, int) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []*models.AttackResponse
+	if rf, ok := ret.Get(0).(func(models.FilterParams, models.ListOptions) []*models.AttackResponse); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.AttackResponse)
 		}
 	}
 
+	var r1 int
+	if rf, ok := ret.Get(1).(func(models.FilterParams, models.ListOptions) int); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Int(1)
+	}
+
+	return r0, r1
+}
+
+// ListIds provides a mock function with given fields: _a0
+func (_m *IDispatcher) ListIds(_a0 models.FilterParams) []*models.AttackBaseInfo {
+	ret := _m.Called(_a0)
+
+	var r0 []*models.AttackBaseInfo
+	if rf, ok := ret.Get(0).(func(models.FilterParams) []*models.AttackBaseInfo); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.AttackBaseInfo)
+		}
+	}
+
+	return r0
+}
+
+// Pin provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IDispatcher) Pin(_a0 string, _a1 bool, _a2 int64) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, bool, int64) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
 	return r0
 }
 
+// PutFeeder provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IDispatcher) PutFeeder(_a0 string, _a1 string, _a2 io.Reader) (*models.FeederInfo, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *models.FeederInfo
+	if rf, ok := ret.Get(0).(func(string, string, io.Reader) *models.FeederInfo); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.FeederInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, io.Reader) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutReplayLog provides a mock function with given fields: _a0, _a1
+func (_m *IDispatcher) PutReplayLog(_a0 models.ReplayParams, _a1 io.Reader) (models.ResultRef, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 models.ResultRef
+	if rf, ok := ret.Get(0).(func(models.ReplayParams, io.Reader) models.ResultRef); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(models.ResultRef)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.ReplayParams, io.Reader) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutTargets provides a mock function with given fields: _a0, _a1
+func (_m *IDispatcher) PutTargets(_a0 string, _a1 io.Reader) (models.ResultRef, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 models.ResultRef
+	if rf, ok := ret.Get(0).(func(string, io.Reader) models.ResultRef); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(models.ResultRef)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, io.Reader) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Run provides a mock function with given fields: _a0
 func (_m *IDispatcher) Run(_a0 chan struct{}) {
 	_m.Called(_a0)
 }
+
+// Samples provides a mock function with given fields: _a0, _a1
+func (_m *IDispatcher) Samples(_a0 string, _a1 models.ListOptions) ([]models.Sample, int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []models.Sample
+	if rf, ok := ret.Get(0).(func(string, models.ListOptions) []models.Sample); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Sample)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(string, models.ListOptions) int); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Int(1)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, models.ListOptions) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SetBaseline provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IDispatcher) SetBaseline(_a0 string, _a1 *models.Baseline, _a2 int64) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *models.Baseline, int64) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Verdict provides a mock function with given fields: _a0
+func (_m *IDispatcher) Verdict(_a0 string) (*models.BaselineVerdict, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *models.BaselineVerdict
+	if rf, ok := ret.Get(0).(func(string) *models.BaselineVerdict); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BaselineVerdict)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
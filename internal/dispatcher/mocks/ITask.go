@@ -3,7 +3,6 @@
 package mocks
 
 import dispatcher "vegeta-server/internal/dispatcher"
-import io "io"
 import mock "github.com/stretchr/testify/mock"
 import models "vegeta-server/models"
 import time "time"
@@ -13,13 +12,13 @@ type ITask struct {
 	mock.Mock
 }
 
-// Cancel provides a mock function with given fields:
-func (_m *ITask) Cancel() error {
-	ret := _m.Called()
+// Cancel provides a mock function with given fields: _a0, _a1
+func (_m *ITask) Cancel(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -28,11 +27,11 @@ func (_m *ITask) Cancel() error {
 }
 
 // Complete provides a mock function with given fields: _a0
-func (_m *ITask) Complete(_a0 io.Reader) error {
+func (_m *ITask) Complete(_a0 models.ResultRef) error {
 	ret := _m.Called(_a0)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(io.Reader) error); ok {
+	if rf, ok := ret.Get(0).(func(models.ResultRef) error); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Error(0)
@@ -55,13 +54,13 @@ func (_m *ITask) CreatedAt() time.Time {
 	return r0
 }
 
-// Fail provides a mock function with given fields:
-func (_m *ITask) Fail() error {
-	ret := _m.Called()
+// Fail provides a mock function with given fields: _a0
+func (_m *ITask) Fail(_a0 string) error {
+	ret := _m.Called(_a0)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -98,15 +97,15 @@ func (_m *ITask) Params() models.AttackParams {
 }
 
 // Result provides a mock function with given fields:
-func (_m *ITask) Result() io.Reader {
+func (_m *ITask) Result() *models.ResultRef {
 	ret := _m.Called()
 
-	var r0 io.Reader
-	if rf, ok := ret.Get(0).(func() io.Reader); ok {
+	var r0 *models.ResultRef
+	if rf, ok := ret.Get(0).(func() *models.ResultRef); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(io.Reader)
+			r0 = ret.Get(0).(*models.ResultRef)
 		}
 	}
 
@@ -127,6 +126,22 @@ func (_m *ITask) Run(_a0 dispatcher.AttackFunc) error {
 	return r0
 }
 
+// Samples provides a mock function with given fields:
+func (_m *ITask) Samples() *models.ResultRef {
+	ret := _m.Called()
+
+	var r0 *models.ResultRef
+	if rf, ok := ret.Get(0).(func() *models.ResultRef); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ResultRef)
+		}
+	}
+
+	return r0
+}
+
 // SendUpdate provides a mock function with given fields:
 func (_m *ITask) SendUpdate() {
 	_m.Called()
@@ -3,21 +3,21 @@
 package mocks
 
 import dispatcher "vegeta-server/internal/dispatcher"
-import io "io"
 import mock "github.com/stretchr/testify/mock"
+import models "vegeta-server/models"
 
 // ITaskActions is an autogenerated mock type for the ITaskActions type
 type ITaskActions struct {
 	mock.Mock
 }
 
-// Cancel provides a mock function with given fields:
-func (_m *ITaskActions) Cancel() error {
-	ret := _m.Called()
+// Cancel provides a mock function with given fields: _a0, _a1
+func (_m *ITaskActions) Cancel(_a0 string, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -26,11 +26,11 @@ func (_m *ITaskActions) Cancel() error {
 }
 
 // Complete provides a mock function with given fields: _a0
-func (_m *ITaskActions) Complete(_a0 io.Reader) error {
+func (_m *ITaskActions) Complete(_a0 models.ResultRef) error {
 	ret := _m.Called(_a0)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(io.Reader) error); ok {
+	if rf, ok := ret.Get(0).(func(models.ResultRef) error); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Error(0)
@@ -39,13 +39,13 @@ func (_m *ITaskActions) Complete(_a0 io.Reader) error {
 	return r0
 }
 
-// Fail provides a mock function with given fields:
-func (_m *ITaskActions) Fail() error {
-	ret := _m.Called()
+// Fail provides a mock function with given fields: _a0
+func (_m *ITaskActions) Fail(_a0 string) error {
+	ret := _m.Called(_a0)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -2,7 +2,6 @@
 
 package mocks
 
-import io "io"
 import mock "github.com/stretchr/testify/mock"
 import models "vegeta-server/models"
 import time "time"
@@ -55,15 +54,31 @@ func (_m *ITaskGetter) Params() models.AttackParams {
 }
 
 // Result provides a mock function with given fields:
-func (_m *ITaskGetter) Result() io.Reader {
+func (_m *ITaskGetter) Result() *models.ResultRef {
 	ret := _m.Called()
 
-	var r0 io.Reader
-	if rf, ok := ret.Get(0).(func() io.Reader); ok {
+	var r0 *models.ResultRef
+	if rf, ok := ret.Get(0).(func() *models.ResultRef); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(io.Reader)
+			r0 = ret.Get(0).(*models.ResultRef)
+		}
+	}
+
+	return r0
+}
+
+// Samples provides a mock function with given fields:
+func (_m *ITaskGetter) Samples() *models.ResultRef {
+	ret := _m.Called()
+
+	var r0 *models.ResultRef
+	if rf, ok := ret.Get(0).(func() *models.ResultRef); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ResultRef)
 		}
 	}
 
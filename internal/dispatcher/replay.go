@@ -0,0 +1,40 @@
+package dispatcher
+
+import (
+	"io"
+	"vegeta-server/models"
+	"vegeta-server/pkg/vegeta"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrInvalidReplayLog is returned when no requests can be read from an
+// uploaded access log
+var ErrInvalidReplayLog = errors.New("invalid access log")
+
+// PutReplayLog streams the access log of a replay into the result store and
+// reads back all of its requests, returning its reference if there are any
+func (d *dispatcher) PutReplayLog(params models.ReplayParams, r io.Reader) (models.ResultRef, error) {
+	key := models.ReplayLogKey(uuid.NewV4().String())
+	ref, err := d.results.Put(key, r)
+	if err != nil {
+		return models.ResultRef{}, errors.Wrap(err, "failed to store access log")
+	}
+
+	rc, err := d.results.Get(key)
+	if err != nil {
+		d.removeUpload(key)
+		return models.ResultRef{}, errors.Wrap(err, "failed to read stored access log")
+	}
+	n, skipped, err := vegeta.ValidateReplayLog(params, rc)
+	rc.Close() // nolint: errcheck
+	if err != nil {
+		d.removeUpload(key)
+		return models.ResultRef{}, errors.Wrap(ErrInvalidReplayLog, err.Error())
+	}
+
+	d.log(log.Fields{"Key": key, "Size": ref.Size}).Infof("stored access log with %d requests, skipped %d lines", n, skipped)
+	return ref, nil
+}
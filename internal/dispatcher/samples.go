@@ -0,0 +1,80 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"io"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNoSamples is returned for attacks that were not sampled, or whose
+// samples are not stored yet
+var ErrNoSamples = errors.New("attack has no samples")
+
+// Samples returns the page of the samples of an attack given by the offset
+// and limit of the list options, along with the total number of samples.
+// Samples are stored once the attack completes.
+func (d *dispatcher) Samples(id string, opts models.ListOptions) ([]models.Sample, int, error) {
+	d.log(log.Fields{"ID": id}).Debug("getting attack samples")
+
+	attack, err := d.db.GetByID(id)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get item by ID")
+	}
+	if attack.Params.Samples == nil {
+		return nil, 0, errors.Wrap(ErrNoSamples, "attack is not sampled")
+	}
+	if attack.Status != models.AttackResponseStatusCompleted {
+		return nil, 0, errors.Wrap(ErrNoSamples, "samples are stored once the attack completes")
+	}
+
+	rc, err := d.results.Get(samplesKey(attack))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to get samples")
+	}
+	defer rc.Close() // nolint: errcheck
+
+	samples := make([]models.Sample, 0)
+	total := 0
+	dec := json.NewDecoder(rc)
+	for {
+		var sample models.Sample
+		if err := dec.Decode(&sample); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to read samples")
+		}
+		if total >= opts.Offset && (opts.Limit <= 0 || len(samples) < opts.Limit) {
+			samples = append(samples, sample)
+		}
+		total++
+	}
+
+	return samples, total, nil
+}
+
+// samplesKey returns the key of the stored samples of an attack, empty if
+// it has none. Attacks stored before samples were referenced keep them
+// under the key derived from their ID.
+func samplesKey(attack models.AttackDetails) string {
+	if attack.Samples != nil {
+		return attack.Samples.Key
+	}
+	if attack.Params.Samples == nil || attack.Status != models.AttackResponseStatusCompleted {
+		return ""
+	}
+	return models.SamplesKey(attack.ID)
+}
+
+// removeSamples deletes the samples of a completed sampled attack
+func (d *dispatcher) removeSamples(attack models.AttackDetails) {
+	key := samplesKey(attack)
+	if key == "" {
+		return
+	}
+	if err := d.results.Delete(key); err != nil {
+		d.log(log.Fields{"Key": key}).WithError(err).Warning("failed to delete samples")
+	}
+}
@@ -0,0 +1,59 @@
+package dispatcher
+
+import (
+	"io"
+	"vegeta-server/models"
+	"vegeta-server/pkg/vegeta"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrInvalidTargets is returned when the targets of an uploaded target file
+// cannot be read
+var ErrInvalidTargets = errors.New("invalid targets")
+
+// PutTargets streams a target file into the result store and reads back
+// all of its targets, one at a time, returning its reference if they are valid
+func (d *dispatcher) PutTargets(format string, r io.Reader) (models.ResultRef, error) {
+	key := models.TargetFileKey(uuid.NewV4().String())
+	ref, err := d.results.Put(key, r)
+	if err != nil {
+		return models.ResultRef{}, errors.Wrap(err, "failed to store targets")
+	}
+
+	rc, err := d.results.Get(key)
+	if err != nil {
+		d.removeUpload(key)
+		return models.ResultRef{}, errors.Wrap(err, "failed to read stored targets")
+	}
+	n, err := vegeta.ValidateTargetFile(format, rc)
+	rc.Close() // nolint: errcheck
+	if err != nil {
+		d.removeUpload(key)
+		return models.ResultRef{}, errors.Wrap(ErrInvalidTargets, err.Error())
+	}
+
+	d.log(log.Fields{"Key": key, "Size": ref.Size}).Infof("stored target file with %d targets", n)
+	return ref, nil
+}
+
+// removeUploads deletes the uploaded target file or access log of an
+// attack, if any
+func (d *dispatcher) removeUploads(params models.AttackParams) {
+	// Keys of imported attacks are not trusted to refer to uploads
+	if f := params.TargetFile; f != nil && models.IsTargetFileKey(f.Key) {
+		d.removeUpload(f.Key)
+	}
+	if r := params.Replay; r != nil && models.IsReplayLogKey(r.Key) {
+		d.removeUpload(r.Key)
+	}
+}
+
+// removeUpload deletes an uploaded file, logging failures
+func (d *dispatcher) removeUpload(key string) {
+	if err := d.results.Delete(key); err != nil {
+		d.log(log.Fields{"Key": key}).WithError(err).Warning("failed to delete upload")
+	}
+}
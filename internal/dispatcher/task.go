@@ -1,7 +1,6 @@
 package dispatcher
 
 import (
-	"bytes"
 	"fmt"
 	"sync"
 	"time"
@@ -11,14 +10,19 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"io"
-	"io/ioutil"
 	"vegeta-server/models"
+	"vegeta-server/pkg/vegeta"
 
 	"github.com/pkg/errors"
 )
 
-// AttackFunc provides type used by the attacker class
-type AttackFunc func(string, models.AttackParams, chan struct{}) (io.Reader, error)
+// AttackFunc provides type used by the attacker class. The attack reads its
+// data feeder and uploaded targets from the inputs, and encoded results are
+// written to the io.Writer while the attack runs.
+type AttackFunc func(string, models.AttackParams, models.AttackInputs, io.Writer, chan struct{}) error
+
+// errAttackCanceled aborts storing the partial result of a canceled attack
+var errAttackCanceled = errors.New("attack canceled")
 
 // ITask defines an interface for attack tasks
 type ITask interface {
@@ -38,8 +42,10 @@ type ITaskGetter interface {
 	CreatedAt() time.Time
 	// UpdatedAt returns the updated at timestamp
 	UpdatedAt() time.Time
-	// Result returns the result as a byte array
-	Result() io.Reader
+	// Result returns the reference to the stored result
+	Result() *models.ResultRef
+	// Samples returns the reference to the stored samples
+	Samples() *models.ResultRef
 }
 
 // ITaskActions defines an interface for the task action methods
@@ -47,11 +53,11 @@ type ITaskActions interface {
 	// Run the attack using the configured attack function.
 	Run(AttackFunc) error
 	// Complete changes task status to completed
-	Complete(io.Reader) error
-	// Cancel changes task status to canceled
-	Cancel() error
-	// Fail changes task status to failed
-	Fail() error
+	Complete(models.ResultRef) error
+	// Cancel changes task status to canceled, recording the actor and reason
+	Cancel(string, string) error
+	// Fail changes task status to failed, recording the reason
+	Fail(string) error
 	// SendUpdate sends an update on the update chan to the caller
 	SendUpdate()
 }
@@ -61,24 +67,47 @@ type ITaskActions interface {
 type UpdateMessage struct {
 	ID     string
 	Status models.AttackStatus
+	// Event describes the status change for the attack's event log
+	Event models.AttackEvent
 }
 
 type task struct {
-	mu     sync.RWMutex
-	id     string
-	params models.AttackParams
-	status models.AttackStatus
-	result *bytes.Buffer
+	mu      sync.RWMutex
+	id      string
+	params  models.AttackParams
+	inputs  models.AttackInputs
+	status  models.AttackStatus
+	result  *models.ResultRef
+	samples *models.ResultRef
 
 	createdAt time.Time
 	updatedAt time.Time
 
+	// actor and reason of the latest status change
+	actor  string
+	reason string
+
 	updateCh chan UpdateMessage
+	// quit is closed once to stop the attack, so cancels never block
 	quit     chan struct{}
+	quitOnce sync.Once
+	// finished is set once the attack function returned, after which the
+	// attack can no longer be canceled
+	finished bool
+
+	results     models.IResultStore
+	compression vegeta.Compression
 }
 
-// NewTask returns a new instance of a task object
-func NewTask(updateCh chan UpdateMessage, params models.AttackParams) *task {
+// NewTask returns a new instance of a task object, which streams its result
+// into the result store using the given compression
+func NewTask(
+	updateCh chan UpdateMessage,
+	params models.AttackParams,
+	inputs models.AttackInputs,
+	results models.IResultStore,
+	compression vegeta.Compression,
+) *task {
 	prfix := ""
 
 	if params.ID != "" {
@@ -91,14 +120,24 @@ func NewTask(updateCh chan UpdateMessage, params models.AttackParams) *task {
 		sync.RWMutex{},
 		id,
 		params,
+		inputs,
 		models.AttackResponseStatusScheduled,
-		bytes.NewBuffer(make([]byte, 0)),
+		nil,
+		nil,
 
 		time.Now(),
 		time.Now(),
 
+		models.ActorUser,
+		"",
+
 		updateCh,
 		make(chan struct{}),
+		sync.Once{},
+		false,
+
+		results,
+		compression,
 	}
 
 	t.log(nil).Debug("creating new task")
@@ -121,6 +160,7 @@ func (t *task) Run(fn AttackFunc) error {
 
 	t.mu.Lock()
 	t.status = models.AttackResponseStatusRunning
+	t.actor, t.reason = models.ActorDispatcher, ""
 	t.mu.Unlock()
 
 	t.SendUpdate()
@@ -129,7 +169,7 @@ func (t *task) Run(fn AttackFunc) error {
 }
 
 // Complete marks a task as completed
-func (t *task) Complete(result io.Reader) error {
+func (t *task) Complete(result models.ResultRef) error {
 	status := t.Status()
 	id := t.ID()
 
@@ -137,14 +177,10 @@ func (t *task) Complete(result io.Reader) error {
 		return fmt.Errorf("cannot mark completed for task %s with status %s", id, status)
 	}
 
-	buf, err := ioutil.ReadAll(result)
-	if err != nil {
-		return errors.Wrap(err, "failed to read result")
-	}
-
 	t.mu.Lock()
 	t.status = models.AttackResponseStatusCompleted
-	t.result = bytes.NewBuffer(buf)
+	t.result = &result
+	t.actor, t.reason = models.ActorDispatcher, ""
 	t.mu.Unlock()
 
 	t.SendUpdate()
@@ -154,20 +190,25 @@ func (t *task) Complete(result io.Reader) error {
 	return nil
 }
 
-// Cancel invokes the context cancel and marks a task as canceled
-func (t *task) Cancel() error {
-	status := t.Status()
-	id := t.ID()
-
+// Cancel stops the attack and marks a task as canceled. Attacks whose attack
+// function already returned cannot be canceled anymore.
+func (t *task) Cancel(actor, reason string) error {
+	t.mu.Lock()
+	status, id := t.status, t.id
 	if status == models.AttackResponseStatusCompleted || status == models.AttackResponseStatusFailed || status == models.AttackResponseStatusCanceled { // nolint: lll
+		t.mu.Unlock()
 		return fmt.Errorf("cannot cancel task %s with status %s", id, status)
 	}
-
-	t.mu.Lock()
-	t.quit <- struct{}{}
+	if t.finished {
+		t.mu.Unlock()
+		return fmt.Errorf("cannot cancel task %s, its attack already finished", id)
+	}
 	t.status = models.AttackResponseStatusCanceled
+	t.actor, t.reason = actor, reason
 	t.mu.Unlock()
 
+	t.quitOnce.Do(func() { close(t.quit) })
+
 	t.SendUpdate()
 
 	t.log(nil).Debug("canceled")
@@ -176,9 +217,10 @@ func (t *task) Cancel() error {
 }
 
 // Fail marks a task as failed
-func (t *task) Fail() error {
+func (t *task) Fail(reason string) error {
 	t.mu.Lock()
 	t.status = models.AttackResponseStatusFailed
+	t.actor, t.reason = models.ActorDispatcher, reason
 	t.mu.Unlock()
 
 	t.SendUpdate()
@@ -191,12 +233,19 @@ func (t *task) Fail() error {
 func (t *task) SendUpdate() {
 	t.mu.Lock()
 	t.updatedAt = time.Now()
+	msg := UpdateMessage{
+		ID:     t.id,
+		Status: t.status,
+		Event: models.AttackEvent{
+			Type:   models.AttackEventType(t.status),
+			Time:   t.updatedAt,
+			Actor:  t.actor,
+			Reason: t.reason,
+		},
+	}
 	t.mu.Unlock()
 
-	t.updateCh <- UpdateMessage{
-		t.ID(),
-		t.Status(),
-	}
+	t.updateCh <- msg
 }
 
 // ID returns the task identifier
@@ -235,29 +284,145 @@ func (t *task) UpdatedAt() time.Time {
 	return t.updatedAt
 }
 
-// Result returns the result as a io.Reader
-func (t *task) Result() io.Reader {
+// Result returns the reference to the stored result, nil until completed
+func (t *task) Result() *models.ResultRef {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 	return t.result
 }
 
+// Samples returns the reference to the stored samples, nil unless the attack
+// is sampled and completed
+func (t *task) Samples() *models.ResultRef {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.samples
+}
+
+// storeResult captures the outcome of streaming a result into the result store
+type storeResult struct {
+	ref models.ResultRef
+	err error
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
 func run(t *task, fn AttackFunc) {
-	buf, err := fn(t.id, t.params, t.quit)
+	// Results are piped into the store while the attack runs, so memory use
+	// is bounded by the store's chunk size rather than the attack length,
+	// except for the in-memory ResultMap which buffers whole results.
+	var rawSize int64
+	pr, pw := io.Pipe()
+	stored := make(chan storeResult, 1)
+	go func() {
+		ref, err := t.results.Put(t.id, pr)
+		// Unblock the attack if the store gave up early
+		pr.CloseWithError(err) // nolint: errcheck
+		stored <- storeResult{ref, err}
+	}()
+
+	inputs, closeSamples := t.storeSamples()
+
+	cw, err := vegeta.NewCompressWriter(pw, t.compression)
+	if err == nil {
+		raw := &countingWriter{w: cw}
+		err = fn(t.id, t.params, inputs, raw, t.quit)
+		if err == nil {
+			err = cw.Close()
+		}
+		rawSize = raw.n
+	}
+
+	// Settle whether the attack was canceled, refusing cancels from now on
+	t.mu.Lock()
+	t.finished = true
+	canceled := t.status == models.AttackResponseStatusCanceled
+	t.mu.Unlock()
+
 	if err != nil {
-		_ = t.Fail()
+		pw.CloseWithError(err) // nolint: errcheck
+		<-stored
+		closeSamples(err)
+		_ = t.Fail(err.Error())
+		return
 	}
 
-	// Attack was canceled
-	if buf == nil {
+	// Attack was canceled, discard the partial result
+	if canceled {
+		pw.CloseWithError(errAttackCanceled) // nolint: errcheck
+		<-stored
+		closeSamples(errAttackCanceled)
 		return
 	}
 
+	pw.Close() // nolint: errcheck
+	res := <-stored
+	closeSamples(res.err)
+	if res.err != nil {
+		log.WithError(res.err).Error("Failed to store result")
+		_ = t.Fail(res.err.Error())
+		return
+	}
+
+	if t.compression != vegeta.CompressionNone && t.compression != "" {
+		res.ref.Encoding = string(t.compression)
+	}
+	res.ref.RawSize = rawSize
+
 	// Mark attack as completed
-	err = t.Complete(buf)
+	err = t.Complete(res.ref)
 	if err != nil {
 		log.WithError(err).Error("Failed to Complete")
-		_ = t.Fail()
+		_ = t.Fail(err.Error())
+	}
+}
+
+// storeSamples pipes the samples of a sampled attack into the result store,
+// returning the inputs of the attack with the sample writer set. The returned
+// function ends the samples with the outcome of the attack, discarding them
+// unless it is nil, and waits for the store.
+func (t *task) storeSamples() (models.AttackInputs, func(error)) {
+	inputs := t.inputs
+	if t.params.Samples == nil {
+		return inputs, func(error) {}
+	}
+
+	key := models.SamplesKey(t.id)
+	pr, pw := io.Pipe()
+	stored := make(chan storeResult, 1)
+	go func() {
+		ref, err := t.results.Put(key, pr)
+		pr.CloseWithError(err) // nolint: errcheck
+		stored <- storeResult{ref, err}
+	}()
+	inputs.Samples = pw
+
+	return inputs, func(outcome error) {
+		if outcome != nil {
+			pw.CloseWithError(outcome) // nolint: errcheck
+			<-stored
+			return
+		}
+		pw.Close() // nolint: errcheck
+		res := <-stored
+		if res.err != nil {
+			t.log(log.Fields{"Key": key}).WithError(res.err).Warning("failed to store samples")
+			return
+		}
+		t.mu.Lock()
+		t.samples = &res.ref
+		t.mu.Unlock()
 	}
 }
 
@@ -284,12 +449,8 @@ func attackDetailFromTask(t ITaskGetter) models.AttackDetails {
 			CreatedAt: t.CreatedAt().Format(time.RFC1123),
 			UpdatedAt: t.UpdatedAt().Format(time.RFC1123),
 		},
-	}
-
-	if t.Status() == models.AttackResponseStatusCompleted {
-		result := t.Result()
-		buf, _ := ioutil.ReadAll(result)
-		details.Result = buf
+		Result:  t.Result(),
+		Samples: t.Samples(),
 	}
 
 	return details
@@ -0,0 +1,48 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetArchiveEndpoint implements a handler for the GET /api/v1/archive endpoint,
+// exporting the attacks matching the same filters as GET /api/v1/attack
+func (e *Endpoints) GetArchiveEndpoint(c *gin.Context) {
+	filterMap, err := attackFilters(c)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("vegeta-attacks-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// The archive is streamed, so errors can only be logged once it started
+	if err := e.dispatcher.Export(c.Writer, filterMap); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// PostArchiveEndpoint implements a handler for the POST /api/v1/archive endpoint,
+// importing the attacks of an archive passed as request body
+func (e *Endpoints) PostArchiveEndpoint(c *gin.Context) {
+	overwrite, err := strconv.ParseBool(c.DefaultQuery("overwrite", "false"))
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	resp, err := e.dispatcher.Import(c.Request.Body, overwrite)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,127 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"vegeta-server/internal/dispatcher"
+	dmocks "vegeta-server/internal/dispatcher/mocks"
+	"vegeta-server/models"
+
+	"github.com/stretchr/testify/mock"
+
+	assert "gopkg.in/go-playground/assert.v1"
+)
+
+func TestEndpoints_GetArchiveEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Bad Request - filter",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/archive?rate_min=many", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Export", mock.Anything, mock.AnythingOfType("models.FilterParams")).
+						Return(nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/archive?status=completed", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+func TestEndpoints_PostArchiveEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Bad Request - overwrite",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/archive?overwrite=sure", strings.NewReader(""))
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - invalid archive",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Import", mock.Anything, false).
+						Return(nil, fmt.Errorf("failed to read archive"))
+
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/archive", strings.NewReader("garbage"))
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Import", mock.Anything, true).
+						Return(&models.AttackImportResponse{Imported: []string{"123"}, Skipped: []string{}}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/archive?overwrite=true", strings.NewReader("archive"))
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
@@ -1,27 +1,242 @@
 package endpoints
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"vegeta-server/internal/dispatcher"
 	"vegeta-server/models"
+	"vegeta-server/pkg/importer"
+	"vegeta-server/pkg/vegeta"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/pkg/errors"
 )
 
-// PostAttackEndpoint implements a handler for the POST /api/v1/attack endpoint
+// PostAttackEndpoint implements a handler for the POST /api/v1/attack endpoint.
+// Attacks with a target file to upload are submitted as multipart form.
 func (e *Endpoints) PostAttackEndpoint(c *gin.Context) {
+	if mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type")); mediaType == "multipart/form-data" {
+		e.postAttackUpload(c)
+		return
+	}
+
 	var attackParams models.AttackParams
 	if err := c.ShouldBindJSON(&attackParams); err != nil {
 		ginErrBadRequest(c, err)
 		return
 	}
+	if err := importTargets(&attackParams); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+	if err := validateAttackParams(attackParams); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+	if err := validateInlineTargets(attackParams); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	e.dispatchAttack(c, attackParams)
+}
+
+// postAttackUpload submits an attack from a multipart form, whose "params"
+// part holds the attack params as JSON and is followed by the target file
+// in the "targets" part, or the access log of a replay in the "log" part.
+// The upload is streamed into the result store.
+func (e *Endpoints) postAttackUpload(c *gin.Context) {
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	var attackParams *models.AttackParams
+parts:
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			ginErrBadRequest(c, errors.Wrap(err, "failed to read multipart form"))
+			return
+		}
+
+		switch part.FormName() {
+		case "params":
+			attackParams = new(models.AttackParams)
+			if err := json.NewDecoder(part).Decode(attackParams); err != nil {
+				ginErrBadRequest(c, errors.Wrap(err, "failed to decode params"))
+				return
+			}
+			if err := binding.Validator.ValidateStruct(attackParams); err != nil {
+				ginErrBadRequest(c, err)
+				return
+			}
+			if err := importTargets(attackParams); err != nil {
+				ginErrBadRequest(c, err)
+				return
+			}
+			if attackParams.TargetFile == nil && attackParams.Replay == nil {
+				attackParams.TargetFile = &models.TargetFile{}
+			}
+			if err := validateAttackParams(*attackParams); err != nil {
+				ginErrBadRequest(c, err)
+				return
+			}
+		case "targets":
+			if attackParams == nil {
+				ginErrBadRequest(c, fmt.Errorf("params must precede targets"))
+				return
+			}
+			if attackParams.TargetFile == nil {
+				ginErrBadRequest(c, fmt.Errorf("targets require a target-file"))
+				return
+			}
+			if attackParams.TargetFile.Data != "" {
+				ginErrBadRequest(c, fmt.Errorf("inline target data and an uploaded target file are mutually exclusive"))
+				return
+			}
+			ref, err := e.dispatcher.PutTargets(attackParams.TargetFile.FormatOrDefault(), part)
+			if errors.Cause(err) == dispatcher.ErrInvalidTargets {
+				ginErrBadRequest(c, err)
+				return
+			}
+			if err != nil {
+				ginErrInternalServerError(c, err)
+				return
+			}
+			attackParams.TargetFile.Key, attackParams.TargetFile.Size = ref.Key, ref.Size
+			// Nothing after the target file is read, so it is never left behind
+			break parts
+		case "log":
+			if attackParams == nil {
+				ginErrBadRequest(c, fmt.Errorf("params must precede log"))
+				return
+			}
+			if attackParams.Replay == nil {
+				ginErrBadRequest(c, fmt.Errorf("log requires a replay"))
+				return
+			}
+			if attackParams.Replay.Data != "" {
+				ginErrBadRequest(c, fmt.Errorf("inline replay data and an uploaded log are mutually exclusive"))
+				return
+			}
+			ref, err := e.dispatcher.PutReplayLog(*attackParams.Replay, part)
+			if errors.Cause(err) == dispatcher.ErrInvalidReplayLog {
+				ginErrBadRequest(c, err)
+				return
+			}
+			if err != nil {
+				ginErrInternalServerError(c, err)
+				return
+			}
+			attackParams.Replay.Key, attackParams.Replay.Size = ref.Key, ref.Size
+			break parts
+		}
+	}
+
+	if attackParams == nil {
+		ginErrBadRequest(c, fmt.Errorf("missing params"))
+		return
+	}
+	if err := validateInlineTargets(*attackParams); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
 
-	// Submit the attack
+	e.dispatchAttack(c, *attackParams)
+}
+
+// importTargets replaces the import of the attack params, if any, by the
+// targets it converts to
+func importTargets(attackParams *models.AttackParams) error {
+	if attackParams.Import == nil {
+		return nil
+	}
+	if len(attackParams.Target) > 0 {
+		return fmt.Errorf("target and import are mutually exclusive")
+	}
+	targets, err := importer.Import(*attackParams.Import)
+	if err != nil {
+		return err
+	}
+	attackParams.Target, attackParams.Import = targets, nil
+	return nil
+}
+
+// validateAttackParams checks the attack params beyond their bindings
+func validateAttackParams(attackParams models.AttackParams) error {
+	if err := models.ValidateLabels(attackParams.Labels); err != nil {
+		return err
+	}
+	if err := models.ValidateScenario(attackParams); err != nil {
+		return err
+	}
+	if err := models.ValidateTargets(attackParams.Target); err != nil {
+		return err
+	}
+	if attackParams.TargetFile != nil {
+		if err := attackParams.TargetFile.Validate(); err != nil {
+			return err
+		}
+	}
+	if attackParams.Replay != nil {
+		if err := attackParams.Replay.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := vegeta.ValidateTemplates(attackParams); err != nil {
+		return err
+	}
+	if attackParams.Feeder != nil {
+		if err := attackParams.Feeder.Validate(); err != nil {
+			return err
+		}
+	}
+	if attackParams.Samples != nil {
+		if err := attackParams.Samples.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateInlineTargets reads the targets of an inline target file, or the
+// requests of an inline access log, unless they were uploaded
+func validateInlineTargets(attackParams models.AttackParams) error {
+	if f := attackParams.TargetFile; f != nil && f.Key == "" {
+		_, err := vegeta.ValidateTargetFile(f.FormatOrDefault(), strings.NewReader(f.Data))
+		return errors.Wrap(err, "invalid target-file data")
+	}
+	if r := attackParams.Replay; r != nil && r.Key == "" {
+		_, _, err := vegeta.ValidateReplayLog(*r, strings.NewReader(r.Data))
+		return errors.Wrap(err, "invalid replay data")
+	}
+	return nil
+}
+
+// dispatchAttack submits the attack and responds with its status
+func (e *Endpoints) dispatchAttack(c *gin.Context, attackParams models.AttackParams) {
 	resp, err := e.dispatcher.Dispatch(attackParams)
+	if errors.Cause(err) == dispatcher.ErrFeederNotFound {
+		ginErrBadRequest(c, err)
+		return
+	}
 	if err != nil {
 		ginErrInternalServerError(c, err)
 		return
 	}
 
+	c.Header("ETag", etag(resp))
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -34,23 +249,170 @@ func (e *Endpoints) GetAttackByIDEndpoint(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", etag(resp))
+	if header := c.GetHeader("If-None-Match"); header != "" && etagMatches(header, etag(resp), true) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetAttackEventsEndpoint implements a handler for the GET /api/v1/attack/<attackID>/events endpoint
+func (e *Endpoints) GetAttackEventsEndpoint(c *gin.Context) {
+	id := c.Param("attackID")
+	resp, err := e.dispatcher.Events(id)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetAttackSamplesEndpoint implements a handler for the GET /api/v1/attack/<attackID>/samples endpoint
+func (e *Endpoints) GetAttackSamplesEndpoint(c *gin.Context) {
+	var (
+		opts models.ListOptions
+		err  error
+	)
+	if opts.Offset, err = intQuery(c, "offset"); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+	if opts.Limit, err = intQuery(c, "limit"); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	id := c.Param("attackID")
+	resp, total, err := e.dispatcher.Samples(id, opts)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+
+	c.Header(totalCountHeader, strconv.Itoa(total))
 	c.JSON(http.StatusOK, resp)
 }
 
 // GetAttackEndpoint implements a handler for the GET /api/v1/attack endpoint
 func (e *Endpoints) GetAttackEndpoint(c *gin.Context) {
-	filterMap := make(models.FilterParams)
-	filterMap["status"] = c.DefaultQuery("status", "")
-	filterMap["created_before"] = c.DefaultQuery("created_before", "")
-	filterMap["created_after"] = c.DefaultQuery("created_after", "")
-	resp := e.dispatcher.List(
+	opts, err := listOptions(c)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	filterMap, err := attackFilters(c)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	resp, total := e.dispatcher.List(
 		//models.StatusFilter(status),
 		filterMap,
+		opts,
 	)
 
+	selected, err := selectFields(c, resp)
+	if err != nil {
+		ginErrInternalServerError(c, err)
+		return
+	}
+
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, selected)
+}
+
+// DeleteAttackEndpoint implements a handler for the DELETE /api/v1/attack endpoint,
+// deleting all attacks matching the same filters as GET /api/v1/attack
+func (e *Endpoints) DeleteAttackEndpoint(c *gin.Context) {
+	force, err := strconv.ParseBool(c.DefaultQuery("force", "false"))
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	filterMap, err := attackFilters(c)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+	if !hasFilter(filterMap) && c.Query("all") != "true" {
+		ginErrBadRequest(c, fmt.Errorf("refusing to delete all attacks without a filter or all=true"))
+		return
+	}
+
+	resp := e.dispatcher.DeleteAll(filterMap, force)
+
 	c.JSON(http.StatusOK, resp)
 }
 
+// DeleteAttackByIDEndpoint implements a handler for the DELETE /api/v1/attack/<attackID> endpoint
+func (e *Endpoints) DeleteAttackByIDEndpoint(c *gin.Context) {
+	id := c.Param("attackID")
+	force, err := strconv.ParseBool(c.DefaultQuery("force", "false"))
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	resp, err := e.dispatcher.Get(id)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+	revision, ok := ifMatch(c, resp)
+	if !ok {
+		return
+	}
+
+	err = e.dispatcher.Delete(id, force, revision)
+	if errors.Cause(err) == dispatcher.ErrAttackActive {
+		ginErrConflict(c, err)
+		return
+	}
+	if revisionConflict(c, revision, err) {
+		return
+	}
+	if err != nil {
+		ginErrInternalServerError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// attackFilters returns the attack filters passed as query params
+func attackFilters(c *gin.Context) (models.FilterParams, error) {
+	filterMap := make(models.FilterParams)
+	filterMap["status"] = c.DefaultQuery("status", "")
+	filterMap["created_before"] = c.DefaultQuery("created_before", "")
+	filterMap["created_after"] = c.DefaultQuery("created_after", "")
+	for _, key := range models.FilterKeys {
+		if v, ok := c.GetQuery(key); ok {
+			filterMap[key] = v
+		}
+	}
+	return filterMap, models.ValidateFilters(filterMap)
+}
+
+// hasFilter reports whether any filter is set
+func hasFilter(filterMap models.FilterParams) bool {
+	for _, v := range filterMap {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // PostAttackByIDCancelEndpoint implements a handler for the POST /api/v1/attack/<attackID>/cancel endpoint
 func (e *Endpoints) PostAttackByIDCancelEndpoint(c *gin.Context) {
 	id := c.Param("attackID")
@@ -60,13 +422,55 @@ func (e *Endpoints) PostAttackByIDCancelEndpoint(c *gin.Context) {
 		return
 	}
 
-	_, err := e.dispatcher.Get(id)
+	resp, err := e.dispatcher.Get(id)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+	revision, ok := ifMatch(c, resp)
+	if !ok {
+		return
+	}
+
+	err = e.dispatcher.Cancel(id, attackCancelParams.Cancel, revision)
+	if revisionConflict(c, revision, err) {
+		return
+	}
+	if err != nil {
+		ginErrInternalServerError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// PostAttackByIDPinEndpoint implements a handler for the POST /api/v1/attack/<attackID>/pin endpoint
+func (e *Endpoints) PostAttackByIDPinEndpoint(c *gin.Context) {
+	e.pinAttack(c, true)
+}
+
+// DeleteAttackByIDPinEndpoint implements a handler for the DELETE /api/v1/attack/<attackID>/pin endpoint
+func (e *Endpoints) DeleteAttackByIDPinEndpoint(c *gin.Context) {
+	e.pinAttack(c, false)
+}
+
+func (e *Endpoints) pinAttack(c *gin.Context, pinned bool) {
+	id := c.Param("attackID")
+
+	resp, err := e.dispatcher.Get(id)
 	if err != nil {
 		ginErrNotFound(c, err)
 		return
 	}
+	revision, ok := ifMatch(c, resp)
+	if !ok {
+		return
+	}
 
-	err = e.dispatcher.Cancel(id, attackCancelParams.Cancel)
+	err = e.dispatcher.Pin(id, pinned, revision)
+	if revisionConflict(c, revision, err) {
+		return
+	}
 	if err != nil {
 		ginErrInternalServerError(c, err)
 		return
@@ -74,3 +478,73 @@ func (e *Endpoints) PostAttackByIDCancelEndpoint(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+// PutAttackByIDBaselineEndpoint implements a handler for the PUT /api/v1/attack/<attackID>/baseline endpoint
+func (e *Endpoints) PutAttackByIDBaselineEndpoint(c *gin.Context) {
+	var baseline models.Baseline
+	if err := c.ShouldBindJSON(&baseline); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+	if err := baseline.Validate(); err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	e.setBaseline(c, &baseline)
+}
+
+// DeleteAttackByIDBaselineEndpoint implements a handler for the DELETE /api/v1/attack/<attackID>/baseline endpoint
+func (e *Endpoints) DeleteAttackByIDBaselineEndpoint(c *gin.Context) {
+	e.setBaseline(c, nil)
+}
+
+func (e *Endpoints) setBaseline(c *gin.Context, baseline *models.Baseline) {
+	id := c.Param("attackID")
+
+	resp, err := e.dispatcher.Get(id)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+	revision, ok := ifMatch(c, resp)
+	if !ok {
+		return
+	}
+
+	err = e.dispatcher.SetBaseline(id, baseline, revision)
+	if revisionConflict(c, revision, err) {
+		return
+	}
+	switch errors.Cause(err) {
+	case nil:
+		c.Status(http.StatusOK)
+	case dispatcher.ErrInvalidBaseline:
+		ginErrBadRequest(c, err)
+	case dispatcher.ErrBaselineNotCompleted:
+		ginErrConflict(c, err)
+	default:
+		ginErrInternalServerError(c, err)
+	}
+}
+
+// GetAttackVerdictEndpoint implements a handler for the GET /api/v1/attack/<attackID>/verdict endpoint.
+// The status code reflects the verdict, so CI jobs can gate on it: 200 if
+// the attack passed, 422 if it failed and 202 while it is pending.
+func (e *Endpoints) GetAttackVerdictEndpoint(c *gin.Context) {
+	id := c.Param("attackID")
+	resp, err := e.dispatcher.Verdict(id)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+
+	code := http.StatusOK
+	switch resp.Status {
+	case models.BaselineFailed:
+		code = http.StatusUnprocessableEntity
+	case models.BaselinePending:
+		code = http.StatusAccepted
+	}
+	c.JSON(code, resp)
+}
@@ -1,8 +1,10 @@
 package endpoints
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,6 +13,7 @@ import (
 	dmocks "vegeta-server/internal/dispatcher/mocks"
 	"vegeta-server/models"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 
 	assert "gopkg.in/go-playground/assert.v1"
@@ -51,10 +54,12 @@ func TestEndpoints_PostAttackEndpoint(t *testing.T) {
 				func() (dispatcher.IDispatcher, *http.Request) {
 					attackParams := models.AttackParams{
 						Rate: 1,
-						Target: models.Target{
-							Method: "GET",
-							URL:    "localhost:80/api/v1/",
-							Scheme: "http",
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "localhost:80/api/v1/",
+								Scheme: "http",
+							},
 						},
 					}
 					d := new(dmocks.IDispatcher)
@@ -72,16 +77,187 @@ func TestEndpoints_PostAttackEndpoint(t *testing.T) {
 				http.StatusBadRequest,
 			},
 		},
+		{
+			name: "Bad Request - Invalid label",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Labels:   map[string]string{"team": "a,b"},
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "localhost:80/api/v1/",
+								Scheme: "http",
+							},
+						},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+					attackParamsBody := string(bAttackParamsBody)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(attackParamsBody))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Missing target and scenario",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+					attackParamsBody := string(bAttackParamsBody)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(attackParamsBody))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Scenario with undefined variable",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Scenario: &models.Scenario{
+							Steps: []models.ScenarioStep{
+								{
+									Name:   "fetch",
+									Target: models.Target{URL: "http://localhost:80/items/${id}"},
+								},
+							},
+						},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+					attackParamsBody := string(bAttackParamsBody)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(attackParamsBody))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Invalid assertion",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "http://localhost:80/api/v1/users",
+								Assert: []models.Assertion{{Type: models.AssertLatency, Value: "fast"}},
+							},
+						},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(string(bAttackParamsBody)))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Invalid samples",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "http://localhost:80/api/v1/users",
+							},
+						},
+						Samples: &models.SampleParams{Filter: "slow"},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(string(bAttackParamsBody)))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Negative target weight",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "localhost:80/api/v1/users",
+								Scheme: "http",
+								Weight: -1,
+							},
+						},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+					attackParamsBody := string(bAttackParamsBody)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(attackParamsBody))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Invalid template",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "localhost:80/api/v1/users/{{.user_id",
+								Scheme: "http",
+							},
+						},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+					attackParamsBody := string(bAttackParamsBody)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(attackParamsBody))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
 		{
 			name: "Internal Server Error - Dispatcher error",
 			params: params{
 				func() (dispatcher.IDispatcher, *http.Request) {
 					attackParams := models.AttackParams{
 						Rate: 1,
-						Target: models.Target{
-							Method: "GET",
-							URL:    "localhost:80/api/v1/",
-							Scheme: "http",
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "localhost:80/api/v1/",
+								Scheme: "http",
+							},
 						},
 						Duration: "1s",
 					}
@@ -97,35 +273,848 @@ func TestEndpoints_PostAttackEndpoint(t *testing.T) {
 
 					return d, req
 				},
-				http.StatusInternalServerError,
+				http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate: 1,
+						Target: []models.Target{
+							{
+								Method: "GET",
+								URL:    "localhost:80/api/v1/",
+								Scheme: "http",
+							},
+						},
+						Duration: "1s",
+					}
+					d := new(dmocks.IDispatcher)
+
+					d.
+						On("Dispatch", attackParams).
+						Return(nil, nil)
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+					attackParamsBody := string(bAttackParamsBody)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(attackParamsBody))
+
+					return d, req
+				},
+				http.StatusOK,
+			},
+		},
+		{
+			name: "OK - Imported targets",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Import: &models.TargetImport{
+							Format: models.ImportFormatCurl,
+							Data:   "curl -H 'X-Header: 1' http://localhost:80/api/v1/",
+						},
+					}
+					d := new(dmocks.IDispatcher)
+
+					// The attack is submitted with the converted targets
+					d.
+						On("Dispatch", models.AttackParams{
+							Rate:     1,
+							Duration: "1s",
+							Target: []models.Target{{
+								Method:  "GET",
+								URL:     "http://localhost:80/api/v1/",
+								Headers: []models.AttackHeader{{Key: "X-Header", Value: "1"}},
+							}},
+						}).
+						Return(nil, nil)
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(string(bAttackParamsBody)))
+
+					return d, req
+				},
+				http.StatusOK,
+			},
+		},
+		{
+			name: "Bad Request - Import and target",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						Target:   []models.Target{{URL: "http://localhost:80/api/v1/"}},
+						Import: &models.TargetImport{
+							Format: models.ImportFormatCurl,
+							Data:   "curl http://localhost:80/api/v1/",
+						},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(string(bAttackParamsBody)))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK - Inline target file",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:     1,
+						Duration: "1s",
+						TargetFile: &models.TargetFile{
+							Format: models.TargetFormatJSON,
+							Data:   `{"method": "GET", "url": "http://localhost:80/api/v1/"}` + "\n",
+						},
+					}
+					d := new(dmocks.IDispatcher)
+
+					d.
+						On("Dispatch", attackParams).
+						Return(nil, nil)
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(string(bAttackParamsBody)))
+
+					return d, req
+				},
+				http.StatusOK,
+			},
+		},
+		{
+			name: "Bad Request - Body file in target file",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					attackParams := models.AttackParams{
+						Rate:       1,
+						Duration:   "1s",
+						TargetFile: &models.TargetFile{Data: "POST http://localhost:80/api/v1/\n@/etc/passwd\n"},
+					}
+					bAttackParamsBody, _ := json.Marshal(attackParams)
+
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(string(bAttackParamsBody)))
+
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK - Uploaded target file",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					d := new(dmocks.IDispatcher)
+
+					d.
+						On("PutTargets", models.TargetFormatHTTP, mock.Anything).
+						Return(models.ResultRef{Key: "targets-1", Size: 42}, nil)
+					d.
+						On("Dispatch", mock.MatchedBy(func(params models.AttackParams) bool {
+							return params.TargetFile != nil && params.TargetFile.Key == "targets-1" && params.TargetFile.Size == 42
+						})).
+						Return(nil, nil)
+
+					return d, multipartAttackRequest(
+						"params", `{"rate": 1, "duration": "1s"}`,
+						"targets", "GET http://localhost:80/api/v1/\n",
+					)
+				},
+				http.StatusOK,
+			},
+		},
+		{
+			name: "OK - Uploaded replay log",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					d := new(dmocks.IDispatcher)
+
+					d.
+						On("PutReplayLog", models.ReplayParams{Mode: models.ReplayModePool, BaseURL: "http://localhost:80"}, mock.Anything).
+						Return(models.ResultRef{Key: "replay-1", Size: 42}, nil)
+					d.
+						On("Dispatch", mock.MatchedBy(func(params models.AttackParams) bool {
+							return params.TargetFile == nil && params.Replay != nil && params.Replay.Key == "replay-1"
+						})).
+						Return(nil, nil)
+
+					return d, multipartAttackRequest(
+						"params", `{"rate": 1, "duration": "1s", "replay": {"mode": "pool", "base-url": "http://localhost:80"}}`,
+						"log", `10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "GET /api/v1/ HTTP/1.1" 200 1`,
+					)
+				},
+				http.StatusOK,
+			},
+		},
+		{
+			name: "Bad Request - Log without replay",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					return new(dmocks.IDispatcher), multipartAttackRequest(
+						"params", `{"rate": 1, "duration": "1s"}`,
+						"log", `10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "GET /api/v1/ HTTP/1.1" 200 1`,
+					)
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Inline replay without requests",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					body := `{"rate": 1, "duration": "1s", "replay": {"base-url": "http://localhost:80", "data": "starting up"}}`
+					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(body))
+					return new(dmocks.IDispatcher), req
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Invalid uploaded target file",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					d := new(dmocks.IDispatcher)
+
+					d.
+						On("PutTargets", models.TargetFormatJSON, mock.Anything).
+						Return(models.ResultRef{}, errors.Wrap(dispatcher.ErrInvalidTargets, "target: required method is missing"))
+
+					return d, multipartAttackRequest(
+						"params", `{"rate": 1, "duration": "1s", "target-file": {"format": "json"}}`,
+						"targets", `{"url": "http://localhost:80/api/v1/"}`,
+					)
+				},
+				http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Targets before params",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					return new(dmocks.IDispatcher), multipartAttackRequest(
+						"targets", "GET http://localhost:80/api/v1/\n",
+						"params", `{"rate": 1, "duration": "1s"}`,
+					)
+				},
+				http.StatusBadRequest,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+// multipartAttackRequest returns an attack submission of the given part
+// names and contents
+func multipartAttackRequest(parts ...string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for i := 0; i+1 < len(parts); i += 2 {
+		w, _ := mw.CreateFormField(parts[i])
+		w.Write([]byte(parts[i+1])) // nolint: errcheck
+	}
+	mw.Close() // nolint: errcheck
+
+	req, _ := http.NewRequest("POST", "/api/v1/attack", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestEndpoints_GetAttackByIDEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Prepare mock
+					wantErr := fmt.Errorf("not found")
+					d.
+						On("Get", mock.AnythingOfType("string")).
+						Return(nil, wantErr)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123", nil)
+					return d, req
+				},
+				http.StatusNotFound,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Prepare mock
+					wantBody := &models.AttackResponse{
+						ID:     "123",
+						Status: models.AttackResponseStatusScheduled,
+					}
+
+					d.
+						On("Get", "123").
+						Return(wantBody, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123", nil)
+
+					return d, req
+				},
+				http.StatusOK,
+			},
+		},
+		{
+			name: "Not Modified",
+			params: params{
+				func() (dispatcher.IDispatcher, *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Prepare mock
+					wantBody := &models.AttackResponse{
+						ID:       "123",
+						Status:   models.AttackResponseStatusScheduled,
+						Revision: 3,
+					}
+
+					d.
+						On("Get", "123").
+						Return(wantBody, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123", nil)
+					req.Header.Set("If-None-Match", `"3"`)
+
+					return d, req
+				},
+				http.StatusNotModified,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+func TestEndpoints_GetAttackEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "OK",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("List", models.FilterParams{
+							"status":         "",
+							"created_before": "",
+							"created_after":  "",
+						}, models.ListOptions{SortBy: models.SortByCreatedAt}).
+						Return([]*models.AttackResponse{}, 0)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+		{
+			name: "OK - paginated",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("List", mock.AnythingOfType("models.FilterParams"), models.ListOptions{
+							SortBy: models.SortByRate,
+							Desc:   true,
+							Offset: 10,
+							Limit:  5,
+						}).
+						Return([]*models.AttackResponse{{ID: "123", Status: models.AttackResponseStatusCompleted}}, 11)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?sort=rate&order=desc&offset=10&limit=5&fields=id", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+		{
+			name: "OK - label selector",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("List", models.FilterParams{
+							"status":         "",
+							"created_before": "",
+							"created_after":  "",
+							"labels":         "team=payments,env!=prod",
+						}, models.ListOptions{SortBy: models.SortByCreatedAt}).
+						Return([]*models.AttackResponse{}, 0)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?labels=team%3Dpayments%2Cenv%21%3Dprod", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+		{
+			name: "Bad Request - label selector",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?labels=%3Dpayments", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK - target and range filters",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("List", models.FilterParams{
+							"status":         "",
+							"created_before": "",
+							"created_after":  "-2h",
+							"host":           "localhost",
+							"method":         "GET",
+							"rate_min":       "10",
+							"duration_max":   "1m",
+						}, models.ListOptions{SortBy: models.SortByCreatedAt}).
+						Return([]*models.AttackResponse{}, 0)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?created_after=-2h&host=localhost&method=GET&rate_min=10&duration_max=1m", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+		{
+			name: "Bad Request - created_after",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?created_after=yesterday", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - status",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?status=done", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - sort",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?sort=target", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - limit",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack?limit=-1", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+func TestEndpoints_PostAttackByIDCancelEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Bad Request - nil body",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(""))
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					// Return valid response
+					d.
+						On("Get", "123").
+						Return(nil, fmt.Errorf("not found"))
+
+					bAttackCancelBody, _ := json.Marshal(&models.AttackCancel{
+						Cancel: true,
+					})
+					attackCancelBody := string(bAttackCancelBody)
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(attackCancelBody))
+					return d, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "Internal Server Error",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					// Return valid response
+					d.
+						On("Get", "123").
+						Return(nil, nil)
+
+					// Return error on Cancel
+					d.
+						On("Cancel", "123", true, int64(0)).
+						Return(fmt.Errorf("internal server error"))
+
+					bAttackCancelBody, _ := json.Marshal(&models.AttackCancel{
+						Cancel: true,
+					})
+					attackCancelBody := string(bAttackCancelBody)
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(attackCancelBody))
+					return d, req
+				},
+				wantCode: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					// Return valid response
+					d.
+						On("Get", "123").
+						Return(nil, nil)
+
+					// Return error on Cancel
+					d.
+						On("Cancel", "123", true, int64(0)).
+						Return(nil)
+
+					bAttackCancelBody, _ := json.Marshal(&models.AttackCancel{
+						Cancel: true,
+					})
+					attackCancelBody := string(bAttackCancelBody)
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(attackCancelBody))
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+func TestEndpoints_AttackByIDPinEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(nil, fmt.Errorf("not found"))
+
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/attack/123/pin", nil)
+					return d, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "OK - pin",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(nil, nil)
+					d.
+						On("Pin", "123", true, int64(0)).
+						Return(nil)
+
+					// Setup router
+					req, _ := http.NewRequest("POST", "/api/v1/attack/123/pin", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+		{
+			name: "OK - unpin",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(nil, nil)
+					d.
+						On("Pin", "123", false, int64(0)).
+						Return(nil)
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123/pin", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+func TestEndpoints_DeleteAttackByIDEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(nil, fmt.Errorf("not found"))
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123", nil)
+					return d, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "Conflict - running",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(nil, nil)
+					d.
+						On("Delete", "123", false, int64(0)).
+						Return(dispatcher.ErrAttackActive)
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123", nil)
+					return d, req
+				},
+				wantCode: http.StatusConflict,
+			},
+		},
+		{
+			name: "Precondition Failed - stale revision",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(&models.AttackResponse{ID: "123", Revision: 4}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123", nil)
+					req.Header.Set("If-Match", `"3"`)
+					return d, req
+				},
+				wantCode: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "Precondition Failed - weak tag",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(&models.AttackResponse{ID: "123", Revision: 3}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123", nil)
+					req.Header.Set("If-Match", `W/"3"`)
+					return d, req
+				},
+				wantCode: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "Precondition Failed - modified after the check",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(&models.AttackResponse{ID: "123", Revision: 3}, nil)
+					d.
+						On("Delete", "123", false, int64(3)).
+						Return(errors.Wrap(models.ErrRevisionConflict, "failed to claim item"))
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123", nil)
+					req.Header.Set("If-Match", `"3"`)
+					return d, req
+				},
+				wantCode: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "OK - matching revision",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Get", "123").
+						Return(&models.AttackResponse{ID: "123", Revision: 3}, nil)
+					d.
+						On("Delete", "123", false, int64(3)).
+						Return(nil)
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123", nil)
+					req.Header.Set("If-Match", `"2", "3"`)
+					return d, req
+				},
+				wantCode: http.StatusNoContent,
+			},
+		},
+		{
+			name: "Bad Request - force",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123?force=maybe", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
 			},
 		},
 		{
-			name: "OK",
+			name: "OK - forced",
 			params: params{
-				func() (dispatcher.IDispatcher, *http.Request) {
-					attackParams := models.AttackParams{
-						Rate: 1,
-						Target: models.Target{
-							Method: "GET",
-							URL:    "localhost:80/api/v1/",
-							Scheme: "http",
-						},
-						Duration: "1s",
-					}
-					d := new(dmocks.IDispatcher)
-
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
 					d.
-						On("Dispatch", attackParams).
+						On("Get", "123").
 						Return(nil, nil)
-					bAttackParamsBody, _ := json.Marshal(attackParams)
-					attackParamsBody := string(bAttackParamsBody)
-
-					req, _ := http.NewRequest("POST", "/api/v1/attack", strings.NewReader(attackParamsBody))
+					d.
+						On("Delete", "123", true, int64(0)).
+						Return(nil)
 
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123?force=true", nil)
 					return d, req
 				},
-				http.StatusOK,
+				wantCode: http.StatusNoContent,
 			},
 		},
 	}
@@ -138,7 +1127,7 @@ func TestEndpoints_PostAttackEndpoint(t *testing.T) {
 	}
 }
 
-func TestEndpoints_GetAttackByIDEndpoint(t *testing.T) {
+func TestEndpoints_DeleteAttackEndpoint(t *testing.T) {
 	type params struct {
 		setup    setupDispatcherFunc
 		wantCode int
@@ -148,46 +1137,112 @@ func TestEndpoints_GetAttackByIDEndpoint(t *testing.T) {
 		params params
 	}{
 		{
-			name: "Not Found",
+			name: "Bad Request - no filter",
 			params: params{
-				func() (dispatcher.IDispatcher, *http.Request) {
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
 					d := &dmocks.IDispatcher{}
 
-					// Prepare mock
-					wantErr := fmt.Errorf("not found")
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack", nil)
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK - status filter",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
 					d.
-						On("Get", mock.AnythingOfType("string")).
-						Return(nil, wantErr)
+						On("DeleteAll", mock.AnythingOfType("models.FilterParams"), false).
+						Return(&models.AttackDeleteResponse{Deleted: []string{"123"}, Skipped: []string{}})
 
 					// Setup router
-					req, _ := http.NewRequest("GET", "/api/v1/attack/123", nil)
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack?status=completed", nil)
 					return d, req
 				},
-				http.StatusNotFound,
+				wantCode: http.StatusOK,
 			},
 		},
 		{
-			name: "OK",
+			name: "OK - all",
 			params: params{
-				func() (dispatcher.IDispatcher, *http.Request) {
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
 					d := &dmocks.IDispatcher{}
+					d.
+						On("DeleteAll", mock.AnythingOfType("models.FilterParams"), true).
+						Return(&models.AttackDeleteResponse{Deleted: []string{}, Skipped: []string{}})
 
-					// Prepare mock
-					wantBody := &models.AttackResponse{
-						ID:     "123",
-						Status: models.AttackResponseStatusScheduled,
-					}
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack?all=true&force=true", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
 
+func TestEndpoints_GetAttackSamplesEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
 					d.
-						On("Get", "123").
-						Return(wantBody, nil)
+						On("Samples", "123", models.ListOptions{}).
+						Return(nil, 0, errors.Wrap(dispatcher.ErrNoSamples, "attack is not sampled"))
 
 					// Setup router
-					req, _ := http.NewRequest("GET", "/api/v1/attack/123", nil)
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/samples", nil)
+					return d, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "Bad Request - Invalid limit",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
 
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/samples?limit=-1", nil)
 					return d, req
 				},
-				http.StatusOK,
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Samples", "123", models.ListOptions{Offset: 10, Limit: 5}).
+						Return([]models.Sample{{Seq: 10, Code: 500}}, 11, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/samples?offset=10&limit=5", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
 			},
 		},
 	}
@@ -200,7 +1255,7 @@ func TestEndpoints_GetAttackByIDEndpoint(t *testing.T) {
 	}
 }
 
-func TestEndpoints_GetAttackEndpoint(t *testing.T) {
+func TestEndpoints_GetAttackEventsEndpoint(t *testing.T) {
 	type params struct {
 		setup    setupDispatcherFunc
 		wantCode int
@@ -209,21 +1264,33 @@ func TestEndpoints_GetAttackEndpoint(t *testing.T) {
 		name   string
 		params params
 	}{
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Events", "123").
+						Return(nil, fmt.Errorf("not found"))
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/events", nil)
+					return d, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
 		{
 			name: "OK",
 			params: params{
 				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
 					d := &dmocks.IDispatcher{}
 					d.
-						On("List", models.FilterParams{
-							"status":         "",
-							"created_before": "",
-							"created_after":  "",
-						}).
-						Return([]*models.AttackResponse{})
+						On("Events", "123").
+						Return([]models.AttackEvent{{Type: models.AttackEventScheduled, Actor: models.ActorUser}}, nil)
 
 					// Setup router
-					req, _ := http.NewRequest("GET", "/api/v1/attack", nil)
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/events", nil)
 					return d, req
 				},
 				wantCode: http.StatusOK,
@@ -239,7 +1306,12 @@ func TestEndpoints_GetAttackEndpoint(t *testing.T) {
 	}
 }
 
-func TestEndpoints_PostAttackByIDCancelEndpoint(t *testing.T) {
+func TestEndpoints_AttackByIDBaselineEndpoint(t *testing.T) {
+	body := `{"labels": {"service": "checkout"}, "rules": [{"metric": "p99", "max_regression": 0.1}]}`
+	isBaseline := mock.MatchedBy(func(b *models.Baseline) bool {
+		return b != nil && b.Labels["service"] == "checkout" && len(b.Rules) == 1 && *b.Rules[0].MaxRegression == 0.1
+	})
+
 	type params struct {
 		setup    setupDispatcherFunc
 		wantCode int
@@ -249,13 +1321,12 @@ func TestEndpoints_PostAttackByIDCancelEndpoint(t *testing.T) {
 		params params
 	}{
 		{
-			name: "Bad Request - nil body",
+			name: "Bad Request - Invalid rule",
 			params: params{
 				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
-					d := &dmocks.IDispatcher{}
 					// Setup router
-					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(""))
-					return d, req
+					req, _ := http.NewRequest("PUT", "/api/v1/attack/123/baseline", strings.NewReader(`{"rules": [{"metric": "p90", "max": 1}]}`))
+					return &dmocks.IDispatcher{}, req
 				},
 				wantCode: http.StatusBadRequest,
 			},
@@ -265,69 +1336,171 @@ func TestEndpoints_PostAttackByIDCancelEndpoint(t *testing.T) {
 			params: params{
 				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
 					d := &dmocks.IDispatcher{}
-					// Return valid response
 					d.
 						On("Get", "123").
 						Return(nil, fmt.Errorf("not found"))
 
-					bAttackCancelBody, _ := json.Marshal(&models.AttackCancel{
-						Cancel: true,
-					})
-					attackCancelBody := string(bAttackCancelBody)
 					// Setup router
-					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(attackCancelBody))
+					req, _ := http.NewRequest("PUT", "/api/v1/attack/123/baseline", strings.NewReader(body))
 					return d, req
 				},
 				wantCode: http.StatusNotFound,
 			},
 		},
 		{
-			name: "Internal Server Error",
+			name: "Bad Request - Labels not set on the attack",
 			params: params{
 				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
 					d := &dmocks.IDispatcher{}
-					// Return valid response
 					d.
 						On("Get", "123").
 						Return(nil, nil)
+					d.
+						On("SetBaseline", "123", isBaseline, int64(0)).
+						Return(errors.Wrap(dispatcher.ErrInvalidBaseline, "baseline labels are not all set on the attack"))
 
-					// Return error on Cancel
+					// Setup router
+					req, _ := http.NewRequest("PUT", "/api/v1/attack/123/baseline", strings.NewReader(body))
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Conflict - Not completed",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
 					d.
-						On("Cancel", "123", true).
-						Return(fmt.Errorf("internal server error"))
+						On("Get", "123").
+						Return(nil, nil)
+					d.
+						On("SetBaseline", "123", isBaseline, int64(0)).
+						Return(dispatcher.ErrBaselineNotCompleted)
 
-					bAttackCancelBody, _ := json.Marshal(&models.AttackCancel{
-						Cancel: true,
-					})
-					attackCancelBody := string(bAttackCancelBody)
 					// Setup router
-					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(attackCancelBody))
+					req, _ := http.NewRequest("PUT", "/api/v1/attack/123/baseline", strings.NewReader(body))
 					return d, req
 				},
-				wantCode: http.StatusInternalServerError,
+				wantCode: http.StatusConflict,
 			},
 		},
 		{
-			name: "OK",
+			name: "OK - set",
 			params: params{
 				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
 					d := &dmocks.IDispatcher{}
-					// Return valid response
 					d.
 						On("Get", "123").
 						Return(nil, nil)
+					d.
+						On("SetBaseline", "123", isBaseline, int64(0)).
+						Return(nil)
 
-					// Return error on Cancel
+					// Setup router
+					req, _ := http.NewRequest("PUT", "/api/v1/attack/123/baseline", strings.NewReader(body))
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+		{
+			name: "OK - unset",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
 					d.
-						On("Cancel", "123", true).
+						On("Get", "123").
+						Return(nil, nil)
+					d.
+						On("SetBaseline", "123", (*models.Baseline)(nil), int64(0)).
 						Return(nil)
 
-					bAttackCancelBody, _ := json.Marshal(&models.AttackCancel{
-						Cancel: true,
-					})
-					attackCancelBody := string(bAttackCancelBody)
 					// Setup router
-					req, _ := http.NewRequest("POST", "/api/v1/attack/123/cancel", strings.NewReader(attackCancelBody))
+					req, _ := http.NewRequest("DELETE", "/api/v1/attack/123/baseline", nil)
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+func TestEndpoints_GetAttackVerdictEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found - No baseline",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Verdict", "123").
+						Return(nil, dispatcher.ErrNoBaseline)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/verdict", nil)
+					return d, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "Accepted - Pending",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Verdict", "123").
+						Return(&models.BaselineVerdict{Status: models.BaselinePending}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/verdict", nil)
+					return d, req
+				},
+				wantCode: http.StatusAccepted,
+			},
+		},
+		{
+			name: "Unprocessable Entity - Failed",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Verdict", "123").
+						Return(&models.BaselineVerdict{Status: models.BaselineFailed}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/verdict", nil)
+					return d, req
+				},
+				wantCode: http.StatusUnprocessableEntity,
+			},
+		},
+		{
+			name: "OK - Passed",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("Verdict", "123").
+						Return(&models.BaselineVerdict{Status: models.BaselinePassed}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/attack/123/verdict", nil)
 					return d, req
 				},
 				wantCode: http.StatusOK,
@@ -31,6 +31,28 @@ var (
 		)
 	}
 
+	ginErrConflict = func(c *gin.Context, err error) {
+		c.JSON(
+			http.StatusConflict,
+			gin.H{
+				"message": "Conflict",
+				"code":    http.StatusConflict,
+				"error":   err.Error(),
+			},
+		)
+	}
+
+	ginErrPreconditionFailed = func(c *gin.Context, err error) {
+		c.JSON(
+			http.StatusPreconditionFailed,
+			gin.H{
+				"message": "Precondition failed",
+				"code":    http.StatusPreconditionFailed,
+				"error":   err.Error(),
+			},
+		)
+	}
+
 	ginErrInternalServerError = func(c *gin.Context, err error) {
 		c.JSON(
 			http.StatusInternalServerError,
@@ -73,12 +95,38 @@ func SetupRouter(d dispatcher.IDispatcher, r reporter.IReporter) *gin.Engine {
 		// Attack endpoints
 		v1.POST("/attack", e.PostAttackEndpoint)
 		v1.GET("/attack", e.GetAttackEndpoint)
+		v1.DELETE("/attack", e.DeleteAttackEndpoint)
 		v1.GET("/attack/:attackID", e.GetAttackByIDEndpoint)
+		v1.DELETE("/attack/:attackID", e.DeleteAttackByIDEndpoint)
+		v1.GET("/attack/:attackID/events", e.GetAttackEventsEndpoint)
+		v1.GET("/attack/:attackID/samples", e.GetAttackSamplesEndpoint)
 		v1.POST("/attack/:attackID/cancel", e.PostAttackByIDCancelEndpoint)
+		v1.POST("/attack/:attackID/pin", e.PostAttackByIDPinEndpoint)
+		v1.DELETE("/attack/:attackID/pin", e.DeleteAttackByIDPinEndpoint)
+		v1.PUT("/attack/:attackID/baseline", e.PutAttackByIDBaselineEndpoint)
+		v1.DELETE("/attack/:attackID/baseline", e.DeleteAttackByIDBaselineEndpoint)
+		v1.GET("/attack/:attackID/verdict", e.GetAttackVerdictEndpoint)
 
 		// Report endpoints
 		v1.GET("/report", e.GetReportEndpoint)
 		v1.GET("/report/:attackID", e.GetReportByIDEndpoint)
+		v1.GET("/report/:attackID/results", e.GetResultsByIDEndpoint)
+		v1.DELETE("/report/:attackID", e.DeleteReportByIDEndpoint)
+
+		// Comparison endpoints
+		v1.GET("/compare", e.GetReportCompareEndpoint)
+
+		// Archive endpoints
+		v1.GET("/archive", e.GetArchiveEndpoint)
+		v1.POST("/archive", e.PostArchiveEndpoint)
+
+		// Feeder endpoints
+		v1.PUT("/feeder/:name", e.PutFeederEndpoint)
+		v1.GET("/feeder/:name", e.GetFeederEndpoint)
+		v1.DELETE("/feeder/:name", e.DeleteFeederEndpoint)
+
+		// Import endpoints
+		v1.POST("/import", e.PostImportEndpoint)
 
 		v1.GET("/metrics", e.HandlerFunc(prom))
 	}
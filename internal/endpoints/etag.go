@@ -0,0 +1,70 @@
+package endpoints
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"vegeta-server/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// etag returns the entity tag of an attack, derived from its revision
+func etag(resp *models.AttackResponse) string {
+	if resp == nil {
+		return `"0"`
+	}
+	return strconv.Quote(strconv.FormatInt(resp.Revision, 10))
+}
+
+// etagMatches reports whether the entity tag is listed in an If-Match or
+// If-None-Match header value. Weak tags only match with the weak comparison
+// of If-None-Match, If-Match requires the strong one (RFC 7232 3.1).
+func etagMatches(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch checks the If-Match precondition of a request modifying the
+// attack, responding with 412 Precondition Failed if it does not hold.
+// It returns the revision the modification must still find for the
+// precondition to hold when it is written, or 0 without a precondition.
+func ifMatch(c *gin.Context, resp *models.AttackResponse) (int64, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return 0, true
+	}
+	if resp != nil && etagMatches(header, etag(resp), false) {
+		return resp.Revision, true
+	}
+
+	ginErrPreconditionFailed(c, fmt.Errorf("attack revision is %s", etag(resp)))
+	return 0, false
+}
+
+// revisionConflict reports whether a modification failed because the attack
+// changed concurrently, responding with 412 Precondition Failed if the
+// request had a precondition and 409 Conflict otherwise
+func revisionConflict(c *gin.Context, revision int64, err error) bool {
+	if errors.Cause(err) != models.ErrRevisionConflict {
+		return false
+	}
+	if revision != 0 {
+		ginErrPreconditionFailed(c, err)
+	} else {
+		ginErrConflict(c, err)
+	}
+	return true
+}
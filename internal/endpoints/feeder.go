@@ -0,0 +1,62 @@
+package endpoints
+
+import (
+	"mime"
+	"net/http"
+	"vegeta-server/internal/dispatcher"
+	"vegeta-server/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// PutFeederEndpoint implements a handler for the PUT /api/v1/feeder/<name> endpoint,
+// storing the CSV or JSON Lines data feeder passed as request body
+func (e *Endpoints) PutFeederEndpoint(c *gin.Context) {
+	name := c.Param("name")
+	format := c.DefaultQuery("format", feederFormat(c.GetHeader("Content-Type")))
+
+	resp, err := e.dispatcher.PutFeeder(name, format, c.Request.Body)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetFeederEndpoint implements a handler for the GET /api/v1/feeder/<name> endpoint
+func (e *Endpoints) GetFeederEndpoint(c *gin.Context) {
+	resp, err := e.dispatcher.GetFeeder(c.Param("name"))
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteFeederEndpoint implements a handler for the DELETE /api/v1/feeder/<name> endpoint
+func (e *Endpoints) DeleteFeederEndpoint(c *gin.Context) {
+	err := e.dispatcher.DeleteFeeder(c.Param("name"))
+	if errors.Cause(err) == dispatcher.ErrFeederNotFound {
+		ginErrNotFound(c, err)
+		return
+	}
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// feederFormat returns the feeder format for the content type, JSON Lines
+// unless it is CSV
+func feederFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "text/csv" {
+		return models.FeederFormatCSV
+	}
+	return models.FeederFormatJSONL
+}
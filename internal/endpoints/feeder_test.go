@@ -0,0 +1,119 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"vegeta-server/internal/dispatcher"
+	dmocks "vegeta-server/internal/dispatcher/mocks"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+
+	assert "gopkg.in/go-playground/assert.v1"
+)
+
+func TestEndpoints_PutFeederEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Bad Request - invalid feeder",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("PutFeeder", "users", models.FeederFormatJSONL, mock.Anything).
+						Return(nil, fmt.Errorf("failed to decode feeder record on line 1"))
+
+					// Setup router
+					req, _ := http.NewRequest("PUT", "/api/v1/feeder/users", strings.NewReader("user_id,name"))
+					return d, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK - CSV",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("PutFeeder", "users", models.FeederFormatCSV, mock.Anything).
+						Return(&models.FeederInfo{Name: "users", Records: 1}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("PUT", "/api/v1/feeder/users", strings.NewReader("user_id\n1\n"))
+					req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+					return d, req
+				},
+				wantCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
+
+func TestEndpoints_DeleteFeederEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupDispatcherFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("DeleteFeeder", "users").
+						Return(errors.Wrap(dispatcher.ErrFeederNotFound, "users"))
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/feeder/users", nil)
+					return d, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					d := &dmocks.IDispatcher{}
+					d.
+						On("DeleteFeeder", "users").
+						Return(nil)
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/feeder/users", nil)
+					return d, req
+				},
+				wantCode: http.StatusNoContent,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package endpoints
+
+import (
+	"io/ioutil"
+	"net/http"
+	"vegeta-server/models"
+	"vegeta-server/pkg/importer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// PostImportEndpoint implements a handler for the POST /api/v1/import endpoint,
+// converting the HAR capture, OpenAPI document or cURL commands passed as
+// request body into targets for review
+func (e *Endpoints) PostImportEndpoint(c *gin.Context) {
+	data, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		ginErrBadRequest(c, errors.Wrap(err, "failed to read request body"))
+		return
+	}
+
+	targets, err := importer.Import(models.TargetImport{
+		Format:  c.Query("format"),
+		Data:    string(data),
+		BaseURL: c.Query("base-url"),
+	})
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, targets)
+}
@@ -0,0 +1,71 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"vegeta-server/internal/dispatcher"
+	dmocks "vegeta-server/internal/dispatcher/mocks"
+	"vegeta-server/models"
+
+	assert "gopkg.in/go-playground/assert.v1"
+)
+
+func TestEndpoints_PostImportEndpoint(t *testing.T) {
+	type params struct {
+		setup     setupDispatcherFunc
+		wantCode  int
+		wantCount int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "OK - cURL",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					body := "curl http://localhost/a\ncurl -X POST http://localhost/b -d 'x=1'\n"
+					req, _ := http.NewRequest("POST", "/api/v1/import?format=curl", strings.NewReader(body))
+					return &dmocks.IDispatcher{}, req
+				},
+				wantCode:  http.StatusOK,
+				wantCount: 2,
+			},
+		},
+		{
+			name: "Bad Request - Unsupported format",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					req, _ := http.NewRequest("POST", "/api/v1/import?format=postman", strings.NewReader("{}"))
+					return &dmocks.IDispatcher{}, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - No targets",
+			params: params{
+				setup: func() (iDispatcher dispatcher.IDispatcher, request *http.Request) {
+					req, _ := http.NewRequest("POST", "/api/v1/import?format=har", strings.NewReader(`{"log": {"entries": []}}`))
+					return &dmocks.IDispatcher{}, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestDispatcherRouter(tt.params.setup())
+			assert.Equal(t, tt.params.wantCode, w.Code)
+			if w.Code == http.StatusOK {
+				var targets []models.Target
+				if err := json.Unmarshal(w.Body.Bytes(), &targets); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.params.wantCount, len(targets))
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"vegeta-server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// totalCountHeader carries the number of items matching a listing request,
+// regardless of pagination
+const totalCountHeader = "X-Total-Count"
+
+// listOptions returns the sorting and pagination options passed as query params
+func listOptions(c *gin.Context) (models.ListOptions, error) {
+	opts := models.ListOptions{
+		SortBy: c.DefaultQuery("sort", models.SortByCreatedAt),
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, fmt.Errorf("unsupported sort order %q", order)
+	}
+
+	var err error
+	if opts.Offset, err = intQuery(c, "offset"); err != nil {
+		return opts, err
+	}
+	if opts.Limit, err = intQuery(c, "limit"); err != nil {
+		return opts, err
+	}
+
+	return opts, opts.Validate()
+}
+
+// intQuery parses an optional integer query param, defaulting to zero
+func intQuery(c *gin.Context, key string) (int, error) {
+	v := c.Query(key)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", key, v)
+	}
+	return i, nil
+}
+
+// selectFields reduces each item of a listing to the JSON fields passed in
+// the fields query param. The items are returned as is without the param.
+func selectFields(c *gin.Context, items interface{}) (interface{}, error) {
+	fields := c.Query("fields")
+	if fields == "" {
+		return items, nil
+	}
+
+	b, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var all []map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make([]map[string]json.RawMessage, 0, len(all))
+	for _, item := range all {
+		s := make(map[string]json.RawMessage)
+		for _, field := range strings.Split(fields, ",") {
+			if v, ok := item[strings.TrimSpace(field)]; ok {
+				s[strings.TrimSpace(field)] = v
+			}
+		}
+		selected = append(selected, s)
+	}
+	return selected, nil
+}
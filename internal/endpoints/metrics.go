@@ -3,8 +3,10 @@ package endpoints
 import (
 	"encoding/json"
 	"strconv"
+	"sync"
 	"time"
 	"vegeta-server/models"
+	"vegeta-server/pkg/vegeta"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,8 +20,17 @@ type Prometheus struct {
 	reqStsCode                                                *prometheus.GaugeVec
 	resSuccessRatio                                           *prometheus.GaugeVec
 	histogram                                                 *prometheus.HistogramVec
+	resultBytes, resultCompressionRatio                       *prometheus.GaugeVec
+	attackLabel                                               *prometheus.GaugeVec
+
+	// Metrics of the targets or scenario steps of attacks
+	targetReqCnt, targetSuccessRatio, targetStsCode                          *prometheus.GaugeVec
+	targetLatMean, targetLat50th, targetLat95th, targetLat99th, targetLatMax *prometheus.GaugeVec
 
 	MetricsList []*models.Metric
+
+	// mu serialises scrapes, which reset the metrics before setting them
+	mu sync.Mutex
 }
 
 func NewPrometheus(subsystem string) *Prometheus {
@@ -44,6 +55,12 @@ func (e *Endpoints) HandlerFunc(p *Prometheus) gin.HandlerFunc {
 
 		var metricId string
 
+		// Start from scratch, so deleted attacks disappear from the metrics.
+		// A concurrent scrape would otherwise see the metrics half set.
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.reset()
+
 		attackInfo := e.GetIdList(c)
 		jsonReports := e.GetAllReports(c)
 
@@ -69,6 +86,26 @@ func (e *Endpoints) HandlerFunc(p *Prometheus) gin.HandlerFunc {
 				for key, mapElem := range element.StatusCodes {
 					p.reqStsCode.WithLabelValues(metricId, strconv.Itoa(elem.Params.Rate), elem.Params.Duration, key).Set(float64(mapElem))
 				}
+
+				for key, value := range elem.Params.Labels {
+					p.attackLabel.WithLabelValues(metricId, key, value).Set(1)
+				}
+
+				for _, target := range element.Targets {
+					p.setTargetMetrics(metricId, target.Name, target.JSONMetrics)
+				}
+				for _, step := range element.Steps {
+					p.setTargetMetrics(metricId, step.Name, step.JSONMetrics)
+				}
+
+				if elem.Result != nil {
+					encoding := elem.Result.Encoding
+					if encoding == "" {
+						encoding = string(vegeta.CompressionNone)
+					}
+					p.resultBytes.WithLabelValues(metricId, encoding).Set(float64(elem.Result.Size))
+					p.resultCompressionRatio.WithLabelValues(metricId, encoding).Set(elem.Result.CompressionRatio())
+				}
 			}
 		}
 
@@ -86,6 +123,33 @@ func (e *Endpoints) HandlerFunc(p *Prometheus) gin.HandlerFunc {
 	}
 }
 
+// setTargetMetrics exports the metrics of a single target or scenario step
+// of an attack
+func (p *Prometheus) setTargetMetrics(id, target string, m models.JSONMetrics) {
+	p.targetReqCnt.WithLabelValues(id, target).Set(float64(m.Requests))
+	p.targetLatMean.WithLabelValues(id, target).Set(milliseconds(m.Latencies.Mean))
+	p.targetLat50th.WithLabelValues(id, target).Set(milliseconds(m.Latencies.P50th))
+	p.targetLat95th.WithLabelValues(id, target).Set(milliseconds(m.Latencies.P95th))
+	p.targetLat99th.WithLabelValues(id, target).Set(milliseconds(m.Latencies.P99th))
+	p.targetLatMax.WithLabelValues(id, target).Set(milliseconds(m.Latencies.Max))
+	p.targetSuccessRatio.WithLabelValues(id, target).Set(m.Success)
+	for code, count := range m.StatusCodes {
+		p.targetStsCode.WithLabelValues(id, target, code).Set(float64(count))
+	}
+}
+
+// reset drops all previously exported label values
+func (p *Prometheus) reset() {
+	for _, metricDef := range p.MetricsList {
+		switch metric := metricDef.MetricCollector.(type) {
+		case *prometheus.GaugeVec:
+			metric.Reset()
+		case *prometheus.HistogramVec:
+			metric.Reset()
+		}
+	}
+}
+
 func (p *Prometheus) registerMetrics(subsystem string) {
 
 	for _, metricDef := range p.MetricsList {
@@ -120,6 +184,28 @@ func (p *Prometheus) registerMetrics(subsystem string) {
 			p.resSuccessRatio = metric.(*prometheus.GaugeVec)
 		case models.Histogram:
 			p.histogram = metric.(*prometheus.HistogramVec)
+		case models.ResultBytes:
+			p.resultBytes = metric.(*prometheus.GaugeVec)
+		case models.ResultCompressionRatio:
+			p.resultCompressionRatio = metric.(*prometheus.GaugeVec)
+		case models.AttackLabel:
+			p.attackLabel = metric.(*prometheus.GaugeVec)
+		case models.TargetReqCnt:
+			p.targetReqCnt = metric.(*prometheus.GaugeVec)
+		case models.TargetLatMean:
+			p.targetLatMean = metric.(*prometheus.GaugeVec)
+		case models.TargetLat50th:
+			p.targetLat50th = metric.(*prometheus.GaugeVec)
+		case models.TargetLat95th:
+			p.targetLat95th = metric.(*prometheus.GaugeVec)
+		case models.TargetLat99th:
+			p.targetLat99th = metric.(*prometheus.GaugeVec)
+		case models.TargetLatMax:
+			p.targetLatMax = metric.(*prometheus.GaugeVec)
+		case models.TargetSuccessRatio:
+			p.targetSuccessRatio = metric.(*prometheus.GaugeVec)
+		case models.TargetStsCode:
+			p.targetStsCode = metric.(*prometheus.GaugeVec)
 		}
 		metricDef.MetricCollector = metric
 	}
@@ -137,7 +223,7 @@ func (e *Endpoints) GetIdList(c *gin.Context) []*models.AttackBaseInfo {
 }
 
 func (e *Endpoints) GetAllReports(c *gin.Context) []models.JSONReportResponse {
-	resp := e.reporter.GetAll()
+	resp, _ := e.reporter.GetAll(make(models.FilterParams), models.ListOptions{})
 	jsonReports := make([]models.JSONReportResponse, 0)
 	for _, report := range resp {
 		var jsonReport models.JSONReportResponse
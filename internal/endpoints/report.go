@@ -2,7 +2,10 @@ package endpoints
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"vegeta-server/models"
 	"vegeta-server/pkg/vegeta"
 
@@ -11,7 +14,19 @@ import (
 
 // GetReportEndpoint implements a handler for the GET /api/v1/report endpoint
 func (e *Endpoints) GetReportEndpoint(c *gin.Context) {
-	resp := e.reporter.GetAll()
+	opts, err := listOptions(c)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	filterMap, err := attackFilters(c)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	resp, total := e.reporter.GetAll(filterMap, opts)
 
 	jsonReports := make([]models.JSONReportResponse, 0)
 
@@ -25,7 +40,14 @@ func (e *Endpoints) GetReportEndpoint(c *gin.Context) {
 		jsonReports = append(jsonReports, jsonReport)
 	}
 
-	c.JSON(http.StatusOK, jsonReports)
+	selected, err := selectFields(c, jsonReports)
+	if err != nil {
+		ginErrInternalServerError(c, err)
+		return
+	}
+
+	c.Header(totalCountHeader, strconv.Itoa(total))
+	c.JSON(http.StatusOK, selected)
 }
 
 // GetReportByIDEndpoint implements a handler for the GET /api/v1/report/<attackID> endpoint
@@ -35,6 +57,15 @@ func (e *Endpoints) GetReportByIDEndpoint(c *gin.Context) {
 	format := vegeta.NewFormat(c.DefaultQuery("format", "json"))
 	bucket := c.DefaultQuery("bucket", vegeta.DefaultBucketString)
 	format.SetMeta("bucket", bucket)
+	if compressed, ok := c.GetQuery("compressed"); ok {
+		format.SetMeta("compressed", compressed)
+	}
+
+	// Results can be large, so they are streamed rather than buffered
+	if format.String() == vegeta.BinaryFormatString {
+		e.streamResult(c, id, format.Meta()["compressed"] == "true")
+		return
+	}
 
 	resp, err := e.reporter.GetInFormat(id, format)
 	if err != nil {
@@ -54,11 +85,92 @@ func (e *Endpoints) GetReportByIDEndpoint(c *gin.Context) {
 	case vegeta.TextFormatString:
 		c.Header("Content-Type", "text/plain")
 		c.String(http.StatusOK, "%s", resp)
-	case vegeta.BinaryFormatString:
-		c.Header("Content-Type", "application/octet-stream")
-		c.Data(http.StatusOK, "application/octet-stream", resp)
-	case vegeta.HistogramFormatString:
+	case vegeta.HistogramFormatString, vegeta.HistogramPlotString:
 		c.Header("Content-Type", "text/plain")
 		c.String(http.StatusOK, "%s", resp)
+	case vegeta.HTMLFormatString:
+		c.Data(http.StatusOK, "text/html; charset=utf-8", resp)
 	}
 }
+
+// streamResult writes the stored result of an attack in the binary format
+func (e *Endpoints) streamResult(c *gin.Context, id string, compressed bool) {
+	result, compression, err := e.reporter.Result(id, compressed)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+	defer result.Close() // nolint: errcheck
+
+	w := &streamWriter{c: c, contentType: compression.ContentType()}
+	if _, err := io.Copy(w, result); err != nil {
+		if !c.Writer.Written() {
+			ginErrInternalServerError(c, err)
+			return
+		}
+		// The result is streamed, so errors can only be logged once it started
+		_ = c.Error(err)
+		return
+	}
+	if !c.Writer.Written() {
+		c.Header("Content-Type", w.contentType)
+		c.Status(http.StatusOK)
+	}
+}
+
+// GetReportCompareEndpoint implements a handler for the GET /api/v1/compare endpoint,
+// comparing the candidate attack against the base attack
+func (e *Endpoints) GetReportCompareEndpoint(c *gin.Context) {
+	base, candidate := c.Query("base"), c.Query("candidate")
+	if base == "" || candidate == "" {
+		ginErrBadRequest(c, fmt.Errorf("base and candidate are required"))
+		return
+	}
+
+	alpha := models.DefaultComparisonAlpha
+	if v := c.Query("alpha"); v != "" {
+		var err error
+		if alpha, err = strconv.ParseFloat(v, 64); err != nil || alpha <= 0 || alpha >= 1 {
+			ginErrBadRequest(c, fmt.Errorf("invalid alpha %q, want a number between 0 and 1", v))
+			return
+		}
+	}
+
+	resp, err := e.reporter.Compare(base, candidate, alpha)
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteReportByIDEndpoint implements a handler for the DELETE /api/v1/report/<attackID> endpoint,
+// removing the stored result while keeping the attack
+func (e *Endpoints) DeleteReportByIDEndpoint(c *gin.Context) {
+	id := c.Param("attackID")
+
+	var revision int64
+	if c.GetHeader("If-Match") != "" {
+		resp, err := e.dispatcher.Get(id)
+		if err != nil {
+			ginErrNotFound(c, err)
+			return
+		}
+		var ok bool
+		if revision, ok = ifMatch(c, resp); !ok {
+			return
+		}
+	}
+
+	err := e.reporter.Delete(id, revision)
+	if revisionConflict(c, revision, err) {
+		return
+	}
+	if err != nil {
+		ginErrNotFound(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
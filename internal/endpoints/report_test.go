@@ -2,14 +2,19 @@ package endpoints
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 	"vegeta-server/internal/reporter"
 	"vegeta-server/models"
 	"vegeta-server/pkg/vegeta"
 
 	"github.com/gin-gonic/gin/json"
+	"github.com/stretchr/testify/mock"
 
 	rmock "vegeta-server/internal/reporter/mocks"
 
@@ -53,8 +58,8 @@ func TestEndpoints_GetReportEndpoint(t *testing.T) {
 					r := &rmock.IReporter{}
 
 					r.
-						On("GetAll").
-						Return(bReports)
+						On("GetAll", mock.AnythingOfType("models.FilterParams"), models.ListOptions{SortBy: models.SortByCreatedAt}).
+						Return(bReports, len(bReports))
 
 					// Setup router
 					req, _ := http.NewRequest("GET", "/api/v1/report", nil)
@@ -73,8 +78,8 @@ func TestEndpoints_GetReportEndpoint(t *testing.T) {
 					r := &rmock.IReporter{}
 
 					r.
-						On("GetAll").
-						Return(reports)
+						On("GetAll", mock.AnythingOfType("models.FilterParams"), models.ListOptions{SortBy: models.SortByCreatedAt}).
+						Return(reports, len(reports))
 
 					// Setup router
 					req, _ := http.NewRequest("GET", "/api/v1/report", nil)
@@ -96,8 +101,10 @@ func TestEndpoints_GetReportEndpoint(t *testing.T) {
 
 func TestEndpoints_GetReportByIDEndpoint(t *testing.T) {
 	type params struct {
-		setup    setupReporterFunc
-		wantCode int
+		setup           setupReporterFunc
+		wantCode        int
+		wantContentType string
+		wantBody        string
 	}
 	tests := []struct {
 		name   string
@@ -198,15 +205,52 @@ func TestEndpoints_GetReportByIDEndpoint(t *testing.T) {
 				setup: func() (reporter.IReporter, *http.Request) {
 					r := &rmock.IReporter{}
 					r.
-						On("GetInFormat", "123", vegeta.NewFormat("binary")).
-						Return([]byte{}, nil)
+						On("Result", "123", false).
+						Return(ioutil.NopCloser(strings.NewReader("results")), vegeta.CompressionNone, nil)
 
 					// Setup router
 					req, _ := http.NewRequest("GET", "/api/v1/report/123?format=binary", nil)
 
 					return r, req
 				},
-				wantCode: http.StatusOK,
+				wantCode:        http.StatusOK,
+				wantContentType: "application/octet-stream",
+				wantBody:        "results",
+			},
+		},
+		{
+			name: "Not Found - binary",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Result", "123", false).
+						Return(nil, vegeta.Compression(""), fmt.Errorf("attack with ID 123 has no result"))
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123?format=binary", nil)
+
+					return r, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "OK - binary compressed",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Result", "123", true).
+						Return(ioutil.NopCloser(strings.NewReader("\x1f\x8b")), vegeta.CompressionGzip, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123?format=binary&compressed=true", nil)
+
+					return r, req
+				},
+				wantCode:        http.StatusOK,
+				wantContentType: "application/gzip",
 			},
 		},
 		{
@@ -228,6 +272,105 @@ func TestEndpoints_GetReportByIDEndpoint(t *testing.T) {
 				wantCode: http.StatusOK,
 			},
 		},
+		{
+			name: "OK - hdrplot",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("GetInFormat", "123", vegeta.NewFormat("hdrplot")).
+						Return([]byte("Value(ms)  Percentile  TotalCount  1/(1-Percentile)\n"), nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123?format=hdrplot", nil)
+
+					return r, req
+				},
+				wantCode:        http.StatusOK,
+				wantContentType: "text/plain",
+				wantBody:        "Value(ms)  Percentile  TotalCount  1/(1-Percentile)\n",
+			},
+		},
+		{
+			name: "OK - html",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("GetInFormat", "123", mock.MatchedBy(func(f vegeta.Format) bool {
+							return f.String() == vegeta.HTMLFormatString
+						})).
+						Return([]byte("<!DOCTYPE html>"), nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123?format=html", nil)
+
+					return r, req
+				},
+				wantCode:        http.StatusOK,
+				wantContentType: "text/html; charset=utf-8",
+				wantBody:        "<!DOCTYPE html>",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestReporterRouter(tt.params.setup())
+			gotCode := w.Code
+			assert.Equal(t, tt.params.wantCode, gotCode)
+			if tt.params.wantContentType != "" {
+				assert.Equal(t, tt.params.wantContentType, w.Header().Get("Content-Type"))
+			}
+			if tt.params.wantBody != "" {
+				assert.Equal(t, tt.params.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestEndpoints_DeleteReportByIDEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupReporterFunc
+		wantCode int
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Delete", "123", int64(0)).
+						Return(fmt.Errorf("not found"))
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/report/123", nil)
+
+					return r, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Delete", "123", int64(0)).
+						Return(nil)
+
+					// Setup router
+					req, _ := http.NewRequest("DELETE", "/api/v1/report/123", nil)
+
+					return r, req
+				},
+				wantCode: http.StatusNoContent,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -237,3 +380,224 @@ func TestEndpoints_GetReportByIDEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestEndpoints_GetResultsByIDEndpoint(t *testing.T) {
+	writeResults := func(_ string, _ models.ResultQuery, w io.Writer) int {
+		fmt.Fprintln(w, `{"seq":1,"code":500}`)
+		fmt.Fprintln(w, `{"seq":2,"code":500}`)
+		return 5
+	}
+
+	type params struct {
+		setup           setupReporterFunc
+		wantCode        int
+		wantContentType string
+		wantBody        string
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Results", "123", models.ResultQuery{Limit: defaultResultsLimit}, mock.Anything).
+						Return(0, fmt.Errorf("not found"))
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123/results", nil)
+
+					return r, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "Not Found - JSON Lines",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Results", "123", models.ResultQuery{}, mock.Anything).
+						Return(0, fmt.Errorf("not found"))
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123/results?format=jsonl", nil)
+
+					return r, req
+				},
+				wantCode:        http.StatusNotFound,
+				wantContentType: "application/json; charset=utf-8",
+			},
+		},
+		{
+			name: "Bad Request - Invalid min-latency",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123/results?min-latency=slow", nil)
+
+					return &rmock.IReporter{}, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Unsupported format",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123/results?format=csv", nil)
+
+					return &rmock.IReporter{}, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					query := models.ResultQuery{
+						Codes:      []uint16{500, 502},
+						MinLatency: time.Second,
+						From:       time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+						Offset:     1,
+						Limit:      2,
+					}
+					r := &rmock.IReporter{}
+					r.
+						On("Results", "123", query, mock.Anything).
+						Return(writeResults, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123/results?code=500,502&min-latency=1s&from=2020-01-01T00:00:00Z&offset=1&limit=2", nil)
+
+					return r, req
+				},
+				wantCode:        http.StatusOK,
+				wantContentType: "application/json; charset=utf-8",
+				wantBody:        `[{"seq":1,"code":500},{"seq":2,"code":500}]`,
+			},
+		},
+		{
+			name: "OK - JSON Lines",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Results", "123", models.ResultQuery{Slowest: 10}, mock.Anything).
+						Return(writeResults, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/report/123/results?format=jsonl&slowest=10", nil)
+
+					return r, req
+				},
+				wantCode:        http.StatusOK,
+				wantContentType: "application/x-ndjson",
+				wantBody:        "{\"seq\":1,\"code\":500}\n{\"seq\":2,\"code\":500}\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestReporterRouter(tt.params.setup())
+			assert.Equal(t, tt.params.wantCode, w.Code)
+			if tt.params.wantContentType != "" {
+				assert.Equal(t, tt.params.wantContentType, w.Header().Get("Content-Type"))
+			}
+			if tt.params.wantBody != "" {
+				assert.Equal(t, tt.params.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestEndpoints_GetReportCompareEndpoint(t *testing.T) {
+	type params struct {
+		setup    setupReporterFunc
+		wantCode int
+		wantBody string
+	}
+	tests := []struct {
+		name   string
+		params params
+	}{
+		{
+			name: "Bad Request - Missing candidate",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/compare?base=123", nil)
+
+					return &rmock.IReporter{}, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Bad Request - Invalid alpha",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/compare?base=123&candidate=456&alpha=1", nil)
+
+					return &rmock.IReporter{}, req
+				},
+				wantCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "Not Found",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Compare", "123", "456", models.DefaultComparisonAlpha).
+						Return(nil, fmt.Errorf("not found"))
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/compare?base=123&candidate=456", nil)
+
+					return r, req
+				},
+				wantCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "OK",
+			params: params{
+				setup: func() (reporter.IReporter, *http.Request) {
+					r := &rmock.IReporter{}
+					r.
+						On("Compare", "123", "456", 0.01).
+						Return(&models.ComparisonResponse{
+							Base:        models.ComparedAttack{ID: "123"},
+							Candidate:   models.ComparedAttack{ID: "456"},
+							LatencyTest: models.SignificanceTest{Verdict: models.VerdictSlower},
+						}, nil)
+
+					// Setup router
+					req, _ := http.NewRequest("GET", "/api/v1/compare?base=123&candidate=456&alpha=0.01", nil)
+
+					return r, req
+				},
+				wantCode: http.StatusOK,
+				wantBody: `"verdict":"slower"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := setupTestReporterRouter(tt.params.setup())
+			assert.Equal(t, tt.params.wantCode, w.Code)
+			if tt.params.wantBody != "" {
+				assert.Equal(t, true, strings.Contains(w.Body.String(), tt.params.wantBody))
+			}
+		})
+	}
+}
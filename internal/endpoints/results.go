@@ -0,0 +1,150 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vegeta-server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Formats of the results returned by GET /api/v1/report/<attackID>/results
+const (
+	resultsFormatJSON  = "json"
+	resultsFormatJSONL = "jsonl"
+)
+
+// defaultResultsLimit bounds the pages of results returned as a JSON array,
+// unless a limit is given
+const defaultResultsLimit = 100
+
+// GetResultsByIDEndpoint implements a handler for the GET /api/v1/report/<attackID>/results endpoint,
+// returning the individual results of an attack matching the query params
+func (e *Endpoints) GetResultsByIDEndpoint(c *gin.Context) {
+	query, err := resultQuery(c)
+	if err != nil {
+		ginErrBadRequest(c, err)
+		return
+	}
+
+	id := c.Param("attackID")
+	switch format := c.DefaultQuery("format", resultsFormatJSON); format {
+	case resultsFormatJSON:
+		if query.Limit == 0 {
+			query.Limit = defaultResultsLimit
+		}
+
+		var buf bytes.Buffer
+		total, err := e.reporter.Results(id, query, &buf)
+		if err != nil {
+			ginErrNotFound(c, err)
+			return
+		}
+
+		results := make([]json.RawMessage, 0)
+		for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+			if len(line) > 0 {
+				results = append(results, line)
+			}
+		}
+		c.Header(totalCountHeader, strconv.Itoa(total))
+		c.JSON(http.StatusOK, results)
+	case resultsFormatJSONL:
+		w := &streamWriter{c: c, contentType: "application/x-ndjson"}
+		if _, err := e.reporter.Results(id, query, w); err != nil {
+			if !c.Writer.Written() {
+				ginErrNotFound(c, err)
+				return
+			}
+			// The results are streamed, so errors can only be logged once they started
+			_ = c.Error(err)
+			return
+		}
+		if !c.Writer.Written() {
+			c.Header("Content-Type", w.contentType)
+			c.Status(http.StatusOK)
+		}
+	default:
+		ginErrBadRequest(c, fmt.Errorf("unsupported results format %q", format))
+	}
+}
+
+// streamWriter writes a streamed response, setting its content type on the
+// first write only, so that errors before it are still returned as JSON
+type streamWriter struct {
+	c           *gin.Context
+	contentType string
+}
+
+// Write implements io.Writer
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if !w.c.Writer.Written() {
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// resultQuery returns the filters and pagination of results passed as query
+// params
+func resultQuery(c *gin.Context) (models.ResultQuery, error) {
+	var (
+		query models.ResultQuery
+		err   error
+	)
+
+	for _, codes := range c.QueryArray("code") {
+		for _, s := range strings.Split(codes, ",") {
+			code, err := strconv.ParseUint(strings.TrimSpace(s), 10, 16)
+			if err != nil {
+				return query, fmt.Errorf("invalid code %q", s)
+			}
+			query.Codes = append(query.Codes, uint16(code))
+		}
+	}
+	query.Error = c.Query("error")
+	query.Name = c.Query("name")
+
+	if v := c.Query("min-latency"); v != "" {
+		if query.MinLatency, err = time.ParseDuration(v); err != nil {
+			return query, fmt.Errorf("invalid min-latency %q", v)
+		}
+	}
+	if query.From, err = timeQuery(c, "from"); err != nil {
+		return query, err
+	}
+	if query.To, err = timeQuery(c, "to"); err != nil {
+		return query, err
+	}
+
+	if query.Slowest, err = intQuery(c, "slowest"); err != nil {
+		return query, err
+	}
+	if query.Offset, err = intQuery(c, "offset"); err != nil {
+		return query, err
+	}
+	if query.Limit, err = intQuery(c, "limit"); err != nil {
+		return query, err
+	}
+
+	return query, query.Validate()
+}
+
+// timeQuery parses an optional RFC 3339 timestamp query param, defaulting to
+// the zero time
+func timeQuery(c *gin.Context, key string) (time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, want an RFC 3339 timestamp", key, v)
+	}
+	return t, nil
+}
@@ -2,8 +2,10 @@
 
 package mocks
 
+import io "io"
 import mock "github.com/stretchr/testify/mock"
 
+import models "vegeta-server/models"
 import vegeta "vegeta-server/pkg/vegeta"
 
 // IReporter is an autogenerated mock type for the IReporter type
@@ -11,13 +13,36 @@ type IReporter struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: _a0
-func (_m *IReporter) Delete(_a0 string) error {
-	ret := _m.Called(_a0)
+// Compare provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IReporter) Compare(_a0 string, _a1 string, _a2 float64) (*models.ComparisonResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *models.ComparisonResponse
+	if rf, ok := ret.Get(0).(func(string, string, float64) *models.ComparisonResponse); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ComparisonResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, float64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0, _a1
+func (_m *IReporter) Delete(_a0 string, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -48,20 +73,50 @@ func (_m *IReporter) Get(_a0 string) ([]byte, error) {
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *IReporter) GetAll() [][]byte {
-	ret := _m.Called()
+// GetAll provides a mock function with given fields: _a0, _a1
+func (_m *IReporter) GetAll(_a0 models.FilterParams, _a1 models.ListOptions) ([][]byte, int) {
+	ret := _m.Called(_a0, _a1)
 
 	var r0 [][]byte
-	if rf, ok := ret.Get(0).(func() [][]byte); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(models.FilterParams, models.ListOptions) [][]byte); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([][]byte)
 		}
 	}
 
-	return r0
+	var r1 int
+	if rf, ok := ret.Get(1).(func(models.FilterParams, models.ListOptions) int); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Int(1)
+	}
+
+	return r0, r1
+}
+
+// GetHistogramMetricInFormat provides a mock function with given fields: _a0
+func (_m *IReporter) GetHistogramMetricInFormat(_a0 string) ([]byte, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // GetInFormat provides a mock function with given fields: _a0, _a1
@@ -86,3 +141,54 @@ func (_m *IReporter) GetInFormat(_a0 string, _a1 vegeta.Format) ([]byte, error)
 
 	return r0, r1
 }
+
+// Result provides a mock function with given fields: _a0, _a1
+func (_m *IReporter) Result(_a0 string, _a1 bool) (io.ReadCloser, vegeta.Compression, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(string, bool) io.ReadCloser); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 vegeta.Compression
+	if rf, ok := ret.Get(1).(func(string, bool) vegeta.Compression); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(vegeta.Compression)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, bool) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Results provides a mock function with given fields: _a0, _a1, _a2
+func (_m *IReporter) Results(_a0 string, _a1 models.ResultQuery, _a2 io.Writer) (int, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string, models.ResultQuery, io.Writer) int); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Int(0)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, models.ResultQuery, io.Writer) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
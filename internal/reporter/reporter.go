@@ -2,7 +2,10 @@ package reporter
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"vegeta-server/models"
 	"vegeta-server/pkg/vegeta"
 
@@ -13,8 +16,9 @@ import (
 type IReporter interface {
 	// Get report in (default) JSON format
 	Get(string) ([]byte, error)
-	// GetAll gets all reports in (default) JSON format
-	GetAll() [][]byte
+	// GetAll gets reports in (default) JSON format, sorted and paginated by
+	// the list options, along with the total number of reports
+	GetAll(models.FilterParams, models.ListOptions) ([][]byte, int)
 
 	// Get report in specified format (supported: JSON/Histogram/Text
 	GetInFormat(string, vegeta.Format) ([]byte, error)
@@ -22,61 +26,67 @@ type IReporter interface {
 	//Get Histogram values to Prometheus
 	GetHistogramMetricInFormat(string) ([]byte, error)
 
-	// Delete report from store
-	Delete(string) error
+	// Result opens the stored result of an attack in the binary format, as
+	// stored if compressed is set and decompressed otherwise, along with
+	// its compression
+	Result(string, bool) (io.ReadCloser, vegeta.Compression, error)
+
+	// Results writes the page of the results of an attack matching the
+	// query as JSON Lines, returning the number of matches
+	Results(string, models.ResultQuery, io.Writer) (int, error)
+
+	// Compare the results of a candidate attack against a base attack, at
+	// the given significance level
+	Compare(string, string, float64) (*models.ComparisonResponse, error)
+
+	// Delete the stored result of a report, keeping the attack. A non-zero
+	// revision is the revision the attack must be at, otherwise
+	// models.ErrRevisionConflict is returned.
+	Delete(string, int64) error
 }
 
+// maxUpdateAttempts bounds the retries of an update on revision conflicts
+const maxUpdateAttempts = 5
+
 type reporter struct {
-	db models.IAttackStore
+	db      models.IAttackStore
+	results models.IResultStore
 }
 
 // NewReporter returns an instance of the reporter object
-func NewReporter(db models.IAttackStore) *reporter { //nolint: golint
+func NewReporter(db models.IAttackStore, results models.IResultStore) *reporter { //nolint: golint
 	return &reporter{
 		db,
+		results,
 	}
 }
 
 // Get returns an attack report by its ID as a byte array
 func (r *reporter) Get(id string) ([]byte, error) {
-	attack, err := r.db.GetByID(id)
-	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
-	}
-
-	result := attack.Result
-	report, err := vegeta.CreateReportFromReader(
-		bytes.NewBuffer(result), attack.ID,
-		vegeta.NewFormat(vegeta.JSONFormatString),
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create report from reader")
-	}
-	return report, nil
+	return r.GetInFormat(id, vegeta.NewFormat(vegeta.JSONFormatString))
 }
 
 // GetAll returns a list of attack reports in byte array format
 // The default format, JSON is returned.
-func (r *reporter) GetAll() [][]byte {
-	attacks := r.db.GetAll(make(models.FilterParams))
+func (r *reporter) GetAll(filters models.FilterParams, opts models.ListOptions) ([][]byte, int) {
+	// Canceled attacks will have a nil result field, so only page
+	// through attacks that have a report
+	withResult := models.FilterParams{"has_result": true}
+	for k, v := range filters {
+		withResult[k] = v
+	}
+
+	attacks, total := r.db.GetAll(withResult, opts)
 	reports := make([][]byte, 0)
 	for _, attack := range attacks {
-		// Canceled attacks will have a nil result field
-		if attack.Result == nil {
-			continue
-		}
-
-		// Create report for all other attacks
-		report, err := vegeta.CreateReportFromReader(
-			bytes.NewBuffer(attack.Result), attack.ID,
-			vegeta.NewFormat(vegeta.JSONFormatString),
-		)
+		// Create report for all attacks with a result
+		report, err := r.report(attack, vegeta.NewFormat(vegeta.JSONFormatString))
 		if err != nil {
 			continue
 		}
 		reports = append(reports, report)
 	}
-	return reports
+	return reports, total
 }
 
 // GetInFormat returns a report in the specified format.
@@ -86,34 +96,237 @@ func (r *reporter) GetInFormat(id string, format vegeta.Format) ([]byte, error)
 		return nil, errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
 	}
 
-	result := attack.Result
-	if format.String() == vegeta.BinaryFormatString {
-		return result, nil
+	return r.report(attack, format)
+}
+
+// GetInFormat returns a report in the specified format.
+func (r *reporter) GetHistogramMetricInFormat(id string) ([]byte, error) {
+	attack, err := r.db.GetByID(id)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
 	}
 
-	report, err := vegeta.CreateReportFromReader(bytes.NewBuffer(result), attack.ID, format)
+	result, err := r.openResult(attack)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close() // nolint: errcheck
+
+	report, err := vegeta.CreateHistogramFromReader(result, attack.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create report from reader")
 	}
 	return report, nil
 }
 
-// GetInFormat returns a report in the specified format.
-func (r *reporter) GetHistogramMetricInFormat(id string) ([]byte, error) {
+// Result opens the stored result of an attack, so that it can be streamed
+// in the binary format
+func (r *reporter) Result(id string, compressed bool) (io.ReadCloser, vegeta.Compression, error) {
 	attack, err := r.db.GetByID(id)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
+		return nil, "", errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
+	}
+
+	result, err := r.openResult(attack)
+	if err != nil {
+		return nil, "", err
+	}
+	// Hand out the result as stored, unless asked for the raw encoding
+	if compressed {
+		compression := vegeta.Compression(attack.Result.Encoding)
+		if compression == "" {
+			compression = vegeta.CompressionNone
+		}
+		return result, compression, nil
 	}
 
-	result := attack.Result
-	report, err := vegeta.CreateHistogramFromReader(bytes.NewBuffer(result), attack.ID)
+	raw, err := vegeta.NewDecompressReader(result)
+	if err != nil {
+		result.Close() // nolint: errcheck
+		return nil, "", err
+	}
+	return &decompressedResult{raw, result}, vegeta.CompressionNone, nil
+}
+
+// decompressedResult closes the stored result along with its decompressor
+type decompressedResult struct {
+	io.ReadCloser
+	stored io.Closer
+}
+
+// Close implements io.Closer
+func (d *decompressedResult) Close() error {
+	d.ReadCloser.Close() // nolint: errcheck
+	return d.stored.Close()
+}
+
+// Results streams the stored result of an attack, writing the results
+// matching the query to w
+func (r *reporter) Results(id string, query models.ResultQuery, w io.Writer) (int, error) {
+	attack, err := r.db.GetByID(id)
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
+	}
+
+	result, err := r.openResult(attack)
+	if err != nil {
+		return 0, err
+	}
+	defer result.Close() // nolint: errcheck
+
+	n, err := vegeta.QueryResults(result, query, w)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to query results")
+	}
+	return n, nil
+}
+
+// Compare compares the stored results of the candidate attack against those
+// of the base attack
+func (r *reporter) Compare(baseID, candidateID string, alpha float64) (*models.ComparisonResponse, error) {
+	results := make([]io.ReadCloser, 0, 2)
+	defer func() {
+		for _, result := range results {
+			result.Close() // nolint: errcheck
+		}
+	}()
+	for _, id := range []string{baseID, candidateID} {
+		attack, err := r.db.GetByID(id)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
+		}
+		result, err := r.openResult(attack)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return vegeta.CompareResults(baseID, results[0], candidateID, results[1], alpha)
+}
+
+// Delete removes the stored result a report is generated from, keeping the
+// attack itself. Without a revision, concurrent changes to the attack are
+// retried rather than reported as conflicts.
+func (r *reporter) Delete(id string, revision int64) error {
+	var key string
+	var err error
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		var attack models.AttackDetails
+		attack, err = r.db.GetByID(id)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to get attack with ID %s", id))
+		}
+		if revision != 0 && attack.Revision != revision {
+			err = models.ErrRevisionConflict
+			break
+		}
+
+		if attack.Result == nil {
+			return fmt.Errorf("attack with ID %s has no result", id)
+		}
+
+		// Drop the reference first, so a concurrent change to the attack
+		// (models.ErrRevisionConflict) never leaves it pointing to a deleted result
+		key = attack.Result.Key
+		attack.Result = nil
+		err = r.db.Update(id, attack)
+		if revision != 0 || errors.Cause(err) != models.ErrRevisionConflict {
+			break
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to update attack with ID %s", id))
+	}
+
+	if err := r.results.Delete(key); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to delete result for attack with ID %s", id))
+	}
+	return nil
+}
+
+// report streams the stored result of an attack into a report of the given format
+func (r *reporter) report(attack models.AttackDetails, format vegeta.Format) ([]byte, error) {
+	result, err := r.openResult(attack)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close() // nolint: errcheck
+
+	// Binary results are streamed by Result instead, this buffers them whole
+	if format.String() == vegeta.BinaryFormatString {
+		if format.Meta()["compressed"] == "true" {
+			return ioutil.ReadAll(result)
+		}
+
+		raw, err := vegeta.NewDecompressReader(result)
+		if err != nil {
+			return nil, err
+		}
+		defer raw.Close() // nolint: errcheck
+		return ioutil.ReadAll(raw)
+	}
+
+	// HTML reports carry the description and labels in their header
+	if format.String() == vegeta.HTMLFormatString {
+		format.SetMeta("description", attack.Params.Description)
+		format.SetMeta("labels", models.FormatLabels(attack.Params.Labels))
+	}
+
+	create := vegeta.CreateReportFromReader
+	if attack.Params.Scenario != nil {
+		create = vegeta.CreateScenarioReportFromReader
+	}
+	report, err := create(result, attack.ID, format)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create report from reader")
 	}
+	return annotate(report, attack, format)
+}
+
+// annotate adds the attack description and labels to JSON and text reports
+func annotate(report []byte, attack models.AttackDetails, format vegeta.Format) ([]byte, error) {
+	params := attack.Params
+	if params.Description == "" && len(params.Labels) == 0 {
+		return report, nil
+	}
+
+	switch format.String() {
+	case vegeta.JSONFormatString:
+		var jsonReport models.JSONReportResponse
+		if err := json.Unmarshal(report, &jsonReport); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal JSONReportResponse")
+		}
+		jsonReport.Description = params.Description
+		jsonReport.Labels = params.Labels
+		return json.Marshal(jsonReport)
+	case vegeta.TextFormatString:
+		var header bytes.Buffer
+		if params.Description != "" {
+			fmt.Fprintf(&header, "Description %s\n", params.Description)
+		}
+		if len(params.Labels) > 0 {
+			fmt.Fprintf(&header, "Labels %s\n", models.FormatLabels(params.Labels))
+		}
+
+		// Keep the ID on the first line
+		i := bytes.IndexByte(report, '\n') + 1
+		annotated := append([]byte{}, report[:i]...)
+		annotated = append(annotated, header.Bytes()...)
+		return append(annotated, report[i:]...), nil
+	}
 	return report, nil
 }
 
-// Delete removes a report from the storage
-func (r *reporter) Delete(id string) error {
-	return r.db.Delete(id)
+// openResult opens the stored result referenced by the attack
+func (r *reporter) openResult(attack models.AttackDetails) (io.ReadCloser, error) {
+	if attack.Result == nil {
+		return nil, fmt.Errorf("attack with ID %s has no result", attack.ID)
+	}
+
+	result, err := r.results.Get(attack.Result.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to get result for attack with ID %s", attack.ID))
+	}
+	return result, nil
 }
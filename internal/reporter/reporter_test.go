@@ -0,0 +1,55 @@
+package reporter
+
+import (
+	"testing"
+	"vegeta-server/models"
+
+	smocks "vegeta-server/models/mocks"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_reporter_Delete(t *testing.T) {
+	attack := models.AttackDetails{
+		AttackInfo: models.AttackInfo{ID: "123", Revision: 2},
+		Result:     &models.ResultRef{Key: "123"},
+	}
+
+	tests := []struct {
+		name      string
+		revision  int64
+		conflicts int
+		updates   int
+		wantErr   error
+	}{
+		{"Retries concurrent changes", 0, 2, 3, nil},
+		{"Gives up after max attempts", 0, maxUpdateAttempts, maxUpdateAttempts, models.ErrRevisionConflict},
+		{"Matching revision", 2, 0, 1, nil},
+		{"Stale revision", 1, 0, 0, models.ErrRevisionConflict},
+		{"Conflict at the matching revision", 2, 1, 1, models.ErrRevisionConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &smocks.IAttackStore{}
+			db.On("GetByID", "123").Return(attack, nil)
+			if tt.conflicts > 0 {
+				db.On("Update", "123", mock.Anything).Return(models.ErrRevisionConflict).Times(tt.conflicts)
+			}
+			db.On("Update", "123", mock.Anything).Return(nil)
+			results := &smocks.IResultStore{}
+			results.On("Delete", "123").Return(nil)
+
+			err := NewReporter(db, results).Delete("123", tt.revision)
+			if errors.Cause(err) != tt.wantErr {
+				t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+			db.AssertNumberOfCalls(t, "Update", tt.updates)
+			if tt.wantErr == nil {
+				results.AssertCalled(t, "Delete", "123")
+			} else {
+				results.AssertNotCalled(t, "Delete", "123")
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Types of response assertions
+const (
+	// AssertStatus expects the status code to be one of a set
+	AssertStatus = "status"
+	// AssertBody expects the body to contain a substring
+	AssertBody = "body"
+	// AssertRegex expects the body to match a regular expression
+	AssertRegex = "regex"
+	// AssertJSONPath expects a value at a JSONPath of a JSON body
+	AssertJSONPath = "jsonpath"
+	// AssertHeader expects a response header
+	AssertHeader = "header"
+	// AssertLatency bounds the latency of the request
+	AssertLatency = "latency"
+)
+
+// Assertion checks the responses of a target. Responses failing an
+// assertion count as errors, named after the assertion.
+type Assertion struct {
+	// Name reports failures, defaults to a description of the assertion
+	Name string `json:"name,omitempty"`
+	// Type is one of status, body, regex, jsonpath, header or latency
+	Type string `json:"type"`
+	// Status lists the expected codes of status assertions. Other codes
+	// fail even if 2xx or 3xx, and listed codes pass even if 4xx or 5xx.
+	Status []int `json:"status,omitempty"`
+	// Expression is the regular expression of regex assertions, the path of
+	// jsonpath assertions or the name of header assertions
+	Expression string `json:"expression,omitempty"`
+	// Value is the substring of body assertions, the expected value of
+	// jsonpath and header assertions, which only expect the value to exist
+	// if empty, or the max latency of latency assertions, e.g. 250ms
+	Value string `json:"value,omitempty"`
+}
+
+// ReportName names the assertion in errors
+func (a Assertion) ReportName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	switch a.Type {
+	case AssertStatus:
+		return fmt.Sprintf("status in %v", a.Status)
+	case AssertBody:
+		return fmt.Sprintf("body contains %q", a.Value)
+	case AssertRegex:
+		return fmt.Sprintf("body matches %q", a.Expression)
+	case AssertJSONPath, AssertHeader:
+		name := a.Expression
+		if a.Type == AssertHeader {
+			name = "header " + name
+		}
+		if a.Value == "" {
+			return name + " exists"
+		}
+		return fmt.Sprintf("%s == %q", name, a.Value)
+	case AssertLatency:
+		return "latency <= " + a.Value
+	}
+	return a.Type
+}
+
+// Validate checks that the assertion has what its type expects
+func (a Assertion) Validate() error {
+	switch a.Type {
+	case AssertStatus:
+		if len(a.Status) == 0 {
+			return fmt.Errorf("status assertion has no status codes")
+		}
+		for _, code := range a.Status {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("invalid status code %d", code)
+			}
+		}
+	case AssertBody:
+		if a.Value == "" {
+			return fmt.Errorf("body assertion has no value")
+		}
+	case AssertRegex:
+		if _, err := regexp.Compile(a.Expression); err != nil {
+			return err
+		}
+	case AssertJSONPath:
+		if _, err := ParseJSONPath(a.Expression); err != nil {
+			return err
+		}
+	case AssertHeader:
+		if a.Expression == "" {
+			return fmt.Errorf("header assertion has no header name")
+		}
+	case AssertLatency:
+		if d, err := time.ParseDuration(a.Value); err != nil || d <= 0 {
+			return fmt.Errorf("latency assertion has an invalid max latency %q", a.Value)
+		}
+	default:
+		return fmt.Errorf("unsupported assertion type %q", a.Type)
+	}
+	return nil
+}
+
+// ValidateAssertions checks the assertions of a target
+func ValidateAssertions(assertions []Assertion) error {
+	for _, a := range assertions {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("assertion %q: %v", a.ReportName(), err)
+		}
+	}
+	return nil
+}
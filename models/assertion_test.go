@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestAssertion_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		assertion Assertion
+		wantErr   bool
+	}{
+		{"Status", Assertion{Type: AssertStatus, Status: []int{200, 404}}, false},
+		{"No status", Assertion{Type: AssertStatus}, true},
+		{"Invalid status", Assertion{Type: AssertStatus, Status: []int{99}}, true},
+		{"Body", Assertion{Type: AssertBody, Value: "ok"}, false},
+		{"Empty body", Assertion{Type: AssertBody}, true},
+		{"Regex", Assertion{Type: AssertRegex, Expression: `"id":\d+`}, false},
+		{"Invalid regex", Assertion{Type: AssertRegex, Expression: "("}, true},
+		{"JSONPath", Assertion{Type: AssertJSONPath, Expression: "$.status", Value: "ok"}, false},
+		{"Invalid JSONPath", Assertion{Type: AssertJSONPath, Expression: "status"}, true},
+		{"Header", Assertion{Type: AssertHeader, Expression: "ETag"}, false},
+		{"No header", Assertion{Type: AssertHeader, Value: "x"}, true},
+		{"Latency", Assertion{Type: AssertLatency, Value: "250ms"}, false},
+		{"Invalid latency", Assertion{Type: AssertLatency, Value: "-1s"}, true},
+		{"Unsupported", Assertion{Type: "xpath"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.assertion.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Assertion.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
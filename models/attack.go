@@ -1,7 +1,8 @@
 package models
 
 import (
-	"time"
+	"fmt"
+	"sort"
 )
 
 // AttackInfo encapsulates the attack information for attacks
@@ -15,75 +16,146 @@ type AttackInfo struct {
 	Params    AttackParams `json:"params,omitempty"`
 	CreatedAt string       `json:"created_at"`
 	UpdatedAt string       `json:"updated_at"`
+	// Pinned attacks are exempt from the retention policy
+	Pinned bool `json:"pinned,omitempty"`
+	// Baseline is set on attacks that attacks with matching labels are
+	// evaluated against, they are exempt from the retention policy as well
+	Baseline *Baseline `json:"baseline,omitempty"`
+	// Verdict of the attack against the baseline matching its labels
+	Verdict *BaselineVerdict `json:"verdict,omitempty"`
+	// Revision is incremented by the store on every write
+	Revision int64 `json:"revision"`
 }
 
 // AttackDetails captures the AttackInfo for COMPLETED attacks,
-// along with the result as a byte array
+// along with a reference to the result held in the result store
 type AttackDetails struct {
 	AttackInfo
-	Result []byte `json:"result,omitempty"`
+	Result *ResultRef `json:"result,omitempty"`
+	// Samples references the sampled requests held in the result store
+	Samples *ResultRef `json:"samples,omitempty"`
+	// Events is the append-only log of state transitions
+	Events []AttackEvent `json:"events,omitempty"`
 }
 
-// FilterParams defines a map structure for the filter parameters received via
-// query params in the request URL
-type FilterParams map[string]interface{}
+// StoredBytes returns the size of everything held in the result store for
+// the attack: its result, samples and uploaded target file or access log
+func (a AttackDetails) StoredBytes() int64 {
+	var n int64
+	if a.Result != nil {
+		n += a.Result.Size
+	}
+	if a.Samples != nil {
+		n += a.Samples.Size
+	}
+	if a.Params.TargetFile != nil {
+		n += a.Params.TargetFile.Size
+	}
+	if a.Params.Replay != nil {
+		n += a.Params.Replay.Size
+	}
+	return n
+}
 
-// Filter defines a type that must be implemented by
-// an attack filter
-type Filter func(AttackDetails) bool
+// storedKeys returns the result store keys of the result, samples and
+// uploaded target file or access log of the attack
+func (a AttackDetails) storedKeys() []string {
+	keys := make([]string, 0)
+	if a.Result != nil {
+		keys = append(keys, a.Result.Key)
+	}
+	if a.Samples != nil {
+		keys = append(keys, a.Samples.Key)
+	}
+	if a.Params.TargetFile != nil && a.Params.TargetFile.Key != "" {
+		keys = append(keys, a.Params.TargetFile.Key)
+	}
+	if a.Params.Replay != nil && a.Params.Replay.Key != "" {
+		keys = append(keys, a.Params.Replay.Key)
+	}
+	return keys
+}
 
-// StatusFilter implements a attack status filter
-// in the Filter function format
-func StatusFilter(status string) Filter {
-	return func(a AttackDetails) bool {
-		if status == "" {
-			return true
-		}
+// Sort fields supported by ListOptions
+const (
+	SortByCreatedAt = "created_at"
+	SortByUpdatedAt = "updated_at"
+	SortByStatus    = "status"
+	SortByRate      = "rate"
+)
 
-		if a.Status == AttackStatus(status) {
-			return true
-		}
+// ListOptions captures the sorting and pagination of attack listings
+type ListOptions struct {
+	// SortBy is the field attacks are ordered by, defaults to created_at
+	SortBy string
+	// Desc reverses the sort order
+	Desc bool
+	// Offset is the number of attacks to skip
+	Offset int
+	// Limit the number of attacks returned, zero returns all
+	Limit int
+}
 
-		return false
+// Validate checks the list options for unsupported values
+func (o ListOptions) Validate() error {
+	switch o.SortBy {
+	case "", SortByCreatedAt, SortByUpdatedAt, SortByStatus, SortByRate:
+	default:
+		return fmt.Errorf("unsupported sort field %q", o.SortBy)
+	}
+	if o.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	if o.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
 	}
+	return nil
 }
 
-// CreationBeforeFilter implements an attack created_before filter
-// in the Filter function format
-func CreationBeforeFilter(d string) Filter {
-	return func(a AttackDetails) bool {
-		if d == "" {
-			return true
+// Apply sorts the attacks and returns the requested page. Attacks that
+// compare equal are ordered by ID, so pages are stable across calls.
+func (o ListOptions) Apply(attacks []AttackDetails) []AttackDetails {
+	less := o.less()
+	sort.SliceStable(attacks, func(i, j int) bool {
+		a, b := attacks[i], attacks[j]
+		if o.Desc {
+			a, b = b, a
 		}
-		const layoutUser = "2006-01-02 15:04:05"
-		t, err := time.ParseInLocation(layoutUser, d, time.Local)
-		// If parsing failed, don't filter
-		if err != nil {
+		if less(a, b) {
 			return true
 		}
+		if less(b, a) {
+			return false
+		}
+		return a.ID < b.ID
+	})
 
-		attackTime, _ := time.Parse(time.RFC1123, a.CreatedAt)
-
-		return attackTime.Before(t)
+	if o.Offset >= len(attacks) {
+		return make([]AttackDetails, 0)
 	}
+	attacks = attacks[o.Offset:]
+	if o.Limit > 0 && o.Limit < len(attacks) {
+		attacks = attacks[:o.Limit]
+	}
+	return attacks
 }
 
-// CreationAfterFilter implements an attack created_after filter
-// in the Filter function format
-func CreationAfterFilter(d string) Filter {
-	return func(a AttackDetails) bool {
-		if d == "" {
-			return true
+func (o ListOptions) less() func(a, b AttackDetails) bool {
+	switch o.SortBy {
+	case SortByUpdatedAt:
+		return func(a, b AttackDetails) bool {
+			return attackTime(a.UpdatedAt).Before(attackTime(b.UpdatedAt))
 		}
-		const layoutUser = "2006-01-02 15:04:05"
-		t, err := time.ParseInLocation(layoutUser, d, time.Local)
-		// If parsing failed, don't filter
-		if err != nil {
-			return true
+	case SortByStatus:
+		return func(a, b AttackDetails) bool {
+			return a.Status < b.Status
 		}
-
-		attackTime, _ := time.Parse(time.RFC1123, a.CreatedAt)
-
-		return attackTime.After(t)
+	case SortByRate:
+		return func(a, b AttackDetails) bool {
+			return a.Params.Rate < b.Params.Rate
+		}
+	}
+	return func(a, b AttackDetails) bool {
+		return attackTime(a.CreatedAt).Before(attackTime(b.CreatedAt))
 	}
 }
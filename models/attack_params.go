@@ -1,5 +1,7 @@
 package models
 
+import "fmt"
+
 // AttackHeader provides a key/value object for headers
 type AttackHeader struct {
 	Key   string `json:"key,omitempty"`
@@ -11,8 +13,13 @@ type AttackParams struct {
 	ID   string `json:"id,omitempty"`
 	Rate int    `json:"rate,omitempty" binding:"required"`
 
+	// Labels are free-form key/value pairs to tell attacks apart
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+
 	Connections int64 `json:"connections,omitempty"`
 	Workers     int64 `json:"workers,omitempty"`
+	MaxWorkers  int64 `json:"max-workers,omitempty"`
 	MaxBody     int64 `json:"max-body,omitempty"`
 	Redirects   int64 `json:"redirects,omitempty"`
 
@@ -29,16 +36,68 @@ type AttackParams struct {
 	Insecure  bool `json:"insecure,omitempty"`
 	Keepalive bool `json:"keepalive,omitempty"`
 
-	Target []Target `json:"target,omitempty" binding:"required"`
+	// Target lists the requests of the attack, unless a Scenario, a
+	// TargetFile or a Replay is given
+	Target []Target `json:"target,omitempty"`
+	// TargetFile holds the targets in one of vegeta's formats
+	TargetFile *TargetFile `json:"target-file,omitempty"`
+	// Import converts a HAR capture, an OpenAPI document or cURL commands
+	// into Target when the attack is submitted
+	Import *TargetImport `json:"import,omitempty"`
+	// Replay sends the requests of an access log
+	Replay *ReplayParams `json:"replay,omitempty"`
+	// Scenario runs multi-step user journeys in place of the targets
+	Scenario *Scenario `json:"scenario,omitempty"`
+	// Feeder supplies the values of templated targets
+	Feeder *FeederParams `json:"feeder,omitempty"`
+	// Samples captures requests and responses of the attack for debugging
+	Samples *SampleParams `json:"samples,omitempty"`
 }
 
 // Target request target parameters
 type Target struct {
+	// Name identifies the target in reports and metrics, defaults to its
+	// method and URL
+	Name    string         `json:"name,omitempty"`
 	Method  string         `json:"method,omitempty"`
 	URL     string         `json:"URL,omitempty"`
 	Scheme  string         `json:"scheme,omitempty"`
 	Body    string         `json:"body,omitempty"`
 	Headers []AttackHeader `json:"headers,omitempty"`
+	// Weight sets the share of the requests sent to the target relative to
+	// the other targets, defaults to 1
+	Weight int `json:"weight,omitempty"`
+	// Assert lists the checks responses must pass to count as successes,
+	// on top of a 2xx or 3xx status unless a status assertion is given
+	Assert []Assertion `json:"assert,omitempty"`
+}
+
+// maxTargetWeight bounds target weights, keeping their sum well within int
+const maxTargetWeight = 1000000
+
+// ReportName names the target in reports
+func (t Target) ReportName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	method := t.Method
+	if method == "" {
+		method = "GET"
+	}
+	return method + " " + t.URL
+}
+
+// ValidateTargets checks the weights and assertions of the targets
+func ValidateTargets(targets []Target) error {
+	for _, t := range targets {
+		if t.Weight < 0 || t.Weight > maxTargetWeight {
+			return fmt.Errorf("weight of target %q must be between 0 and %d", t.ReportName(), maxTargetWeight)
+		}
+		if err := ValidateAssertions(t.Assert); err != nil {
+			return fmt.Errorf("target %q: %v", t.ReportName(), err)
+		}
+	}
+	return nil
 }
 
 // AttackStatus as a string enum
@@ -2,3 +2,17 @@ package models
 
 // AttackResponse with attacks UUID and AttackStatus
 type AttackResponse AttackInfo
+
+// AttackDeleteResponse lists the attacks removed by a bulk delete, along with
+// those that were skipped, e.g. because they are still running
+type AttackDeleteResponse struct {
+	Deleted []string `json:"deleted"`
+	Skipped []string `json:"skipped"`
+}
+
+// AttackImportResponse lists the attacks added from an archive, along with
+// those that were skipped, e.g. because they already exist
+type AttackImportResponse struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metrics the rules of a baseline can be set on
+const (
+	BaselineMetricMean       = "mean"
+	BaselineMetricP50        = "p50"
+	BaselineMetricP95        = "p95"
+	BaselineMetricP99        = "p99"
+	BaselineMetricMax        = "max"
+	BaselineMetricSuccess    = "success"
+	BaselineMetricThroughput = "throughput"
+	BaselineMetricRate       = "rate"
+)
+
+// Statuses of the verdict of an attack evaluated against a baseline
+const (
+	// BaselinePassed means the attack satisfied all rules of the baseline
+	BaselinePassed = "passed"
+	// BaselineFailed means the attack broke a rule, did not complete or
+	// could not be compared
+	BaselineFailed = "failed"
+	// BaselinePending means the attack is yet to be evaluated
+	BaselinePending = "pending"
+)
+
+// Baseline marks an attack as the baseline of the attacks carrying its
+// labels. Each attack completing with matching labels is compared against
+// the baseline attack, and must satisfy all of the rules.
+type Baseline struct {
+	// Labels an attack must carry to be evaluated against the baseline.
+	// They default to the labels of the baseline attack, and must be a
+	// subset of them.
+	Labels map[string]string `json:"labels,omitempty"`
+	Rules  []BaselineRule    `json:"rules"`
+}
+
+// Validate checks the baseline for missing or malformed rules
+func (b Baseline) Validate() error {
+	if err := ValidateLabels(b.Labels); err != nil {
+		return err
+	}
+	if len(b.Rules) == 0 {
+		return fmt.Errorf("baseline needs at least one rule")
+	}
+	for i, rule := range b.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether an attack with the given labels is evaluated
+// against the baseline
+func (b Baseline) Matches(labels map[string]string) bool {
+	for k, v := range b.Labels {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate checks the rules against the comparison of an attack to the
+// baseline attack
+func (b Baseline) Evaluate(cmp *ComparisonResponse) []RuleCheck {
+	checks := make([]RuleCheck, 0, len(b.Rules))
+	for _, rule := range b.Rules {
+		checks = append(checks, rule.Check(cmp))
+	}
+	return checks
+}
+
+// BaselineRule bounds a single metric of the attacks evaluated against a
+// baseline. Latencies are in milliseconds, throughput and rate in requests
+// per second, and success is a ratio.
+type BaselineRule struct {
+	// Metric is one of mean, p50, p95, p99, max, success, throughput or rate
+	Metric string `json:"metric"`
+	// MaxRegression is the largest change for the worse relative to the
+	// baseline, e.g. 0.1 for a p99 up to 10% higher or a throughput up to
+	// 10% lower
+	MaxRegression *float64 `json:"max_regression,omitempty"`
+	// Min is the least absolute value of the metric
+	Min *float64 `json:"min,omitempty"`
+	// Max is the greatest absolute value of the metric
+	Max *float64 `json:"max,omitempty"`
+}
+
+// Validate checks the rule for an unknown metric or missing bounds
+func (r BaselineRule) Validate() error {
+	switch r.Metric {
+	case BaselineMetricMean, BaselineMetricP50, BaselineMetricP95, BaselineMetricP99, BaselineMetricMax,
+		BaselineMetricSuccess, BaselineMetricThroughput, BaselineMetricRate:
+	default:
+		return fmt.Errorf("unsupported metric %q", r.Metric)
+	}
+	if r.MaxRegression == nil && r.Min == nil && r.Max == nil {
+		return fmt.Errorf("one of max_regression, min or max is required")
+	}
+	if r.MaxRegression != nil && *r.MaxRegression < 0 {
+		return fmt.Errorf("max_regression must not be negative")
+	}
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		return fmt.Errorf("min must not exceed max")
+	}
+	return nil
+}
+
+// Check evaluates the rule against the comparison of an attack to the
+// baseline attack
+func (r BaselineRule) Check(cmp *ComparisonResponse) RuleCheck {
+	d, lowerIsBetter := r.delta(cmp)
+	check := RuleCheck{BaselineRule: r, Base: d.Base, Candidate: d.Candidate, Passed: true}
+
+	worse := d.Delta
+	if !lowerIsBetter {
+		worse = -worse
+	}
+	if d.Base != 0 {
+		regression := worse / d.Base
+		check.Regression = &regression
+	}
+
+	switch {
+	case r.Min != nil && d.Candidate < *r.Min:
+		check.Reason = fmt.Sprintf("%s of %g is below %g", r.Metric, d.Candidate, *r.Min)
+	case r.Max != nil && d.Candidate > *r.Max:
+		check.Reason = fmt.Sprintf("%s of %g is above %g", r.Metric, d.Candidate, *r.Max)
+	case r.MaxRegression == nil || worse <= 0:
+	case check.Regression == nil:
+		check.Reason = fmt.Sprintf("%s regressed from 0 to %g", r.Metric, d.Candidate)
+	case *check.Regression > *r.MaxRegression:
+		check.Reason = fmt.Sprintf("%s regressed by %.2f%%, more than %.2f%%",
+			r.Metric, *check.Regression*100, *r.MaxRegression*100)
+	}
+	check.Passed = check.Reason == ""
+	return check
+}
+
+// delta returns the delta of the metric of the rule, in the units of the
+// rule, and whether lower values are better
+func (r BaselineRule) delta(cmp *ComparisonResponse) (Delta, bool) {
+	latency := func(d Delta) Delta {
+		return NewDelta(d.Base/float64(time.Millisecond), d.Candidate/float64(time.Millisecond))
+	}
+	switch r.Metric {
+	case BaselineMetricMean:
+		return latency(cmp.Deltas.Latencies.Mean), true
+	case BaselineMetricP50:
+		return latency(cmp.Deltas.Latencies.P50th), true
+	case BaselineMetricP95:
+		return latency(cmp.Deltas.Latencies.P95th), true
+	case BaselineMetricP99:
+		return latency(cmp.Deltas.Latencies.P99th), true
+	case BaselineMetricMax:
+		return latency(cmp.Deltas.Latencies.Max), true
+	case BaselineMetricThroughput:
+		return cmp.Deltas.Throughput, false
+	case BaselineMetricRate:
+		return cmp.Deltas.Rate, false
+	}
+	return cmp.Deltas.Success, false
+}
+
+// RuleCheck is the outcome of a single rule of a baseline
+type RuleCheck struct {
+	BaselineRule
+	Base      float64 `json:"base"`
+	Candidate float64 `json:"candidate"`
+	// Regression is the change for the worse relative to the baseline,
+	// negative for improvements and missing if the baseline value is 0
+	Regression *float64 `json:"regression,omitempty"`
+	Passed     bool     `json:"passed"`
+	// Reason the rule was broken
+	Reason string `json:"reason,omitempty"`
+}
+
+// BaselineVerdict is the outcome of evaluating an attack against a baseline
+type BaselineVerdict struct {
+	// Status is passed, failed or pending
+	Status string `json:"status"`
+	// Baseline is the ID of the baseline attack
+	Baseline string `json:"baseline"`
+	// Labels of the baseline the attack was matched by
+	Labels      map[string]string `json:"labels"`
+	Checks      []RuleCheck       `json:"checks"`
+	EvaluatedAt *time.Time        `json:"evaluated_at,omitempty"`
+	// Error the attack could not be evaluated with, failing it
+	Error string `json:"error,omitempty"`
+}
+
+// NewBaselineVerdict returns the verdict of the rule checks, or of the error
+// the attack could not be evaluated with
+func NewBaselineVerdict(baselineID string, baseline Baseline, checks []RuleCheck, err error) *BaselineVerdict {
+	now := time.Now()
+	v := PendingBaselineVerdict(baselineID, baseline)
+	v.Status, v.EvaluatedAt = BaselinePassed, &now
+	if checks != nil {
+		v.Checks = checks
+	}
+	if err != nil {
+		v.Status, v.Error = BaselineFailed, err.Error()
+	}
+	for _, check := range v.Checks {
+		if !check.Passed {
+			v.Status = BaselineFailed
+		}
+	}
+	return v
+}
+
+// PendingBaselineVerdict returns the verdict of an attack yet to be evaluated
+// against the baseline
+func PendingBaselineVerdict(baselineID string, baseline Baseline) *BaselineVerdict {
+	return &BaselineVerdict{
+		Status:   BaselinePending,
+		Baseline: baselineID,
+		Labels:   baseline.Labels,
+		Checks:   make([]RuleCheck, 0),
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBaseline_Validate(t *testing.T) {
+	ratio := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name     string
+		baseline Baseline
+		wantErr  bool
+	}{
+		{"Valid", Baseline{Rules: []BaselineRule{{Metric: BaselineMetricP99, MaxRegression: ratio(0.1)}}}, false},
+		{"Valid bounds", Baseline{Rules: []BaselineRule{{Metric: BaselineMetricSuccess, Min: ratio(0.999), Max: ratio(1)}}}, false},
+		{"No rules", Baseline{}, true},
+		{"Invalid label", Baseline{Labels: map[string]string{"env": "a,b"}, Rules: []BaselineRule{{Metric: BaselineMetricP99, Max: ratio(1)}}}, true},
+		{"Unknown metric", Baseline{Rules: []BaselineRule{{Metric: "p90", Max: ratio(1)}}}, true},
+		{"No bounds", Baseline{Rules: []BaselineRule{{Metric: BaselineMetricP99}}}, true},
+		{"Negative regression", Baseline{Rules: []BaselineRule{{Metric: BaselineMetricP99, MaxRegression: ratio(-0.1)}}}, true},
+		{"Min above max", Baseline{Rules: []BaselineRule{{Metric: BaselineMetricRate, Min: ratio(2), Max: ratio(1)}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.baseline.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBaseline_Matches(t *testing.T) {
+	b := Baseline{Labels: map[string]string{"service": "checkout"}}
+	if !b.Matches(map[string]string{"service": "checkout", "env": "staging"}) {
+		t.Error("Matches() = false for a superset of the labels")
+	}
+	if b.Matches(map[string]string{"service": "cart"}) || b.Matches(nil) {
+		t.Error("Matches() = true for other labels")
+	}
+}
+
+func TestBaselineRule_Check(t *testing.T) {
+	ratio := func(f float64) *float64 { return &f }
+	cmp := &ComparisonResponse{}
+	cmp.Deltas.Latencies.P99th = NewDelta(float64(100*time.Millisecond), float64(115*time.Millisecond))
+	cmp.Deltas.Latencies.Mean = NewDelta(float64(50*time.Millisecond), float64(40*time.Millisecond))
+	cmp.Deltas.Success = NewDelta(1, 0.998)
+	cmp.Deltas.Throughput = NewDelta(0, 10)
+	cmp.Deltas.Rate = NewDelta(100, 95)
+
+	tests := []struct {
+		name           string
+		rule           BaselineRule
+		wantPassed     bool
+		wantRegression float64
+	}{
+		{"Latency regressed", BaselineRule{Metric: BaselineMetricP99, MaxRegression: ratio(0.1)}, false, 0.15},
+		{"Latency within tolerance", BaselineRule{Metric: BaselineMetricP99, MaxRegression: ratio(0.2)}, true, 0.15},
+		{"Latency improved", BaselineRule{Metric: BaselineMetricMean, MaxRegression: ratio(0)}, true, -0.2},
+		{"Latency above max", BaselineRule{Metric: BaselineMetricP99, Max: ratio(110)}, false, 0.15},
+		{"Success below min", BaselineRule{Metric: BaselineMetricSuccess, Min: ratio(0.999)}, false, 0.002},
+		{"Rate regressed", BaselineRule{Metric: BaselineMetricRate, MaxRegression: ratio(0.01)}, false, 0.05},
+		{"Throughput from 0", BaselineRule{Metric: BaselineMetricThroughput, MaxRegression: ratio(0)}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.Check(cmp)
+			if got.Passed != tt.wantPassed || got.Passed != (got.Reason == "") {
+				t.Errorf("Check() passed = %v (%s), want %v", got.Passed, got.Reason, tt.wantPassed)
+			}
+			if got.Regression != nil && (*got.Regression-tt.wantRegression > 1e-9 || tt.wantRegression-*got.Regression > 1e-9) {
+				t.Errorf("Check() regression = %v, want %v", *got.Regression, tt.wantRegression)
+			}
+		})
+	}
+}
+
+func TestNewBaselineVerdict(t *testing.T) {
+	b := Baseline{Labels: map[string]string{"service": "checkout"}}
+
+	if v := NewBaselineVerdict("123", b, []RuleCheck{{Passed: true}}, nil); v.Status != BaselinePassed || v.EvaluatedAt == nil {
+		t.Errorf("NewBaselineVerdict() = %+v, want passed", v)
+	}
+	if v := NewBaselineVerdict("123", b, []RuleCheck{{Passed: true}, {}}, nil); v.Status != BaselineFailed {
+		t.Errorf("NewBaselineVerdict() = %+v, want failed on a broken rule", v)
+	}
+	if v := NewBaselineVerdict("123", b, nil, fmt.Errorf("attack is failed")); v.Status != BaselineFailed || v.Error == "" || v.Checks == nil {
+		t.Errorf("NewBaselineVerdict() = %+v, want failed on an error", v)
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+// Verdicts of the latency significance test of a comparison
+const (
+	// VerdictSlower means the candidate latencies are significantly higher
+	VerdictSlower = "slower"
+	// VerdictFaster means the candidate latencies are significantly lower
+	VerdictFaster = "faster"
+	// VerdictNoChange means no significant difference was found
+	VerdictNoChange = "no-change"
+)
+
+// DefaultComparisonAlpha is the significance level of comparisons
+const DefaultComparisonAlpha = 0.05
+
+// ComparisonResponse provides the model for the comparison of a candidate
+// attack against a base attack
+type ComparisonResponse struct {
+	Base      ComparedAttack `json:"base"`
+	Candidate ComparedAttack `json:"candidate"`
+	// Deltas of the metrics, from the base to the candidate
+	Deltas ComparisonDeltas `json:"deltas"`
+	// StatusCodes holds the deltas of the number of responses by status code
+	StatusCodes map[string]Delta `json:"status_codes"`
+	Errors      ErrorDiff        `json:"errors"`
+	// LatencyTest tests whether the latencies of the attacks differ
+	LatencyTest SignificanceTest `json:"latency_test"`
+}
+
+// ComparedAttack holds the metrics of one of the compared attacks
+type ComparedAttack struct {
+	ID string `json:"id"`
+	JSONMetrics
+	Throughput float64 `json:"throughput"`
+}
+
+// Delta is the change of a metric from the base to the candidate
+type Delta struct {
+	Base      float64 `json:"base"`
+	Candidate float64 `json:"candidate"`
+	// Delta is the absolute change, candidate minus base
+	Delta float64 `json:"delta"`
+	// Relative is the change relative to the base, missing if the base is 0
+	Relative *float64 `json:"relative,omitempty"`
+}
+
+// NewDelta returns the change from base to candidate
+func NewDelta(base, candidate float64) Delta {
+	d := Delta{Base: base, Candidate: candidate, Delta: candidate - base}
+	if base != 0 {
+		relative := d.Delta / base
+		d.Relative = &relative
+	}
+	return d
+}
+
+// ComparisonDeltas holds the changes of the metrics of a comparison.
+// Latencies are in nanoseconds.
+type ComparisonDeltas struct {
+	Requests   Delta `json:"requests"`
+	Rate       Delta `json:"rate"`
+	Throughput Delta `json:"throughput"`
+	Success    Delta `json:"success"`
+	Latencies  struct {
+		Mean  Delta `json:"mean"`
+		P50th Delta `json:"50th"`
+		P95th Delta `json:"95th"`
+		P99th Delta `json:"99th"`
+		Max   Delta `json:"max"`
+	} `json:"latencies"`
+}
+
+// ErrorDiff compares the errors of the attacks
+type ErrorDiff struct {
+	// New errors only occurred in the candidate
+	New []string `json:"new"`
+	// Resolved errors only occurred in the base
+	Resolved []string `json:"resolved"`
+	// Common errors occurred in both attacks
+	Common []string `json:"common"`
+}
+
+// SignificanceTest is the outcome of a two-sided test of the latency
+// distributions of the attacks
+type SignificanceTest struct {
+	// Test names the test, mann-whitney-u
+	Test string `json:"test"`
+	// U is the statistic of the candidate latencies
+	U float64 `json:"u"`
+	// Z is the normal approximation of U, corrected for ties
+	Z      float64 `json:"z"`
+	PValue float64 `json:"p_value"`
+	Alpha  float64 `json:"alpha"`
+	// Significant is set if the p-value is below alpha
+	Significant bool `json:"significant"`
+	// Effect is the probability that a candidate latency exceeds a base
+	// latency, ties counting half. Above 0.5 the candidate is slower.
+	Effect float64 `json:"effect"`
+	// Verdict is one of slower, faster or no-change
+	Verdict string `json:"verdict"`
+}
@@ -3,22 +3,35 @@ package models
 import (
 	"encoding/json"
 	"fmt"
-	"sync"
+	"strings"
+	"time"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
 )
 
+// ErrRevisionConflict is returned when updating an attack whose revision
+// changed since it was read
+var ErrRevisionConflict = errors.New("attack was modified concurrently")
+
 // IAttackStore captures all methods related to storing and retrieving attack details
 type IAttackStore interface {
-	// Add item by its ID string
+	// Add item by its ID string, replacing any existing item unconditionally.
+	// The stored revision is one above the item's revision.
 	Add(AttackDetails) error
 
-	// GetAll items
-	GetAll(filters FilterParams) []AttackDetails
+	// GetAll items matching the filters, sorted and paginated by the list
+	// options, along with the total number of matching items
+	GetAll(filters FilterParams, opts ListOptions) ([]AttackDetails, int)
+	// GetBaselines gets the items marked as baselines, from an index kept
+	// as items are written rather than by reading every item
+	GetBaselines() []AttackDetails
 	// GetByID gets an item by its ID
 	GetByID(string) (AttackDetails, error)
 
-	// Update multiple fields in an item
+	// Update multiple fields in an item. The update only succeeds if the
+	// item's revision matches the stored one, otherwise ErrRevisionConflict
+	// is returned. The stored revision is incremented on success.
 	Update(string, AttackDetails) error
 	// Set a member field
 	//Set(string, string, interface{}) error
@@ -27,16 +40,31 @@ type IAttackStore interface {
 	Delete(string) error
 }
 
-var mu sync.RWMutex
+const (
+	// redisIndexPrefix namespaces index keys from attack keys
+	redisIndexPrefix = "index:"
+	// redisBaselinesKey holds the set of the IDs of baseline attacks
+	redisBaselinesKey = redisIndexPrefix + "baselines"
+)
+
+// redisTTLGrace is added to the retention max age when setting key TTLs, so
+// the dispatcher normally expires attacks (and their results) first and the
+// TTL only acts as a backstop while the server is down.
+const redisTTLGrace = time.Hour
 
 // Redis stores all Attack/Report information in a redis database
 type Redis struct {
-	connFn func() redis.Conn
+	connFn    func() redis.Conn
+	retention RetentionPolicy
 }
 
-func NewRedis(f func() redis.Conn) Redis {
+// NewRedis constructs a new instance of Redis. Keys of attacks that expire
+// by age under the retention policy are given a native TTL, as are the keys
+// of their results, samples and uploads kept in redis.
+func NewRedis(f func() redis.Conn, retention RetentionPolicy) Redis {
 	return Redis{
 		f,
+		retention,
 	}
 }
 
@@ -44,44 +72,124 @@ func (r Redis) Add(attack AttackDetails) error {
 	conn := r.connFn()
 	defer conn.Close()
 
-	v, err := json.Marshal(attack)
+	attack.Revision++
+	args, err := r.setArgs(attack)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Do("SET", attack.ID, v)
-	if err != nil {
+
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := conn.Send("SET", args...); err != nil {
 		return err
 	}
+	if err := r.sendIndex(conn, attack); err != nil {
+		return err
+	}
+	if err := r.sendFileTTLs(conn, attack); err != nil {
+		return err
+	}
+	_, err = conn.Do("EXEC")
+	return err
+}
+
+// sendIndex queues the update of the indexes for a written attack
+func (r Redis) sendIndex(conn redis.Conn, attack AttackDetails) error {
+	if attack.Baseline != nil {
+		return conn.Send("SADD", redisBaselinesKey, attack.ID)
+	}
+	return conn.Send("SREM", redisBaselinesKey, attack.ID)
+}
+
+// setArgs returns the SET command arguments to store an attack
+func (r Redis) setArgs(attack AttackDetails) ([]interface{}, error) {
+	v, err := json.Marshal(attack)
+	if err != nil {
+		return nil, err
+	}
+
+	// SET without an expiry also clears the TTL, e.g. once an attack is pinned
+	args := []interface{}{attack.ID, v}
+	if ttl := r.ttl(attack); ttl > 0 {
+		args = append(args, "PX", int64(ttl/time.Millisecond))
+	}
+	return args, nil
+}
+
+// ttl returns the native TTL of the keys of an attack, zero if they are kept
+func (r Redis) ttl(attack AttackDetails) time.Duration {
+	ttl := r.retention.TTL(attack.Status)
+	if ttl <= 0 || attack.Pinned || attack.Baseline != nil {
+		return 0
+	}
+	return ttl + redisTTLGrace
+}
+
+// sendFileTTLs queues giving the stored files of an attack the TTL of the
+// attack, or clearing it. Files kept in another result store have no key
+// here, and are left to the dispatcher.
+func (r Redis) sendFileTTLs(conn redis.Conn, attack AttackDetails) error {
+	ttl := r.ttl(attack)
+	for _, key := range attack.storedKeys() {
+		var err error
+		if ttl > 0 {
+			err = conn.Send("PEXPIRE", redisResultPrefix+key, int64(ttl/time.Millisecond))
+		} else {
+			err = conn.Send("PERSIST", redisResultPrefix+key)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (r Redis) GetAll(filterParams FilterParams) []AttackDetails {
+// get reads an attack using the given connection
+func (r Redis) get(conn redis.Conn, id interface{}) (AttackDetails, error) {
+	var attack AttackDetails
+
+	b, err := redis.Bytes(conn.Do("GET", id))
+	if err == redis.ErrNil {
+		return attack, fmt.Errorf("attack with id %s not found", id)
+	}
+	if err != nil {
+		return attack, err
+	}
+
+	err = json.Unmarshal(b, &attack)
+	return attack, err
+}
+
+func (r Redis) GetAll(filterParams FilterParams, opts ListOptions) ([]AttackDetails, int) {
 	attacks := make([]AttackDetails, 0)
 
-	filters := createFilterChain(filterParams)
+	// Malformed filters match nothing, callers validate them upfront
+	filters, err := createFilterChain(filterParams, time.Now())
+	if err != nil {
+		return attacks, 0
+	}
 	conn := r.connFn()
 	defer conn.Close()
 
 	res, err := conn.Do("KEYS", "*")
 	if err != nil {
-		return nil
+		return nil, 0
 	}
 	attackIDs, ok := res.([]interface{})
 	if !ok {
-		return nil
+		return nil, 0
 	}
 
 	for _, attackID := range attackIDs {
-		var attack AttackDetails
-
-		res, err := conn.Do("GET", attackID)
-		if err != nil {
-			return nil
+		if key, _ := redis.String(attackID, nil); strings.HasPrefix(key, redisResultPrefix) ||
+			strings.HasPrefix(key, redisIndexPrefix) {
+			continue
 		}
-
-		err = json.Unmarshal(res.([]byte), &attack)
+		attack, err := r.get(conn, attackID)
 		if err != nil {
-			return nil
+			// Expired or deleted since listing the keys
+			continue
 		}
 		for _, filter := range filters {
 			if !filter(attack) {
@@ -92,152 +200,102 @@ func (r Redis) GetAll(filterParams FilterParams) []AttackDetails {
 	skip:
 	}
 
-	return attacks
+	return opts.Apply(attacks), len(attacks)
 }
 
-func (r Redis) GetByID(id string) (AttackDetails, error) {
-	var attack AttackDetails
+// GetBaselines reads the attacks in the baselines index
+func (r Redis) GetBaselines() []AttackDetails {
 	conn := r.connFn()
 	defer conn.Close()
 
-	res, err := conn.Do("GET", id)
+	attacks := make([]AttackDetails, 0)
+	ids, err := redis.Strings(conn.Do("SMEMBERS", redisBaselinesKey))
 	if err != nil {
-		return attack, err
+		return attacks
 	}
-
-	err = json.Unmarshal(res.([]byte), &attack)
-	if err != nil {
-		return attack, err
+	for _, id := range ids {
+		attack, err := r.get(conn, id)
+		// Deleted or unmarked since reading the index
+		if err != nil || attack.Baseline == nil {
+			continue
+		}
+		attacks = append(attacks, attack)
 	}
+	return attacks
+}
 
-	return attack, err
+func (r Redis) GetByID(id string) (AttackDetails, error) {
+	conn := r.connFn()
+	defer conn.Close()
+
+	return r.get(conn, id)
 }
 
+// Update compares and swaps the attack in a transaction that is aborted
+// if the key changes after reading the stored revision
 func (r Redis) Update(id string, attack AttackDetails) error {
 	if attack.ID != id {
 		return fmt.Errorf("update ID %s and attack ID %s do not match", id, attack.ID)
 	}
-	return r.Add(attack)
-}
 
-func (r Redis) Delete(id string) error {
 	conn := r.connFn()
 	defer conn.Close()
 
-	_, err := conn.Do("DEL", id)
-	if err != nil {
+	if _, err := conn.Do("WATCH", id); err != nil {
 		return err
 	}
-	return nil
-}
-
-// TaskMap is a map of attack ID's to their AttackDetails
-type TaskMap map[string]AttackDetails
-
-// NewTaskMap constructs a new instance of TaskMap
-func NewTaskMap() TaskMap {
-	return make(TaskMap)
-}
-
-// Add attack details by ID to store
-func (tm TaskMap) Add(attack AttackDetails) error {
-	mu.Lock()
-	defer mu.Unlock()
-
-	tm[attack.ID] = attack
+	defer conn.Do("UNWATCH") // nolint: errcheck
 
-	return nil
-}
-
-// GetAll attacks and details from store
-func (tm TaskMap) GetAll(filterParams FilterParams) []AttackDetails {
-	mu.RLock()
-	defer mu.RUnlock()
-
-	filters := createFilterChain(filterParams)
-	attacks := make([]AttackDetails, 0)
-	for _, attack := range tm {
-		for _, filter := range filters {
-			if !filter(attack) {
-				goto skip
-			}
-		}
-		attacks = append(attacks, attack)
-	skip:
+	stored, err := r.get(conn, id)
+	if err != nil {
+		return err
 	}
-
-	return attacks
-}
-
-// GetByID returns an attack detail by ID
-func (tm TaskMap) GetByID(id string) (AttackDetails, error) {
-	mu.RLock()
-	defer mu.RUnlock()
-
-	attack, ok := tm[id]
-	if !ok {
-		return AttackDetails{}, fmt.Errorf("attack with id %s not found", id)
+	if stored.Revision != attack.Revision {
+		return ErrRevisionConflict
 	}
 
-	return attack, nil
-}
-
-// Update an attack detail in the store
-func (tm TaskMap) Update(id string, attack AttackDetails) error {
-	if attack.ID != id {
-		return fmt.Errorf("update ID %s and attack ID %s do not match", id, attack.ID)
+	attack.Revision++
+	args, err := r.setArgs(attack)
+	if err != nil {
+		return err
 	}
 
-	mu.RLock()
-	_, ok := tm[id]
-	mu.RUnlock()
-	if !ok {
-		return fmt.Errorf("attack with id %s not found", id)
+	if err := conn.Send("MULTI"); err != nil {
+		return err
 	}
-
-	mu.Lock()
-	tm[id] = attack
-	mu.Unlock()
-
-	return nil
-}
-
-// Delete an attack by ID from the store
-func (tm TaskMap) Delete(id string) error {
-	mu.RLock()
-	_, ok := tm[id]
-	mu.RUnlock()
-	if !ok {
-		return fmt.Errorf("attack with id %s not found", id)
+	if err := conn.Send("SET", args...); err != nil {
+		return err
+	}
+	if err := r.sendIndex(conn, attack); err != nil {
+		return err
+	}
+	if err := r.sendFileTTLs(conn, attack); err != nil {
+		return err
+	}
+	res, err := conn.Do("EXEC")
+	if err != nil {
+		return err
+	}
+	// A nil reply means the watched key changed and the transaction was aborted
+	if res == nil {
+		return ErrRevisionConflict
 	}
-
-	mu.Lock()
-	defer mu.Unlock()
-
-	delete(tm, id)
-
 	return nil
 }
 
-func createFilterChain(params FilterParams) []Filter {
-	filters := make([]Filter, 0)
-	if status, ok := params["status"]; ok {
-		filters = append(
-			filters,
-			StatusFilter(status.(string)),
-		)
-	}
-	if createdBefore, ok := params["created_before"]; ok {
-		filters = append(
-			filters,
-			CreationBeforeFilter(createdBefore.(string)),
-		)
-	}
-	if createdAfter, ok := params["created_after"]; ok {
-		filters = append(
-			filters,
-			CreationAfterFilter(createdAfter.(string)),
-		)
-	}
-	return filters
+func (r Redis) Delete(id string) error {
+	conn := r.connFn()
+	defer conn.Close()
+
+	if err := conn.Send("MULTI"); err != nil {
+		return err
+	}
+	if err := conn.Send("DEL", id); err != nil {
+		return err
+	}
+	if err := conn.Send("SREM", redisBaselinesKey, id); err != nil {
+		return err
+	}
+	_, err := conn.Do("EXEC")
+	return err
 }
@@ -3,6 +3,7 @@ package models
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestTaskMap_Add(t *testing.T) {
@@ -11,13 +12,13 @@ func TestTaskMap_Add(t *testing.T) {
 	}
 	tests := []struct {
 		name    string
-		tm      TaskMap
+		tm      *TaskMap
 		args    args
 		wantErr bool
 	}{
 		{
 			name: "OK",
-			tm:   make(TaskMap),
+			tm:   NewTaskMap(),
 			args: args{
 				attack: AttackDetails{
 					AttackInfo: AttackInfo{
@@ -45,7 +46,7 @@ type argsAll struct {
 // testAll is test struct for the GetAll test case
 type testAll struct {
 	name string
-	tm   TaskMap
+	tm   *TaskMap
 	args argsAll
 	want []AttackDetails
 }
@@ -55,13 +56,13 @@ func TestTaskMap_GetAll(t *testing.T) {
 
 	ok := testAll{
 		name: "OK",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID: "1",
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: make(FilterParams),
 		},
@@ -80,7 +81,7 @@ func TestTaskMap_GetAll(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.tm.GetAll(tt.args.filterParams); !reflect.DeepEqual(got, tt.want) {
+			if got, _ := tt.tm.GetAll(tt.args.filterParams, ListOptions{}); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("TaskMap.GetAll() = %v, want %v", got, tt.want)
 			}
 		})
@@ -92,14 +93,14 @@ func dataStatus() []testAll {
 
 	match := testAll{
 		name: "OK - With Status filter match",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:     "1",
 					Status: AttackResponseStatusCompleted,
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"status": "completed",
@@ -117,14 +118,14 @@ func dataStatus() []testAll {
 
 	mismatch := testAll{
 		name: "OK - With Status filter mismatch",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:     "1",
 					Status: AttackResponseStatusCompleted,
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"status": "failed",
@@ -135,14 +136,14 @@ func dataStatus() []testAll {
 
 	empty := testAll{
 		name: "OK - With Status filter empty",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:     "1",
 					Status: AttackResponseStatusCompleted,
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"status": "",
@@ -166,7 +167,7 @@ func dataBefore() []testAll {
 	t := make([]testAll, 0)
 	match := testAll{
 		name: "OK - With Created_Before filter match",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:        "1",
@@ -179,7 +180,7 @@ func dataBefore() []testAll {
 					CreatedAt: "Sun, 02 Jan 2022 01:00:00 MST",
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"created_before": "2020-02-05 01:00:02",
@@ -196,40 +197,33 @@ func dataBefore() []testAll {
 	}
 
 	failed := testAll{
-		name: "OK - With Created_Before filter failed",
-		tm: TaskMap{
+		name: "OK - With Created_Before filter malformed",
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:        "1",
 					CreatedAt: "Wed, 02 Jan 2019 01:00:00 MST",
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"created_before": "bad date",
 			},
 		},
-		want: []AttackDetails{
-			{
-				AttackInfo: AttackInfo{
-					ID:        "1",
-					CreatedAt: "Wed, 02 Jan 2019 01:00:00 MST",
-				},
-			},
-		},
+		want: []AttackDetails{},
 	}
 
 	empty := testAll{
 		name: "OK - With Created_Before filter empty",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:        "1",
 					CreatedAt: "Wed, 02 Jan 2019 01:00:00 MST",
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"created_before": "",
@@ -253,7 +247,7 @@ func dataAfter() []testAll {
 	t := make([]testAll, 0)
 	match := testAll{
 		name: "OK - With Created_After filter match",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:        "1",
@@ -266,7 +260,7 @@ func dataAfter() []testAll {
 					CreatedAt: "Sun, 02 Jan 2022 01:00:00 MST",
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"created_after": "2020-05-17 01:02:03",
@@ -283,40 +277,33 @@ func dataAfter() []testAll {
 	}
 
 	failed := testAll{
-		name: "OK - With Created_After filter failed",
-		tm: TaskMap{
+		name: "OK - With Created_After filter malformed",
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:        "1",
 					CreatedAt: "Wed, 02 Jan 2019 01:00:00 MST",
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"created_after": "bad date",
 			},
 		},
-		want: []AttackDetails{
-			{
-				AttackInfo: AttackInfo{
-					ID:        "1",
-					CreatedAt: "Wed, 02 Jan 2019 01:00:00 MST",
-				},
-			},
-		},
+		want: []AttackDetails{},
 	}
 
 	empty := testAll{
 		name: "OK - With Created_After filter empty",
-		tm: TaskMap{
+		tm: taskMapOf(map[string]AttackDetails{
 			"1": AttackDetails{
 				AttackInfo: AttackInfo{
 					ID:        "1",
 					CreatedAt: "Wed, 02 Jan 2019 01:00:00 MST",
 				},
 			},
-		},
+		}),
 		args: argsAll{
 			filterParams: FilterParams{
 				"created_after": "",
@@ -342,20 +329,20 @@ func TestTaskMap_GetByID(t *testing.T) {
 	}
 	tests := []struct {
 		name    string
-		tm      TaskMap
+		tm      *TaskMap
 		args    args
 		want    AttackDetails
 		wantErr bool
 	}{
 		{
 			name: "OK",
-			tm: TaskMap{
+			tm: taskMapOf(map[string]AttackDetails{
 				"1": AttackDetails{
 					AttackInfo: AttackInfo{
 						ID: "1",
 					},
 				},
-			},
+			}),
 			args: args{
 				id: "1",
 			},
@@ -368,13 +355,13 @@ func TestTaskMap_GetByID(t *testing.T) {
 		},
 		{
 			name: "OK",
-			tm: TaskMap{
+			tm: taskMapOf(map[string]AttackDetails{
 				"1": AttackDetails{
 					AttackInfo: AttackInfo{
 						ID: "1",
 					},
 				},
-			},
+			}),
 			args: args{
 				id: "2",
 			},
@@ -403,20 +390,20 @@ func TestTaskMap_Update(t *testing.T) {
 	}
 	tests := []struct {
 		name    string
-		tm      TaskMap
+		tm      *TaskMap
 		args    args
 		wantErr bool
 	}{
 		{
 			name: "OK",
-			tm: TaskMap{
+			tm: taskMapOf(map[string]AttackDetails{
 				"1": AttackDetails{
 					AttackInfo: AttackInfo{
 						ID:     "1",
 						Status: AttackResponseStatusRunning,
 					},
 				},
-			},
+			}),
 			args: args{
 				id: "1",
 				attack: AttackDetails{
@@ -430,14 +417,14 @@ func TestTaskMap_Update(t *testing.T) {
 		},
 		{
 			name: "Error : args id mismatch",
-			tm: TaskMap{
+			tm: taskMapOf(map[string]AttackDetails{
 				"1": AttackDetails{
 					AttackInfo: AttackInfo{
 						ID:     "1",
 						Status: AttackResponseStatusRunning,
 					},
 				},
-			},
+			}),
 			args: args{
 				id: "1",
 				attack: AttackDetails{
@@ -449,16 +436,39 @@ func TestTaskMap_Update(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Error : stale revision",
+			tm: taskMapOf(map[string]AttackDetails{
+				"1": AttackDetails{
+					AttackInfo: AttackInfo{
+						ID:       "1",
+						Status:   AttackResponseStatusRunning,
+						Revision: 2,
+					},
+				},
+			}),
+			args: args{
+				id: "1",
+				attack: AttackDetails{
+					AttackInfo: AttackInfo{
+						ID:       "1",
+						Status:   AttackResponseStatusCompleted,
+						Revision: 1,
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Error : ID not found",
-			tm: TaskMap{
+			tm: taskMapOf(map[string]AttackDetails{
 				"1": AttackDetails{
 					AttackInfo: AttackInfo{
 						ID:     "1",
 						Status: AttackResponseStatusRunning,
 					},
 				},
-			},
+			}),
 			args: args{
 				id: "2",
 				attack: AttackDetails{
@@ -480,26 +490,52 @@ func TestTaskMap_Update(t *testing.T) {
 	}
 }
 
+func TestTaskMap_Update_Revision(t *testing.T) {
+	tm := NewTaskMap()
+	attack := AttackDetails{AttackInfo: AttackInfo{ID: "1"}}
+	if err := tm.Add(attack); err != nil {
+		t.Fatalf("TaskMap.Add() error = %v", err)
+	}
+
+	stored, _ := tm.GetByID("1")
+	if stored.Revision != 1 {
+		t.Errorf("TaskMap.Add() revision = %v, want 1", stored.Revision)
+	}
+
+	if err := tm.Update("1", stored); err != nil {
+		t.Fatalf("TaskMap.Update() error = %v", err)
+	}
+	updated, _ := tm.GetByID("1")
+	if updated.Revision != 2 {
+		t.Errorf("TaskMap.Update() revision = %v, want 2", updated.Revision)
+	}
+
+	// Writing the previously read copy again must conflict
+	if err := tm.Update("1", stored); err != ErrRevisionConflict {
+		t.Errorf("TaskMap.Update() error = %v, want %v", err, ErrRevisionConflict)
+	}
+}
+
 func TestTaskMap_Delete(t *testing.T) {
 	type args struct {
 		id string
 	}
 	tests := []struct {
 		name    string
-		tm      TaskMap
+		tm      *TaskMap
 		args    args
 		wantErr bool
 	}{
 		{
 			name: "OK",
-			tm: TaskMap{
+			tm: taskMapOf(map[string]AttackDetails{
 				"1": AttackDetails{
 					AttackInfo: AttackInfo{
 						ID:     "1",
 						Status: AttackResponseStatusRunning,
 					},
 				},
-			},
+			}),
 			args: args{
 				id: "1",
 			},
@@ -507,14 +543,14 @@ func TestTaskMap_Delete(t *testing.T) {
 		},
 		{
 			name: "OK",
-			tm: TaskMap{
+			tm: taskMapOf(map[string]AttackDetails{
 				"1": AttackDetails{
 					AttackInfo: AttackInfo{
 						ID:     "1",
 						Status: AttackResponseStatusRunning,
 					},
 				},
-			},
+			}),
 			args: args{
 				id: "2",
 			},
@@ -533,11 +569,11 @@ func TestTaskMap_Delete(t *testing.T) {
 func TestNewTaskMap(t *testing.T) {
 	tests := []struct {
 		name string
-		want TaskMap
+		want *TaskMap
 	}{
 		{
 			name: "OK",
-			want: make(TaskMap),
+			want: NewTaskMap(),
 		},
 	}
 	for _, tt := range tests {
@@ -548,3 +584,38 @@ func TestNewTaskMap(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskMap_GetAll_ListOptions(t *testing.T) {
+	created := func(d time.Duration) string {
+		return time.Date(2019, 2, 18, 19, 0, 0, 0, time.UTC).Add(d).Format(time.RFC1123)
+	}
+	tm := taskMapOf(map[string]AttackDetails{
+		"a": AttackDetails{AttackInfo: AttackInfo{ID: "a", CreatedAt: created(2 * time.Minute), Params: AttackParams{Rate: 5}}},
+		"b": AttackDetails{AttackInfo: AttackInfo{ID: "b", CreatedAt: created(0), Params: AttackParams{Rate: 10}}},
+		"c": AttackDetails{AttackInfo: AttackInfo{ID: "c", CreatedAt: created(time.Minute), Params: AttackParams{Rate: 5}}},
+	})
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"Default - created_at", ListOptions{}, []string{"b", "c", "a"}},
+		{"Descending", ListOptions{Desc: true}, []string{"a", "c", "b"}},
+		{"Rate - ties by ID", ListOptions{SortBy: SortByRate}, []string{"a", "c", "b"}},
+		{"Offset and limit", ListOptions{Offset: 1, Limit: 1}, []string{"c"}},
+		{"Offset past end", ListOptions{Offset: 5}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := tm.GetAll(make(FilterParams), tt.opts)
+			ids := make([]string, 0)
+			for _, attack := range got {
+				ids = append(ids, attack.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) || total != 3 {
+				t.Errorf("TaskMap.GetAll() = %v, %d, want %v, 3", ids, total, tt.want)
+			}
+		})
+	}
+}
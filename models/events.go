@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// AttackEventType names a state transition in the life of an attack
+type AttackEventType string
+
+const (
+	// AttackEventScheduled is recorded when an attack is submitted
+	AttackEventScheduled AttackEventType = "scheduled"
+	// AttackEventRunning is recorded when an attack starts
+	AttackEventRunning AttackEventType = "running"
+	// AttackEventCanceled is recorded when an attack is canceled
+	AttackEventCanceled AttackEventType = "canceled"
+	// AttackEventCompleted is recorded when an attack finishes and its result is stored
+	AttackEventCompleted AttackEventType = "completed"
+	// AttackEventFailed is recorded when an attack or storing its result fails
+	AttackEventFailed AttackEventType = "failed"
+	// AttackEventPinned is recorded when an attack is exempted from retention
+	AttackEventPinned AttackEventType = "pinned"
+	// AttackEventUnpinned is recorded when an attack is subject to retention again
+	AttackEventUnpinned AttackEventType = "unpinned"
+	// AttackEventBaselined is recorded when an attack is marked as a baseline
+	AttackEventBaselined AttackEventType = "baselined"
+	// AttackEventUnbaselined is recorded when an attack is no longer a baseline
+	AttackEventUnbaselined AttackEventType = "unbaselined"
+	// AttackEventEvaluated is recorded when an attack is evaluated against a
+	// baseline, the reason holding the verdict
+	AttackEventEvaluated AttackEventType = "evaluated"
+	// AttackEventImported is recorded when an attack is imported from an archive
+	AttackEventImported AttackEventType = "imported"
+)
+
+const (
+	// ActorUser is the actor of events caused by API requests
+	ActorUser = "user"
+	// ActorDispatcher is the actor of events caused by the dispatcher itself
+	ActorDispatcher = "dispatcher"
+)
+
+// AttackEvent is an entry in the append-only event log of an attack
+type AttackEvent struct {
+	Type AttackEventType `json:"type"`
+	// Time of the transition with nanosecond precision
+	Time time.Time `json:"time"`
+	// Actor that caused the transition
+	Actor string `json:"actor"`
+	// Reason for the transition, e.g. the error an attack failed with
+	Reason string `json:"reason,omitempty"`
+}
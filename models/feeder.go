@@ -0,0 +1,167 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Formats data feeders are uploaded in
+const (
+	// FeederFormatCSV is a CSV file whose first row names the fields
+	FeederFormatCSV = "csv"
+	// FeederFormatJSONL is a JSON Lines file of objects
+	FeederFormatJSONL = "jsonl"
+)
+
+// Orders in which attacks read the records of a data feeder
+const (
+	// FeederOrderSequential cycles through the records in upload order
+	FeederOrderSequential = "sequential"
+	// FeederOrderRandom picks a random record for every request
+	FeederOrderRandom = "random"
+	// FeederOrderShuffle shuffles the records once, then cycles through them
+	FeederOrderShuffle = "shuffle"
+)
+
+// feederNamePattern restricts feeder names to characters that are safe in
+// result store keys
+var feederNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-]{0,62}$`)
+
+// Feed holds the records of a data feeder, each mapping field names to values
+type Feed []map[string]string
+
+// FeederParams selects the data feeder templated targets read values from
+type FeederParams struct {
+	// Name of the uploaded feeder
+	Name string `json:"name"`
+	// Order is one of sequential, random or shuffle, defaults to sequential
+	Order string `json:"order,omitempty"`
+}
+
+// FeederInfo describes an uploaded data feeder
+type FeederInfo struct {
+	Name    string   `json:"name"`
+	Records int      `json:"records"`
+	Fields  []string `json:"fields"`
+}
+
+// Validate checks the feeder name and order
+func (p FeederParams) Validate() error {
+	if err := ValidateFeederName(p.Name); err != nil {
+		return err
+	}
+	switch p.Order {
+	case "", FeederOrderSequential, FeederOrderRandom, FeederOrderShuffle:
+		return nil
+	}
+	return fmt.Errorf("unsupported feeder order %q", p.Order)
+}
+
+// ValidateFeederName checks that the name can be used as a feeder key
+func ValidateFeederName(name string) error {
+	if !feederNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid feeder name %q", name)
+	}
+	return nil
+}
+
+// FeederKey returns the result store key a feeder is kept under
+func FeederKey(name string) string {
+	return "feeder-" + name
+}
+
+// ParseFeed reads the records of a CSV or JSON Lines data feeder. Values of
+// JSON Lines records are converted to strings, objects and arrays to JSON.
+func ParseFeed(r io.Reader, format string) (Feed, error) {
+	switch format {
+	case FeederFormatCSV:
+		return parseCSVFeed(r)
+	case FeederFormatJSONL:
+		return parseJSONLFeed(r)
+	}
+	return nil, fmt.Errorf("unsupported feeder format %q", format)
+}
+
+func parseCSVFeed(r io.Reader) (Feed, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("feeder has no header row")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read feeder header")
+	}
+
+	feed := make(Feed, 0)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read feeder record")
+		}
+
+		record := make(map[string]string, len(header))
+		for i, field := range header {
+			record[field] = row[i]
+		}
+		feed = append(feed, record)
+	}
+	return feed, nil
+}
+
+func parseJSONLFeed(r io.Reader) (Feed, error) {
+	feed := make(Feed, 0)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to decode feeder record on line %d", line))
+		}
+		record := make(map[string]string, len(fields))
+		for k, v := range fields {
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				s = string(v)
+			}
+			record[k] = s
+		}
+		feed = append(feed, record)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read feeder")
+	}
+	return feed, nil
+}
+
+// Info describes the feed under the given name
+func (f Feed) Info(name string) FeederInfo {
+	seen := make(map[string]bool)
+	fields := make([]string, 0)
+	for _, record := range f {
+		for k := range record {
+			if !seen[k] {
+				seen[k] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	return FeederInfo{Name: name, Records: len(f), Fields: fields}
+}
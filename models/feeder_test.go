@@ -0,0 +1,97 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		want    Feed
+		wantErr bool
+	}{
+		{
+			name:   "CSV",
+			format: FeederFormatCSV,
+			data:   "user_id,name\n1,alice\n2,\"bob, jr\"\n",
+			want: Feed{
+				{"user_id": "1", "name": "alice"},
+				{"user_id": "2", "name": "bob, jr"},
+			},
+		},
+		{
+			name:   "JSON Lines",
+			format: FeederFormatJSONL,
+			data:   "{\"user_id\": 1, \"name\": \"alice\"}\n\n{\"user_id\": 2, \"tags\": [\"a\"]}\n",
+			want: Feed{
+				{"user_id": "1", "name": "alice"},
+				{"user_id": "2", "tags": `["a"]`},
+			},
+		},
+		{
+			name:    "CSV without header",
+			format:  FeederFormatCSV,
+			data:    "",
+			wantErr: true,
+		},
+		{
+			name:    "CSV with ragged rows",
+			format:  FeederFormatCSV,
+			data:    "a,b\n1\n",
+			wantErr: true,
+		},
+		{
+			name:    "JSON Lines with invalid record",
+			format:  FeederFormatJSONL,
+			data:    "{\"a\": 1}\n[1, 2]\n",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported format",
+			format:  "xml",
+			data:    "<a/>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFeed(strings.NewReader(tt.data), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFeed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFeed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeed_Info(t *testing.T) {
+	feed := Feed{{"b": "1", "a": "2"}, {"c": "3"}}
+	want := FeederInfo{Name: "users", Records: 2, Fields: []string{"a", "b", "c"}}
+	if got := feed.Info("users"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Feed.Info() = %v, want %v", got, want)
+	}
+}
+
+func TestFeederParams_Validate(t *testing.T) {
+	tests := []struct {
+		params  FeederParams
+		wantErr bool
+	}{
+		{FeederParams{Name: "users"}, false},
+		{FeederParams{Name: "users.v2", Order: FeederOrderShuffle}, false},
+		{FeederParams{Name: "users", Order: "reverse"}, true},
+		{FeederParams{Name: "../users"}, true},
+		{FeederParams{}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.params.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("FeederParams.Validate(%v) error = %v, wantErr %v", tt.params, err, tt.wantErr)
+		}
+	}
+}
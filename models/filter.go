@@ -0,0 +1,311 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterParams defines a map structure for the filter parameters received via
+// query params in the request URL
+type FilterParams map[string]interface{}
+
+// FilterKeys are the query params understood as attack filters
+var FilterKeys = []string{
+	"status",
+	"id_prefix",
+	"created_before",
+	"created_after",
+	"updated_before",
+	"updated_after",
+	"url",
+	"host",
+	"method",
+	"rate_min",
+	"rate_max",
+	"duration_min",
+	"duration_max",
+	"labels",
+}
+
+// Filter defines a type that must be implemented by
+// an attack filter
+type Filter func(AttackDetails) bool
+
+// ValidateFilters checks that all filter parameters are well-formed
+func ValidateFilters(params FilterParams) error {
+	_, err := createFilterChain(params, time.Now())
+	return err
+}
+
+// StatusFilter implements a attack status filter
+// in the Filter function format
+func StatusFilter(status string) Filter {
+	return func(a AttackDetails) bool {
+		if status == "" {
+			return true
+		}
+
+		if a.Status == AttackStatus(status) {
+			return true
+		}
+
+		return false
+	}
+}
+
+// IDPrefixFilter implements an attack ID prefix filter
+// in the Filter function format
+func IDPrefixFilter(prefix string) Filter {
+	return func(a AttackDetails) bool {
+		return strings.HasPrefix(a.ID, prefix)
+	}
+}
+
+// CreationBeforeFilter implements an attack created_before filter
+// in the Filter function format
+func CreationBeforeFilter(t time.Time) Filter {
+	return func(a AttackDetails) bool {
+		return attackTime(a.CreatedAt).Before(t)
+	}
+}
+
+// CreationAfterFilter implements an attack created_after filter
+// in the Filter function format
+func CreationAfterFilter(t time.Time) Filter {
+	return func(a AttackDetails) bool {
+		return attackTime(a.CreatedAt).After(t)
+	}
+}
+
+// UpdateBeforeFilter implements an attack updated_before filter
+// in the Filter function format
+func UpdateBeforeFilter(t time.Time) Filter {
+	return func(a AttackDetails) bool {
+		return attackTime(a.UpdatedAt).Before(t)
+	}
+}
+
+// UpdateAfterFilter implements an attack updated_after filter
+// in the Filter function format
+func UpdateAfterFilter(t time.Time) Filter {
+	return func(a AttackDetails) bool {
+		return attackTime(a.UpdatedAt).After(t)
+	}
+}
+
+// TargetFilter implements a filter for attacks with at least one target,
+// or scenario step, matching the predicate, in the Filter function format
+func TargetFilter(match func(Target) bool) Filter {
+	return func(a AttackDetails) bool {
+		for _, target := range a.Params.Target {
+			if match(target) {
+				return true
+			}
+		}
+		if a.Params.Scenario != nil {
+			for _, step := range a.Params.Scenario.Steps {
+				if match(step.Target) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// RateFilter implements an attack rate range filter in the Filter function
+// format. A zero bound is open.
+func RateFilter(min, max int) Filter {
+	return func(a AttackDetails) bool {
+		return a.Params.Rate >= min && (max == 0 || a.Params.Rate <= max)
+	}
+}
+
+// DurationFilter implements an attack duration range filter in the Filter
+// function format. A zero bound is open.
+func DurationFilter(min, max time.Duration) Filter {
+	return func(a AttackDetails) bool {
+		d, err := time.ParseDuration(a.Params.Duration)
+		if err != nil {
+			return false
+		}
+		return d >= min && (max == 0 || d <= max)
+	}
+}
+
+// HasResultFilter implements a filter for attacks with a stored result
+// in the Filter function format
+func HasResultFilter() Filter {
+	return func(a AttackDetails) bool {
+		return a.Result != nil
+	}
+}
+
+// createFilterChain turns the filter params into filters, failing on
+// malformed values. Relative times are resolved against now.
+func createFilterChain(params FilterParams, now time.Time) ([]Filter, error) {
+	filters := make([]Filter, 0)
+
+	str := func(key string) string {
+		v, _ := params[key].(string)
+		return strings.TrimSpace(v)
+	}
+
+	if status := str("status"); status != "" {
+		if !validStatus(AttackStatus(status)) {
+			return nil, fmt.Errorf("invalid status %q", status)
+		}
+		filters = append(filters, StatusFilter(status))
+	}
+	if prefix := str("id_prefix"); prefix != "" {
+		filters = append(filters, IDPrefixFilter(prefix))
+	}
+
+	timeFilters := []struct {
+		key    string
+		filter func(time.Time) Filter
+	}{
+		{"created_before", CreationBeforeFilter},
+		{"created_after", CreationAfterFilter},
+		{"updated_before", UpdateBeforeFilter},
+		{"updated_after", UpdateAfterFilter},
+	}
+	for _, tf := range timeFilters {
+		v := str(tf.key)
+		if v == "" {
+			continue
+		}
+		t, err := parseFilterTime(v, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", tf.key, err)
+		}
+		filters = append(filters, tf.filter(t))
+	}
+
+	if u := str("url"); u != "" {
+		filters = append(filters, TargetFilter(func(t Target) bool {
+			return strings.Contains(t.URL, u)
+		}))
+	}
+	if host := str("host"); host != "" {
+		filters = append(filters, TargetFilter(func(t Target) bool {
+			return targetHostMatches(t, host)
+		}))
+	}
+	if method := str("method"); method != "" {
+		filters = append(filters, TargetFilter(func(t Target) bool {
+			return strings.EqualFold(t.Method, method)
+		}))
+	}
+
+	rateMin, rateMax := str("rate_min"), str("rate_max")
+	if rateMin != "" || rateMax != "" {
+		min, err := parseFilterInt("rate_min", rateMin)
+		if err != nil {
+			return nil, err
+		}
+		max, err := parseFilterInt("rate_max", rateMax)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, RateFilter(min, max))
+	}
+
+	durationMin, durationMax := str("duration_min"), str("duration_max")
+	if durationMin != "" || durationMax != "" {
+		min, err := parseFilterDuration("duration_min", durationMin)
+		if err != nil {
+			return nil, err
+		}
+		max, err := parseFilterDuration("duration_max", durationMax)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, DurationFilter(min, max))
+	}
+
+	switch labels := params["labels"].(type) {
+	case LabelSelector:
+		filters = append(filters, LabelFilter(labels))
+	case string:
+		if labels != "" {
+			selector, err := ParseLabelSelector(labels)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, LabelFilter(selector))
+		}
+	}
+
+	if _, ok := params["has_result"]; ok {
+		filters = append(filters, HasResultFilter())
+	}
+
+	return filters, nil
+}
+
+// filterLayoutLocal is the local time layout accepted before RFC3339 support
+const filterLayoutLocal = "2006-01-02 15:04:05"
+
+// parseFilterTime accepts RFC3339 timestamps, the legacy local time layout
+// and durations relative to now, e.g. -2h
+func parseFilterTime(v string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(filterLayoutLocal, v, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is neither an RFC3339 time nor a relative duration like -2h", v)
+}
+
+func parseFilterInt(key, v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, v)
+	}
+	return i, nil
+}
+
+func parseFilterDuration(key, v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, v)
+	}
+	return d, nil
+}
+
+// targetHostMatches compares the target host case-insensitively, with or
+// without the port
+func targetHostMatches(t Target, host string) bool {
+	raw := t.URL
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, host) || strings.EqualFold(u.Hostname(), host)
+}
+
+func validStatus(status AttackStatus) bool {
+	switch status {
+	case AttackResponseStatusScheduled, AttackResponseStatusRunning, AttackResponseStatusCanceled,
+		AttackResponseStatusCompleted, AttackResponseStatusFailed:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_createFilterChain(t *testing.T) {
+	now := time.Date(2019, 2, 18, 20, 0, 0, 0, time.UTC)
+	attack := AttackDetails{
+		AttackInfo: AttackInfo{
+			ID:        "494f98a2-7165-4d1b-8834-3226b49ab582",
+			Status:    AttackResponseStatusCompleted,
+			CreatedAt: now.Add(-3 * time.Hour).Format(time.RFC1123),
+			UpdatedAt: now.Add(-time.Hour).Format(time.RFC1123),
+			Params: AttackParams{
+				Rate:     50,
+				Duration: "30s",
+				Labels:   map[string]string{"team": "payments"},
+				Target: []Target{
+					{Method: "GET", URL: "http://api.example.com:8080/v1/users"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		params  FilterParams
+		want    bool
+		wantErr bool
+	}{
+		{"ID prefix", FilterParams{"id_prefix": "494f"}, true, false},
+		{"ID prefix mismatch", FilterParams{"id_prefix": "c6fb"}, false, false},
+		{"Created after relative", FilterParams{"created_after": "-4h"}, true, false},
+		{"Created after RFC3339", FilterParams{"created_after": "2019-02-18T18:00:00Z"}, false, false},
+		{"Updated before relative", FilterParams{"updated_before": "-30m"}, true, false},
+		{"Updated after relative", FilterParams{"updated_after": "-30m"}, false, false},
+		{"URL", FilterParams{"url": "/v1/users"}, true, false},
+		{"Host without port", FilterParams{"host": "API.example.com"}, true, false},
+		{"Host with port", FilterParams{"host": "api.example.com:8080"}, true, false},
+		{"Host mismatch", FilterParams{"host": "example.com"}, false, false},
+		{"Method", FilterParams{"method": "get"}, true, false},
+		{"Rate range", FilterParams{"rate_min": "10", "rate_max": "50"}, true, false},
+		{"Rate range mismatch", FilterParams{"rate_min": "51"}, false, false},
+		{"Duration range", FilterParams{"duration_min": "10s", "duration_max": "1m"}, true, false},
+		{"Duration range mismatch", FilterParams{"duration_max": "10s"}, false, false},
+		{"Labels", FilterParams{"labels": "team=payments"}, true, false},
+		{"Malformed status", FilterParams{"status": "done"}, false, true},
+		{"Malformed time", FilterParams{"created_before": "yesterday"}, false, true},
+		{"Malformed rate", FilterParams{"rate_min": "ten"}, false, true},
+		{"Malformed duration", FilterParams{"duration_min": "-1s"}, false, true},
+		{"Malformed labels", FilterParams{"labels": "=payments"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := createFilterChain(tt.params, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("createFilterChain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got := true
+			for _, filter := range filters {
+				got = got && filter(attack)
+			}
+			if got != tt.want {
+				t.Errorf("filters match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is a single object key or array index of a JSONPath
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// JSONPath is a path into a decoded JSON document. It supports the subset
+// of the JSONPath syntax addressing a single value: `$`, `.key`, `['key']`
+// and `[index]`.
+type JSONPath []jsonPathStep
+
+// ParseJSONPath parses a path such as `$.data.items[0]['access-token']`
+func ParseJSONPath(expr string) (JSONPath, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", expr)
+	}
+
+	path := make(JSONPath, 0)
+	rest := expr[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			i := strings.IndexAny(rest, ".[")
+			if i < 0 {
+				i = len(rest)
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("JSONPath %q has an empty key", expr)
+			}
+			path = append(path, jsonPathStep{key: rest[:i]})
+			rest = rest[i:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an unterminated [", expr)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if n := len(inner); n >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[n-1] == inner[0] {
+				path = append(path, jsonPathStep{key: inner[1 : n-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an invalid index %q", expr, inner)
+			}
+			path = append(path, jsonPathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("JSONPath %q has an unexpected %q", expr, rest[0])
+		}
+	}
+
+	return path, nil
+}
+
+// Lookup returns the value at the path in a document decoded into
+// interface{} values, and whether it exists
+func (p JSONPath) Lookup(doc interface{}) (interface{}, bool) {
+	v := doc
+	for _, step := range p {
+		if step.isIndex {
+			items, ok := v.([]interface{})
+			if !ok || step.index >= len(items) {
+				return nil, false
+			}
+			v = items[step.index]
+			continue
+		}
+
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = fields[step.key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// labelKeyPattern restricts label keys to characters that are safe in
+// selectors and Prometheus label values
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-/]{0,62}$`)
+
+// ValidateLabels checks that all label keys and values can be used in a
+// label selector
+func ValidateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if !labelKeyPattern.MatchString(k) {
+			return fmt.Errorf("invalid label key %q", k)
+		}
+		if strings.ContainsAny(v, ",=!") || strings.TrimSpace(v) != v {
+			return fmt.Errorf("invalid value %q for label %s", v, k)
+		}
+	}
+	return nil
+}
+
+// FormatLabels returns the labels as a selector matching them, sorted by key
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// labelRequirement is a single term of a LabelSelector
+type labelRequirement struct {
+	key    string
+	value  string
+	negate bool
+	exists bool
+}
+
+func (r labelRequirement) matches(labels map[string]string) bool {
+	v, ok := labels[r.key]
+	switch {
+	case r.exists:
+		return ok
+	case r.negate:
+		return !ok || v != r.value
+	}
+	return ok && v == r.value
+}
+
+// LabelSelector matches attacks by their labels. All requirements must match.
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector parses a comma separated list of `key=value`,
+// `key!=value` and `key` (label is set) requirements
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	selector := make(LabelSelector, 0)
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var r labelRequirement
+		if i := strings.Index(term, "!="); i >= 0 {
+			r = labelRequirement{key: term[:i], value: term[i+2:], negate: true}
+		} else if i := strings.Index(term, "="); i >= 0 {
+			r = labelRequirement{key: term[:i], value: term[i+1:]}
+		} else {
+			r = labelRequirement{key: term, exists: true}
+		}
+
+		r.key, r.value = strings.TrimSpace(r.key), strings.TrimSpace(r.value)
+		if !labelKeyPattern.MatchString(r.key) || strings.ContainsAny(r.value, "=!") {
+			return nil, fmt.Errorf("invalid label selector %q", term)
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+// Matches reports whether the labels satisfy all requirements
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// LabelFilter implements an attack label selector filter
+// in the Filter function format
+func LabelFilter(selector LabelSelector) Filter {
+	return func(a AttackDetails) bool {
+		return selector.Matches(a.Params.Labels)
+	}
+}
@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func TestLabelSelector_Matches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "env": "staging"}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"team=payments", true},
+		{"team=payments,env=staging", true},
+		{" team = payments , env=prod", false},
+		{"env!=prod", true},
+		{"env!=staging", false},
+		{"owner!=alice", true},
+		{"team", true},
+		{"owner", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("ParseLabelSelector() error = %v", err)
+			}
+			if got := selector.Matches(labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLabelSelector_Invalid(t *testing.T) {
+	for _, s := range []string{"=payments", "team==payments", "te am=payments", "team=a=b"} {
+		if _, err := ParseLabelSelector(s); err == nil {
+			t.Errorf("ParseLabelSelector(%q) error = nil", s)
+		}
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{"OK", map[string]string{"team": "payments", "app.kubernetes.io/name": "api"}, false},
+		{"Invalid key", map[string]string{"1team": "payments"}, true},
+		{"Invalid value", map[string]string{"team": "a,b"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLabels(tt.labels); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ type AttackBaseInfo struct {
 	ID string `json:"id,omitempty"`
 	// Params captures the attack parameters
 	Params AttackParams `json:"params,omitempty"`
+	// Result references the stored result
+	Result *ResultRef `json:"result,omitempty"`
 }
 
 type Metric struct {
@@ -121,6 +123,94 @@ var Histogram = &Metric{
 	Args: []string{"id"},
 }
 
+var ResultBytes = &Metric{
+	ID:          "resultBytes",
+	Name:        "result_stored_bytes",
+	Description: "Size of the stored, possibly compressed, attack result.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "encoding"},
+}
+
+var ResultCompressionRatio = &Metric{
+	ID:          "resultCompressionRatio",
+	Name:        "result_compression_ratio",
+	Description: "Ratio of the raw to the stored attack result size.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "encoding"},
+}
+
+var AttackLabel = &Metric{
+	ID:          "attackLabel",
+	Name:        "attack_label",
+	Description: "User labels of an attack, one series per label set to 1. Join on id to select attacks by label.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "key", "value"},
+}
+
+var TargetReqCnt = &Metric{
+	ID:          "targetReqCnt",
+	Name:        "target_requests_total",
+	Description: "Number of requests sent to a single target or scenario step of an attack.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target"},
+}
+
+var TargetLatMean = &Metric{
+	ID:          "targetLatMean",
+	Name:        "target_request_latencies_mean",
+	Description: "Average of the latencies of the requests to a single target or scenario step.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target"},
+}
+
+var TargetLat50th = &Metric{
+	ID:          "targetLat50th",
+	Name:        "target_request_latencies_50thpercentile",
+	Description: "50th percentile of the requests to a single target or scenario step.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target"},
+}
+
+var TargetLat95th = &Metric{
+	ID:          "targetLat95th",
+	Name:        "target_request_latencies_95thpercentile",
+	Description: "95th percentile of the requests to a single target or scenario step.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target"},
+}
+
+var TargetLat99th = &Metric{
+	ID:          "targetLat99th",
+	Name:        "target_request_latencies_99thpercentile",
+	Description: "99th percentile of the requests to a single target or scenario step.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target"},
+}
+
+var TargetLatMax = &Metric{
+	ID:          "targetLatMax",
+	Name:        "target_request_latencies_max",
+	Description: "Maximum latency of the requests to a single target or scenario step.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target"},
+}
+
+var TargetSuccessRatio = &Metric{
+	ID:          "targetSuccessRatio",
+	Name:        "target_response_success_ratio",
+	Description: "The percentage of requests to a single target or scenario step whose responses didn't error",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target"},
+}
+
+var TargetStsCode = &Metric{
+	ID:          "targetStsCode",
+	Name:        "target_request_status_code",
+	Description: "Number of responses of a single target or scenario step, partitioned by status code.",
+	Type:        "gauge_vec",
+	Args:        []string{"id", "target", "code"},
+}
+
 var StandardMetrics = []*Metric{
 	ReqCnt,
 	ReqDur,
@@ -135,6 +225,17 @@ var StandardMetrics = []*Metric{
 	ResSuccessRatio,
 	ReqStsCode,
 	Histogram,
+	ResultBytes,
+	ResultCompressionRatio,
+	AttackLabel,
+	TargetReqCnt,
+	TargetLatMean,
+	TargetLat50th,
+	TargetLat95th,
+	TargetLat99th,
+	TargetLatMax,
+	TargetSuccessRatio,
+	TargetStsCode,
 }
 
 // NewMetric associates prometheus.Collector based on Metric.Type
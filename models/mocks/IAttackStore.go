@@ -38,13 +38,36 @@ func (_m *IAttackStore) Delete(_a0 string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: filters
-func (_m *IAttackStore) GetAll(filters models.FilterParams) []models.AttackDetails {
-	ret := _m.Called(filters)
+// GetAll provides a mock function with given fields: filters, opts
+func (_m *IAttackStore) GetAll(filters models.FilterParams, opts models.ListOptions) ([]models.AttackDetails, int) {
+	ret := _m.Called(filters, opts)
 
 	var r0 []models.AttackDetails
-	if rf, ok := ret.Get(0).(func(models.FilterParams) []models.AttackDetails); ok {
-		r0 = rf(filters)
+	if rf, ok := ret.Get(0).(func(models.FilterParams, models.ListOptions) []models.AttackDetails); ok {
+		r0 = rf(filters, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AttackDetails)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(models.FilterParams, models.ListOptions) int); ok {
+		r1 = rf(filters, opts)
+	} else {
+		r1 = ret.Int(1)
+	}
+
+	return r0, r1
+}
+
+// GetBaselines provides a mock function with given fields:
+func (_m *IAttackStore) GetBaselines() []models.AttackDetails {
+	ret := _m.Called()
+
+	var r0 []models.AttackDetails
+	if rf, ok := ret.Get(0).(func() []models.AttackDetails); ok {
+		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.AttackDetails)
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// redisResultPrefix namespaces result keys from attack keys
+	redisResultPrefix = "result:"
+	// redisResultChunk is the size of the list items results are split into
+	redisResultChunk = 1 << 20
+	// redisResultTempTTL expires the temporary keys of results being
+	// written, should the server stop before they complete. It is refreshed
+	// with every chunk.
+	redisResultTempTTL = time.Hour
+)
+
+// RedisResultStore stores encoded attack results in a redis database, as
+// lists of chunks, so they are kept alongside the attacks and shared by all
+// servers using the database
+type RedisResultStore struct {
+	connFn    func() redis.Conn
+	chunkSize int
+}
+
+// NewRedisResultStore constructs a new instance of RedisResultStore
+func NewRedisResultStore(f func() redis.Conn) *RedisResultStore {
+	return &RedisResultStore{f, redisResultChunk}
+}
+
+// Put streams the encoded result into the store chunk by chunk
+func (rs *RedisResultStore) Put(key string, r io.Reader) (ResultRef, error) {
+	conn := rs.connFn()
+	defer conn.Close()
+
+	// Write to a temporary key first so readers never see a partial result
+	tmp := redisResultPrefix + "tmp:" + key + ":" + uuid.NewV4().String()
+	defer conn.Do("DEL", tmp) // nolint: errcheck
+
+	cw := newChecksumWriter()
+	buf := make([]byte, rs.chunkSize)
+	for chunks := 0; ; chunks++ {
+		n, err := io.ReadFull(io.TeeReader(r, cw), buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return ResultRef{}, errors.Wrap(err, "failed to read result")
+		}
+		// An empty result is stored as a single empty chunk
+		if n > 0 || chunks == 0 {
+			if err := conn.Send("RPUSH", tmp, buf[:n]); err != nil {
+				return ResultRef{}, errors.Wrap(err, "failed to write result")
+			}
+			if _, err := conn.Do("PEXPIRE", tmp, int64(redisResultTempTTL/time.Millisecond)); err != nil {
+				return ResultRef{}, errors.Wrap(err, "failed to write result")
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err := conn.Send("MULTI"); err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to move result")
+	}
+	if err := conn.Send("RENAME", tmp, redisResultPrefix+key); err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to move result")
+	}
+	if err := conn.Send("PERSIST", redisResultPrefix+key); err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to move result")
+	}
+	if _, err := conn.Do("EXEC"); err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to move result")
+	}
+
+	return cw.Ref(key), nil
+}
+
+// Get returns a reader fetching the chunks of the encoded result one at a
+// time
+func (rs *RedisResultStore) Get(key string) (io.ReadCloser, error) {
+	conn := rs.connFn()
+
+	n, err := redis.Int(conn.Do("LLEN", redisResultPrefix+key))
+	if err != nil {
+		conn.Close() // nolint: errcheck
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to open result with key %s", key))
+	}
+	if n == 0 {
+		conn.Close() // nolint: errcheck
+		return nil, fmt.Errorf("result with key %s not found", key)
+	}
+
+	return &redisResultReader{conn: conn, key: redisResultPrefix + key, chunks: n}, nil
+}
+
+// Delete removes the encoded result from the store
+func (rs *RedisResultStore) Delete(key string) error {
+	conn := rs.connFn()
+	defer conn.Close()
+
+	n, err := redis.Int(conn.Do("DEL", redisResultPrefix+key))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to delete result with key %s", key))
+	}
+	if n == 0 {
+		return fmt.Errorf("result with key %s not found", key)
+	}
+	return nil
+}
+
+// redisResultReader reads the chunks of a stored result in order
+type redisResultReader struct {
+	conn   redis.Conn
+	key    string
+	chunks int
+	next   int
+	buf    bytes.Reader
+}
+
+// Read implements io.Reader
+func (rr *redisResultReader) Read(p []byte) (int, error) {
+	for rr.buf.Len() == 0 {
+		if rr.next == rr.chunks {
+			return 0, io.EOF
+		}
+		chunk, err := redis.Bytes(rr.conn.Do("LINDEX", rr.key, rr.next))
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to read result chunk")
+		}
+		rr.next++
+		rr.buf.Reset(chunk)
+	}
+	return rr.buf.Read(p)
+}
+
+// Close implements io.Closer
+func (rr *redisResultReader) Close() error {
+	return rr.conn.Close()
+}
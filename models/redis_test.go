@@ -0,0 +1,254 @@
+package models
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+)
+
+// newTestRedis starts an in-memory redis server for the test, returning it
+// along with a function dialing it
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, func() redis.Conn) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	return s, func() redis.Conn {
+		conn, err := redis.Dial("tcp", s.Addr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+}
+
+func TestRedis_TTL(t *testing.T) {
+	s, connFn := newTestRedis(t)
+	db := NewRedis(connFn, RetentionPolicy{MaxAge: time.Hour})
+	results := NewRedisResultStore(connFn)
+
+	ref, err := results.Put("completed", strings.NewReader("result"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, _ := results.Put(SamplesKey("completed"), strings.NewReader("samples"))
+	attack := AttackDetails{
+		AttackInfo: AttackInfo{ID: "completed", Status: AttackResponseStatusCompleted},
+		Result:     &ref,
+		Samples:    &samples,
+	}
+	if err := db.Add(attack); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Add(AttackDetails{AttackInfo: AttackInfo{ID: "running", Status: AttackResponseStatusRunning}})
+
+	// The attack and its files expire together, after the max age and grace
+	want := time.Hour + redisTTLGrace
+	for _, key := range []string{"completed", "result:completed", "result:" + SamplesKey("completed")} {
+		if got := s.TTL(key); got != want {
+			t.Errorf("TTL(%s) = %v, want %v", key, got, want)
+		}
+	}
+	if got := s.TTL("running"); got != 0 {
+		t.Errorf("TTL(running) = %v, want 0", got)
+	}
+
+	// Pinning keeps the attack and its files
+	attack, _ = db.GetByID("completed")
+	attack.Pinned = true
+	if err := db.Update("completed", attack); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"completed", "result:completed", "result:" + SamplesKey("completed")} {
+		if got := s.TTL(key); got != 0 {
+			t.Errorf("TTL(%s) of a pinned attack = %v, want 0", key, got)
+		}
+	}
+}
+
+func TestRedis_Update_Revision(t *testing.T) {
+	_, connFn := newTestRedis(t)
+	db := NewRedis(connFn, RetentionPolicy{})
+	if err := db.Add(AttackDetails{AttackInfo: AttackInfo{ID: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	attack, _ := db.GetByID("1")
+	if attack.Revision != 1 {
+		t.Fatalf("Add() revision = %d, want 1", attack.Revision)
+	}
+	if err := db.Update("1", attack); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, _ := db.GetByID("1"); got.Revision != 2 {
+		t.Errorf("Update() revision = %d, want 2", got.Revision)
+	}
+
+	// The attack read before the update is stale
+	if err := db.Update("1", attack); err != ErrRevisionConflict {
+		t.Errorf("Update() stale error = %v, want %v", err, ErrRevisionConflict)
+	}
+	if err := db.Update("2", AttackDetails{AttackInfo: AttackInfo{ID: "2"}}); err == nil {
+		t.Error("Update() of a missing attack error = nil")
+	}
+}
+
+func TestRedis_GetBaselines(t *testing.T) {
+	s, connFn := newTestRedis(t)
+	db := NewRedis(connFn, RetentionPolicy{})
+	baseline := &Baseline{Labels: map[string]string{"service": "checkout"}}
+	for _, id := range []string{"1", "2", "3"} {
+		if err := db.Add(AttackDetails{AttackInfo: AttackInfo{ID: id, Baseline: baseline}}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	_ = db.Add(AttackDetails{AttackInfo: AttackInfo{ID: "4"}})
+
+	// Marking an attack on update adds it to the index
+	marked, _ := db.GetByID("4")
+	marked.Baseline = baseline
+	if err := db.Update("4", marked); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// Unmarked and deleted attacks leave the index
+	unmarked, _ := db.GetByID("2")
+	unmarked.Baseline = nil
+	if err := db.Update("2", unmarked); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := db.Delete("3"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	members, _ := s.Members(redisBaselinesKey)
+	if strings.Join(members, ",") != "1,4" {
+		t.Errorf("baselines index = %v, want [1 4]", members)
+	}
+	// Attacks gone since reading the index are skipped
+	_, _ = s.SAdd(redisBaselinesKey, "5")
+	got := db.GetBaselines()
+	if len(got) != 2 || got[0].Baseline == nil || got[1].Baseline == nil {
+		t.Errorf("GetBaselines() = %+v, want attacks 1 and 4", got)
+	}
+
+	// The index is not listed as an attack
+	if _, total := db.GetAll(FilterParams{}, ListOptions{}); total != 3 {
+		t.Errorf("GetAll() total = %d, want 3", total)
+	}
+}
+
+// interceptedConn runs before each time a command is sent
+type interceptedConn struct {
+	redis.Conn
+	before func(cmd string)
+}
+
+func (c *interceptedConn) Send(cmd string, args ...interface{}) error {
+	c.before(cmd)
+	return c.Conn.Send(cmd, args...)
+}
+
+// Close keeps the connection open, the way a pool does
+func (c *interceptedConn) Close() error {
+	return nil
+}
+
+func TestRedis_Update_ConcurrentWrite(t *testing.T) {
+	_, connFn := newTestRedis(t)
+	other := NewRedis(connFn, RetentionPolicy{})
+	if err := other.Add(AttackDetails{AttackInfo: AttackInfo{ID: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another writer changes the attack between reading its revision and
+	// the transaction, which redis aborts
+	write := true
+	conn := &interceptedConn{Conn: connFn(), before: func(cmd string) {
+		if cmd == "MULTI" && write {
+			write = false
+			attack, _ := other.GetByID("1")
+			attack.Pinned = true
+			if err := other.Update("1", attack); err != nil {
+				t.Error(err)
+			}
+		}
+	}}
+	db := NewRedis(func() redis.Conn { return conn }, RetentionPolicy{})
+
+	attack, _ := db.GetByID("1")
+	attack.Events = append(attack.Events, AttackEvent{Type: AttackEventCanceled})
+	if err := db.Update("1", attack); err != ErrRevisionConflict {
+		t.Fatalf("Update() error = %v, want %v", err, ErrRevisionConflict)
+	}
+	if got, _ := db.GetByID("1"); !got.Pinned || len(got.Events) != 0 || got.Revision != 2 {
+		t.Errorf("stored attack = %+v, want the concurrent write only", got.AttackInfo)
+	}
+}
+
+func TestRedis_Update_Unwatch(t *testing.T) {
+	_, connFn := newTestRedis(t)
+	other := NewRedis(connFn, RetentionPolicy{})
+	_ = other.Add(AttackDetails{AttackInfo: AttackInfo{ID: "1"}})
+	_ = other.Add(AttackDetails{AttackInfo: AttackInfo{ID: "2"}})
+
+	// A pooled connection is reused after an update gave up early
+	conn := &interceptedConn{Conn: connFn(), before: func(string) {}}
+	db := NewRedis(func() redis.Conn { return conn }, RetentionPolicy{})
+
+	stale, _ := db.GetByID("1")
+	stale.Revision = 0
+	if err := db.Update("1", stale); err != ErrRevisionConflict {
+		t.Fatalf("Update() error = %v, want %v", err, ErrRevisionConflict)
+	}
+
+	// Changes to the attack it watched must not abort later transactions
+	attack, _ := other.GetByID("1")
+	if err := other.Update("1", attack); err != nil {
+		t.Fatal(err)
+	}
+	attack, _ = db.GetByID("2")
+	if err := db.Update("2", attack); err != nil {
+		t.Errorf("Update() on the reused connection error = %v", err)
+	}
+}
+
+func TestRedis_ConcurrentUpdate(t *testing.T) {
+	_, connFn := newTestRedis(t)
+	db := NewRedis(connFn, RetentionPolicy{})
+	if err := db.Add(AttackDetails{AttackInfo: AttackInfo{ID: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each writer appends an event, retrying on conflicts, so no event
+	// may be lost
+	const writers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				attack, _ := db.GetByID("1")
+				attack.Events = append(attack.Events, AttackEvent{Type: AttackEventPinned})
+				if err := db.Update("1", attack); err != ErrRevisionConflict {
+					if err != nil {
+						t.Error(err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := db.GetByID("1")
+	if len(got.Events) != writers || got.Revision != writers+1 {
+		t.Errorf("Update() events = %d, revision = %d, want %d and %d", len(got.Events), got.Revision, writers, writers+1)
+	}
+}
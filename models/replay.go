@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Formats of access logs replayed by attacks
+const (
+	// ReplayFormatCombined is the combined, or common, log format of nginx
+	// and Apache
+	ReplayFormatCombined = "combined"
+	// ReplayFormatJSON is a JSON Lines log of objects
+	ReplayFormatJSON = "json"
+)
+
+// Modes of replaying access logs
+const (
+	// ReplayModeTiming sends the logged requests at their recorded
+	// inter-arrival times, divided by the speed
+	ReplayModeTiming = "timing"
+	// ReplayModePool cycles through the logged requests at the attack rate
+	ReplayModePool = "pool"
+)
+
+// replayLogKeyPrefix prefixes the result store keys of uploaded access logs
+const replayLogKeyPrefix = "replay-"
+
+// ReplayParams replays the requests of a web server access log against a
+// new host, in place of Target. The log is either given inline or uploaded
+// along with the attack.
+type ReplayParams struct {
+	// Format is combined or json, defaults to combined
+	Format string `json:"format,omitempty"`
+	// Mode is timing or pool, defaults to timing
+	Mode string `json:"mode,omitempty"`
+	// Speed multiplies the recorded pace in timing mode, defaults to 1
+	Speed float64 `json:"speed,omitempty"`
+	// BaseURL is the scheme and host the logged paths are sent to
+	BaseURL string `json:"base-url"`
+	// Headers are sent with every request
+	Headers []AttackHeader `json:"headers,omitempty"`
+	// Fields names the fields of json logs, if not one of the usual names
+	Fields *ReplayFields `json:"fields,omitempty"`
+	// Data holds the log inline
+	Data string `json:"data,omitempty"`
+	// Key of the uploaded log in the result store, set by the server
+	Key string `json:"key,omitempty"`
+	// Size of the uploaded log in bytes, set by the server
+	Size int64 `json:"size,omitempty"`
+}
+
+// ReplayFields names the fields of the requests of json logs
+type ReplayFields struct {
+	Time   string `json:"time,omitempty"`
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Request holds the request line, e.g. "GET /items HTTP/1.1", in logs
+	// without separate method and path fields
+	Request string `json:"request,omitempty"`
+}
+
+// Validate checks the format, mode, speed and base URL of the replay, and
+// that its log is given inline, the key being reserved for uploads
+func (p ReplayParams) Validate() error {
+	switch p.Format {
+	case "", ReplayFormatCombined, ReplayFormatJSON:
+	default:
+		return fmt.Errorf("unsupported replay format %q", p.Format)
+	}
+	switch p.Mode {
+	case "", ReplayModeTiming, ReplayModePool:
+	default:
+		return fmt.Errorf("unsupported replay mode %q", p.Mode)
+	}
+	if p.Speed < 0 {
+		return fmt.Errorf("replay speed must not be negative")
+	}
+	u, err := url.Parse(p.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("replay base-url %q is not an absolute http or https URL", p.BaseURL)
+	}
+	if p.Key != "" || p.Size != 0 {
+		return fmt.Errorf("replay log key and size are set by the server")
+	}
+	return nil
+}
+
+// FormatOrDefault returns the format of the log, combined if not set
+func (p ReplayParams) FormatOrDefault() string {
+	if p.Format == "" {
+		return ReplayFormatCombined
+	}
+	return p.Format
+}
+
+// ModeOrDefault returns the mode of the replay, timing if not set
+func (p ReplayParams) ModeOrDefault() string {
+	if p.Mode == "" {
+		return ReplayModeTiming
+	}
+	return p.Mode
+}
+
+// SpeedOrDefault returns the speed of the replay, 1 if not set
+func (p ReplayParams) SpeedOrDefault() float64 {
+	if p.Speed == 0 {
+		return 1
+	}
+	return p.Speed
+}
+
+// ReplayLogKey returns the result store key an uploaded access log is kept
+// under
+func ReplayLogKey(id string) string {
+	return replayLogKeyPrefix + id
+}
+
+// IsReplayLogKey reports whether key refers to an uploaded access log
+func IsReplayLogKey(key string) bool {
+	return strings.HasPrefix(key, replayLogKeyPrefix)
+}
@@ -4,7 +4,32 @@ import "time"
 
 // JSONReportResponse provides the model for a report response object
 type JSONReportResponse struct {
-	ID        string `json:"id"`
+	ID          string            `json:"id"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	JSONMetrics
+	// Steps breaks the metrics of scenario attacks down by step
+	Steps []StepReportResponse `json:"steps,omitempty"`
+	// Targets breaks the metrics of attacks on several targets down by
+	// target, along with their share of the requests
+	Targets []TargetReportResponse `json:"targets,omitempty"`
+}
+
+// StepReportResponse captures the metrics of a single scenario step
+type StepReportResponse struct {
+	Name string `json:"name"`
+	JSONMetrics
+}
+
+// TargetReportResponse captures the metrics of a single target
+type TargetReportResponse struct {
+	Name  string  `json:"name"`
+	Share float64 `json:"share"`
+	JSONMetrics
+}
+
+// JSONMetrics provides the model for the metrics of a JSON report
+type JSONMetrics struct {
 	Latencies struct {
 		Total int `json:"total"`
 		Mean  int `json:"mean"`
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxSlowestResults bounds the slowest results of a query, which are held in
+// memory while the results are read
+const maxSlowestResults = 10000
+
+// ResultQuery selects individual results of an attack. Results match if they
+// pass all of the filters that are set.
+type ResultQuery struct {
+	// Codes lists the status codes of the results, 0 for failed requests
+	Codes []uint16
+	// Error is a substring of the errors of the results
+	Error string
+	// Name is the name of the results, i.e. their target or scenario step
+	Name string
+	// MinLatency is the least latency of the results
+	MinLatency time.Duration
+	// From and To bound the timestamps of the results, if not zero
+	From time.Time
+	To   time.Time
+	// Slowest selects the given number of slowest matches, slowest first,
+	// in place of all of them in the order of the attack
+	Slowest int
+	// Offset is the number of matches to skip
+	Offset int
+	// Limit the number of matches returned, zero returns all
+	Limit int
+}
+
+// Validate checks the bounds of the query
+func (q ResultQuery) Validate() error {
+	if q.MinLatency < 0 {
+		return fmt.Errorf("min-latency must not be negative")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return fmt.Errorf("to must not be before from")
+	}
+	if q.Slowest < 0 || q.Slowest > maxSlowestResults {
+		return fmt.Errorf("slowest must be between 0 and %d", maxSlowestResults)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResultQuery_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		query   ResultQuery
+		wantErr bool
+	}{
+		{"Empty", ResultQuery{}, false},
+		{"Filters", ResultQuery{Codes: []uint16{500}, Error: "timeout", MinLatency: time.Second, From: now, To: now.Add(time.Minute)}, false},
+		{"Negative min latency", ResultQuery{MinLatency: -time.Second}, true},
+		{"To before from", ResultQuery{From: now, To: now.Add(-time.Minute)}, true},
+		{"Too many slowest", ResultQuery{Slowest: maxSlowestResults + 1}, true},
+		{"Negative offset", ResultQuery{Offset: -1}, true},
+		{"Negative limit", ResultQuery{Limit: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ResultQuery.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
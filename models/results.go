@@ -0,0 +1,209 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ResultRef references an encoded attack result held in an IResultStore
+type ResultRef struct {
+	// Key identifies the result blob in the result store
+	Key string `json:"key"`
+	// Size of the encoded result in bytes
+	Size int64 `json:"size"`
+	// Checksum is the hex encoded SHA-256 digest of the encoded result
+	Checksum string `json:"checksum"`
+	// Encoding is the compression applied to the stored result, empty if none
+	Encoding string `json:"encoding,omitempty"`
+	// RawSize of the encoded result before compression in bytes
+	RawSize int64 `json:"raw_size,omitempty"`
+}
+
+// CompressionRatio returns the ratio of the raw to the stored result size
+func (r ResultRef) CompressionRatio() float64 {
+	if r.Size == 0 || r.RawSize == 0 {
+		return 1
+	}
+	return float64(r.RawSize) / float64(r.Size)
+}
+
+// IResultStore captures all methods related to storing and retrieving
+// encoded attack results, kept apart from the attack metadata in IAttackStore
+type IResultStore interface {
+	// Put streams an encoded result into the store under the given key
+	Put(string, io.Reader) (ResultRef, error)
+	// Get opens the encoded result stored under the given key
+	Get(string) (io.ReadCloser, error)
+	// Delete the encoded result stored under the given key
+	Delete(string) error
+}
+
+// checksumWriter computes the size and SHA-256 digest of everything written to it
+type checksumWriter struct {
+	h hash.Hash
+	n int64
+}
+
+func newChecksumWriter() *checksumWriter {
+	return &checksumWriter{h: sha256.New()}
+}
+
+// Write implements io.Writer
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.h.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Ref returns a ResultRef for the data written so far
+func (c *checksumWriter) Ref(key string) ResultRef {
+	return ResultRef{
+		Key:      key,
+		Size:     c.n,
+		Checksum: hex.EncodeToString(c.h.Sum(nil)),
+	}
+}
+
+// ResultMap stores encoded attack results in memory. Each result is
+// buffered whole, so memory use grows with the length of the attacks; it
+// suits tests and short attacks, the other stores are meant for long ones.
+type ResultMap struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewResultMap constructs a new instance of ResultMap
+func NewResultMap() *ResultMap {
+	return &ResultMap{
+		blobs: make(map[string][]byte),
+	}
+}
+
+// Put reads the whole encoded result into memory
+func (rm *ResultMap) Put(key string, r io.Reader) (ResultRef, error) {
+	cw := newChecksumWriter()
+	buf, err := ioutil.ReadAll(io.TeeReader(r, cw))
+	if err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to read result")
+	}
+
+	rm.mu.Lock()
+	rm.blobs[key] = buf
+	rm.mu.Unlock()
+
+	return cw.Ref(key), nil
+}
+
+// Get returns a reader over the encoded result
+func (rm *ResultMap) Get(key string) (io.ReadCloser, error) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	buf, ok := rm.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("result with key %s not found", key)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(buf)), nil
+}
+
+// Delete removes an encoded result from memory
+func (rm *ResultMap) Delete(key string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if _, ok := rm.blobs[key]; !ok {
+		return fmt.Errorf("result with key %s not found", key)
+	}
+	delete(rm.blobs, key)
+
+	return nil
+}
+
+// FileStore stores encoded attack results as files in a local directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore constructs a new instance of FileStore, creating the
+// directory if it does not exist yet
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to create result directory %s", dir))
+	}
+	return &FileStore{dir}, nil
+}
+
+// Put writes the encoded result to a file named after the key
+func (fs *FileStore) Put(key string, r io.Reader) (ResultRef, error) {
+	path, err := fs.path(key)
+	if err != nil {
+		return ResultRef{}, err
+	}
+
+	// Write to a temporary file first so readers never see a partial result
+	f, err := ioutil.TempFile(fs.dir, ".tmp-"+key+"-")
+	if err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to create result file")
+	}
+	defer os.Remove(f.Name()) // nolint: errcheck
+
+	cw := newChecksumWriter()
+	if _, err := io.Copy(io.MultiWriter(f, cw), r); err != nil {
+		f.Close() // nolint: errcheck
+		return ResultRef{}, errors.Wrap(err, "failed to write result file")
+	}
+	if err := f.Close(); err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to write result file")
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return ResultRef{}, errors.Wrap(err, "failed to move result file")
+	}
+
+	return cw.Ref(key), nil
+}
+
+// Get opens the result file for the key
+func (fs *FileStore) Get(key string) (io.ReadCloser, error) {
+	path, err := fs.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path) // nolint: gosec
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to open result with key %s", key))
+	}
+	return f, nil
+}
+
+// Delete removes the result file for the key
+func (fs *FileStore) Delete(key string) error {
+	path, err := fs.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to delete result with key %s", key))
+	}
+	return nil
+}
+
+func (fs *FileStore) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || key[0] == '.' {
+		return "", fmt.Errorf("invalid result key %q", key)
+	}
+	return filepath.Join(fs.dir, key), nil
+}
@@ -0,0 +1,355 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// s3StandIn is a minimal in-memory stand-in for an S3 compatible server
+type s3StandIn struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[string][]byte
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), s3Algorithm+" Credential=key/") {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+		http.Error(w, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	switch {
+	case r.Method == http.MethodPost && query["uploads"] != nil:
+		s.uploads[r.URL.Path] = make(map[string][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", r.URL.Path) // nolint: lll
+	case r.Method == http.MethodPut && uploadID != "":
+		etag := fmt.Sprintf("%q", sha256Hex(body))
+		s.uploads[uploadID][etag] = body
+		w.Header().Set("ETag", etag)
+	case r.Method == http.MethodPost && uploadID != "":
+		var complete struct {
+			Parts []s3CompletePart `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			http.Error(w, "MalformedXML", http.StatusBadRequest)
+			return
+		}
+		var object []byte
+		for _, part := range complete.Parts {
+			object = append(object, s.uploads[uploadID][part.ETag]...)
+		}
+		s.objects[r.URL.Path] = object
+		delete(s.uploads, uploadID)
+	case r.Method == http.MethodDelete && uploadID != "":
+		delete(s.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		s.objects[r.URL.Path] = body
+	case r.Method == http.MethodGet:
+		body, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(body) // nolint: errcheck
+	case r.Method == http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// errReader fails every read, like a result stream from a canceled attack
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, fmt.Errorf("attack canceled")
+}
+
+func newTestS3Store(t *testing.T) (*S3Store, *s3StandIn) {
+	standIn := &s3StandIn{
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[string][]byte),
+	}
+	srv := httptest.NewServer(standIn)
+	t.Cleanup(srv.Close)
+
+	s, err := NewS3Store(S3Config{
+		Endpoint:  srv.URL,
+		Bucket:    "results",
+		Prefix:    "vegeta/",
+		AccessKey: "key",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, standIn
+}
+
+func newTestFileStore(t *testing.T) *FileStore {
+	dir, err := ioutil.TempDir("", "vegeta-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) }) // nolint: errcheck
+
+	fs, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func TestResultStores(t *testing.T) {
+	fs := newTestFileStore(t)
+	s3, _ := newTestS3Store(t)
+	_, connFn := newTestRedis(t)
+
+	tests := []struct {
+		name  string
+		store IResultStore
+	}{
+		{"ResultMap", NewResultMap()},
+		{"FileStore", fs},
+		{"S3Store", s3},
+		{"RedisResultStore", NewRedisResultStore(connFn)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []byte("encoded result")
+
+			ref, err := tt.store.Put("123", bytes.NewReader(want))
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if ref.Key != "123" || ref.Size != int64(len(want)) || ref.Checksum != sha256Hex(want) {
+				t.Errorf("Put() = %+v", ref)
+			}
+
+			rc, err := tt.store.Get("123")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			got, _ := ioutil.ReadAll(rc)
+			rc.Close() // nolint: errcheck
+			if !bytes.Equal(got, want) {
+				t.Errorf("Get() = %s, want %s", got, want)
+			}
+
+			if err := tt.store.Delete("123"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := tt.store.Get("123"); err == nil {
+				t.Errorf("Get() after Delete() error = nil")
+			}
+		})
+	}
+}
+
+func TestFileStore_InvalidKey(t *testing.T) {
+	fs := newTestFileStore(t)
+
+	for _, key := range []string{"", "../123", "a/b", ".hidden"} {
+		if _, err := fs.Put(key, strings.NewReader("x")); err == nil {
+			t.Errorf("Put(%q) error = nil", key)
+		}
+	}
+}
+
+func TestS3Store_ObjectPath(t *testing.T) {
+	s, standIn := newTestS3Store(t)
+
+	if _, err := s.Put("abc-123", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := standIn.objects["/results/vegeta/abc-123"]; !ok {
+		t.Errorf("object not stored path-style, got %v", standIn.objects)
+	}
+}
+
+func TestS3Store_MultipartUpload(t *testing.T) {
+	s, standIn := newTestS3Store(t)
+	s.partSize = 4
+
+	want := []byte("a result spanning several parts")
+	ref, err := s.Put("123", bytes.NewReader(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Size != int64(len(want)) || ref.Checksum != sha256Hex(want) {
+		t.Errorf("Put() = %+v", ref)
+	}
+	if got := standIn.objects["/results/vegeta/123"]; !bytes.Equal(got, want) {
+		t.Errorf("stored object = %s, want %s", got, want)
+	}
+	if len(standIn.uploads) != 0 {
+		t.Errorf("multipart uploads left behind: %v", standIn.uploads)
+	}
+}
+
+func TestS3Store_MultipartUploadConcurrent(t *testing.T) {
+	s, standIn := newTestS3Store(t)
+	s.partSize = 4
+
+	// Hold back the part uploads until all of them are in flight, so the
+	// result is only stored if it is read while parts are being uploaded
+	const parts = 3
+	var arrived sync.WaitGroup
+	arrived.Add(parts)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("partNumber") != "" {
+			arrived.Done()
+			done := make(chan struct{})
+			go func() { arrived.Wait(); close(done) }()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				http.Error(w, "SlowDown", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		standIn.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	s.cfg.Endpoint = srv.URL
+
+	want := []byte("three parts!")
+	if _, err := s.Put("123", bytes.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+	if got := standIn.objects["/results/vegeta/123"]; !bytes.Equal(got, want) {
+		t.Errorf("stored object = %s, want %s", got, want)
+	}
+}
+
+func TestS3Store_MultipartUploadAborted(t *testing.T) {
+	s, standIn := newTestS3Store(t)
+	s.partSize = 4
+
+	r := io.MultiReader(strings.NewReader("partial result"), errReader{})
+	if _, err := s.Put("123", r); err == nil {
+		t.Fatal("Put() error = nil")
+	}
+	if len(standIn.objects) != 0 || len(standIn.uploads) != 0 {
+		t.Errorf("partial result left behind: %v %v", standIn.objects, standIn.uploads)
+	}
+}
+
+func TestFileStore_PutAborted(t *testing.T) {
+	fs := newTestFileStore(t)
+
+	r := io.MultiReader(strings.NewReader("partial result"), errReader{})
+	if _, err := fs.Put("123", r); err == nil {
+		t.Fatal("Put() error = nil")
+	}
+
+	files, _ := ioutil.ReadDir(fs.dir)
+	if len(files) != 0 {
+		t.Errorf("partial result left behind: %v", files)
+	}
+}
+
+func TestRedisResultStore_Chunks(t *testing.T) {
+	s, connFn := newTestRedis(t)
+	rs := NewRedisResultStore(connFn)
+	rs.chunkSize = 4
+
+	tests := []struct {
+		name   string
+		result string
+		chunks int
+	}{
+		{"Empty", "", 1},
+		{"Single chunk", "abc", 1},
+		{"Exact chunks", "abcdefgh", 2},
+		{"Multiple chunks", "encoded result", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := rs.Put("123", strings.NewReader(tt.result))
+			if err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if ref.Size != int64(len(tt.result)) || ref.Checksum != sha256Hex([]byte(tt.result)) {
+				t.Errorf("Put() = %+v", ref)
+			}
+			if chunks, _ := s.List("result:123"); len(chunks) != tt.chunks {
+				t.Errorf("Put() stored %d chunks, want %d", len(chunks), tt.chunks)
+			}
+			if got := s.TTL("result:123"); got != 0 {
+				t.Errorf("Put() left a TTL of %v", got)
+			}
+
+			rc, err := rs.Get("123")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			got, err := ioutil.ReadAll(rc)
+			rc.Close() // nolint: errcheck
+			if err != nil || string(got) != tt.result {
+				t.Errorf("Get() = %q, %v, want %q", got, err, tt.result)
+			}
+		})
+	}
+
+	// Only the result is kept, the temporary keys are gone
+	if keys := s.Keys(); len(keys) != 1 || keys[0] != "result:123" {
+		t.Errorf("stored keys = %v, want [result:123]", keys)
+	}
+}
+
+func TestRedisResultStore_PutAborted(t *testing.T) {
+	s, connFn := newTestRedis(t)
+	rs := NewRedisResultStore(connFn)
+	rs.chunkSize = 4
+
+	r := io.MultiReader(strings.NewReader("partial result"), errReader{})
+	if _, err := rs.Put("123", r); err == nil {
+		t.Fatal("Put() error = nil")
+	}
+	if keys := s.Keys(); len(keys) != 0 {
+		t.Errorf("partial result left behind: %v", keys)
+	}
+	if _, err := rs.Get("123"); err == nil {
+		t.Error("Get() of an aborted result error = nil")
+	}
+}
+
+func TestRedisResultStore_Delete(t *testing.T) {
+	s, connFn := newTestRedis(t)
+	rs := NewRedisResultStore(connFn)
+
+	if err := rs.Delete("123"); err == nil {
+		t.Error("Delete() of a missing result error = nil")
+	}
+	if _, err := rs.Put("123", strings.NewReader("result")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Delete("123"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if s.Exists("result:123") {
+		t.Error("Delete() kept the result")
+	}
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// RetentionPolicy captures the rules after which stored attacks expire.
+// Zero values disable the respective rule. Pinned attacks, baselines and attacks in
+// a status not listed in Statuses never expire, but still count towards
+// the count and result size limits.
+type RetentionPolicy struct {
+	// MaxAge since the last update after which an attack expires
+	MaxAge time.Duration
+	// StatusMaxAge overrides MaxAge for individual statuses
+	StatusMaxAge map[AttackStatus]time.Duration
+	// MaxCount of attacks to keep, the oldest expire first
+	MaxCount int
+	// MaxResultBytes of stored results, samples and uploads to keep, the
+	// oldest expire first
+	MaxResultBytes int64
+	// Statuses eligible for expiry
+	Statuses []AttackStatus
+}
+
+// DefaultRetentionStatuses are the statuses eligible for expiry if the
+// policy does not list any
+var DefaultRetentionStatuses = []AttackStatus{
+	AttackResponseStatusCompleted,
+	AttackResponseStatusCanceled,
+	AttackResponseStatusFailed,
+}
+
+// Enabled reports whether any retention rule is configured
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || len(p.StatusMaxAge) > 0 || p.MaxCount > 0 || p.MaxResultBytes > 0
+}
+
+// TTL returns the max age for attacks in the given status, zero if attacks
+// in that status do not expire by age
+func (p RetentionPolicy) TTL(status AttackStatus) time.Duration {
+	if !p.eligible(status) {
+		return 0
+	}
+	if ttl, ok := p.StatusMaxAge[status]; ok {
+		return ttl
+	}
+	return p.MaxAge
+}
+
+// Expired returns the attacks that have to be removed to satisfy the policy
+func (p RetentionPolicy) Expired(attacks []AttackDetails, now time.Time) []AttackDetails {
+	expired := make([]AttackDetails, 0)
+	if !p.Enabled() {
+		return expired
+	}
+
+	// Oldest first, so the count and size limits remove the oldest attacks
+	sorted := make([]AttackDetails, len(attacks))
+	copy(sorted, attacks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return attackTime(sorted[i].UpdatedAt).Before(attackTime(sorted[j].UpdatedAt))
+	})
+
+	var storedBytes int64
+	for _, attack := range sorted {
+		storedBytes += attack.StoredBytes()
+	}
+	count := len(sorted)
+
+	for _, attack := range sorted {
+		if attack.Pinned || attack.Baseline != nil || !p.eligible(attack.Status) {
+			continue
+		}
+
+		ttl := p.TTL(attack.Status)
+		tooOld := ttl > 0 && now.Sub(attackTime(attack.UpdatedAt)) > ttl
+		tooMany := p.MaxCount > 0 && count > p.MaxCount
+		tooLarge := p.MaxResultBytes > 0 && storedBytes > p.MaxResultBytes && attack.StoredBytes() > 0
+		if !tooOld && !tooMany && !tooLarge {
+			continue
+		}
+
+		expired = append(expired, attack)
+		count--
+		storedBytes -= attack.StoredBytes()
+	}
+
+	return expired
+}
+
+func (p RetentionPolicy) eligible(status AttackStatus) bool {
+	statuses := p.Statuses
+	if len(statuses) == 0 {
+		statuses = DefaultRetentionStatuses
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// attackTime parses an attack timestamp, returning the zero time if it is malformed
+func attackTime(t string) time.Time {
+	parsed, _ := time.Parse(time.RFC1123, t)
+	return parsed
+}
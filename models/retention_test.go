@@ -0,0 +1,145 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func retentionAttack(id string, status AttackStatus, age time.Duration, size int64, pinned bool) AttackDetails {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	a := AttackDetails{
+		AttackInfo: AttackInfo{
+			ID:        id,
+			Status:    status,
+			UpdatedAt: now.Add(-age).Format(time.RFC1123),
+			Pinned:    pinned,
+		},
+	}
+	if size > 0 {
+		a.Result = &ResultRef{Key: id, Size: size}
+	}
+	return a
+}
+
+func expiredIDs(attacks []AttackDetails) []string {
+	ids := make([]string, 0)
+	for _, a := range attacks {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func TestRetentionPolicy_Expired(t *testing.T) {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	attacks := []AttackDetails{
+		retentionAttack("old", AttackResponseStatusCompleted, 72*time.Hour, 100, false),
+		retentionAttack("old-pinned", AttackResponseStatusCompleted, 96*time.Hour, 100, true),
+		retentionAttack("old-running", AttackResponseStatusRunning, 96*time.Hour, 0, false),
+		retentionAttack("failed", AttackResponseStatusFailed, 2*time.Hour, 0, false),
+		retentionAttack("recent", AttackResponseStatusCompleted, time.Hour, 100, false),
+		retentionAttack("new", AttackResponseStatusCompleted, time.Minute, 100, false),
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{
+			name:   "Disabled",
+			policy: RetentionPolicy{},
+			want:   []string{},
+		},
+		{
+			name:   "MaxAge",
+			policy: RetentionPolicy{MaxAge: 48 * time.Hour},
+			want:   []string{"old"},
+		},
+		{
+			name: "StatusMaxAge",
+			policy: RetentionPolicy{
+				MaxAge:       48 * time.Hour,
+				StatusMaxAge: map[AttackStatus]time.Duration{AttackResponseStatusFailed: time.Hour},
+			},
+			want: []string{"old", "failed"},
+		},
+		{
+			name:   "MaxCount",
+			policy: RetentionPolicy{MaxCount: 4},
+			want:   []string{"old", "failed"},
+		},
+		{
+			name:   "MaxResultBytes",
+			policy: RetentionPolicy{MaxResultBytes: 250},
+			want:   []string{"old", "recent"},
+		},
+		{
+			name:   "Statuses",
+			policy: RetentionPolicy{MaxAge: time.Minute, Statuses: []AttackStatus{AttackResponseStatusFailed}},
+			want:   []string{"failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiredIDs(tt.policy.Expired(attacks, now)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RetentionPolicy.Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionPolicy_TTL(t *testing.T) {
+	policy := RetentionPolicy{
+		MaxAge:       time.Hour,
+		StatusMaxAge: map[AttackStatus]time.Duration{AttackResponseStatusFailed: time.Minute},
+	}
+
+	if got := policy.TTL(AttackResponseStatusCompleted); got != time.Hour {
+		t.Errorf("TTL(completed) = %v, want %v", got, time.Hour)
+	}
+	if got := policy.TTL(AttackResponseStatusFailed); got != time.Minute {
+		t.Errorf("TTL(failed) = %v, want %v", got, time.Minute)
+	}
+	if got := policy.TTL(AttackResponseStatusRunning); got != 0 {
+		t.Errorf("TTL(running) = %v, want 0", got)
+	}
+}
+
+func TestRetentionPolicy_Expired_Baseline(t *testing.T) {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	baseline := retentionAttack("old-baseline", AttackResponseStatusCompleted, 96*time.Hour, 100, false)
+	baseline.Baseline = &Baseline{Labels: map[string]string{"service": "checkout"}}
+	attacks := []AttackDetails{
+		baseline,
+		retentionAttack("old", AttackResponseStatusCompleted, 72*time.Hour, 100, false),
+	}
+
+	got := expiredIDs(RetentionPolicy{MaxAge: 48 * time.Hour}.Expired(attacks, now))
+	if !reflect.DeepEqual(got, []string{"old"}) {
+		t.Errorf("Expired() = %v, want [old]", got)
+	}
+}
+
+func TestRetentionPolicy_Expired_StoredBytes(t *testing.T) {
+	now := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	// Samples and uploads count towards the size limit along with results
+	sampled := retentionAttack("sampled", AttackResponseStatusCompleted, 3*time.Hour, 100, false)
+	sampled.Samples = &ResultRef{Key: SamplesKey("sampled"), Size: 100}
+	uploaded := retentionAttack("uploaded", AttackResponseStatusFailed, 2*time.Hour, 0, false)
+	uploaded.Params.TargetFile = &TargetFile{Key: TargetFileKey("1"), Size: 100}
+	replayed := retentionAttack("replayed", AttackResponseStatusCompleted, time.Hour, 0, false)
+	replayed.Params.Replay = &ReplayParams{Key: ReplayLogKey("1"), Size: 100}
+	attacks := []AttackDetails{
+		sampled,
+		uploaded,
+		replayed,
+		retentionAttack("new", AttackResponseStatusCompleted, time.Minute, 100, false),
+	}
+
+	got := expiredIDs(RetentionPolicy{MaxResultBytes: 250}.Expired(attacks, now))
+	if !reflect.DeepEqual(got, []string{"sampled", "uploaded"}) {
+		t.Errorf("Expired() = %v, want [sampled uploaded]", got)
+	}
+}
@@ -0,0 +1,381 @@
+package models
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3Service       = "s3"
+	s3DateLayout    = "20060102"
+	s3AmzLayout     = "20060102T150405Z"
+	s3DefaultRegion = "us-east-1"
+
+	// s3PartSize is the minimum part size accepted for multipart uploads
+	s3PartSize = 5 * 1024 * 1024
+	// s3PartUploads is the number of parts of a multipart upload uploaded
+	// at once, while the next part is read
+	s3PartUploads = 4
+)
+
+// S3Config captures the connection settings for an S3 compatible object store
+type S3Config struct {
+	// Endpoint is the base URL of the object store, e.g. http://localhost:9000
+	Endpoint string
+	// Region used for request signing, defaults to us-east-1
+	Region string
+	// Bucket that holds the results
+	Bucket string
+	// Prefix is prepended to every object key
+	Prefix string
+
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store stores encoded attack results in an S3 compatible object store,
+// addressing objects path-style so that MinIO and similar servers work
+// without DNS setup.
+type S3Store struct {
+	cfg         S3Config
+	client      *http.Client
+	now         func() time.Time
+	partSize    int
+	partUploads int
+}
+
+// NewS3Store constructs a new instance of S3Store
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = s3DefaultRegion
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+
+	return &S3Store{
+		cfg:         cfg,
+		client:      http.DefaultClient,
+		now:         time.Now,
+		partSize:    s3PartSize,
+		partUploads: s3PartUploads,
+	}, nil
+}
+
+// Put uploads the encoded result as an object named after the key. The result
+// is read in parts of partSize bytes, so results larger than a single part
+// are streamed using a multipart upload with bounded memory use. Parts are
+// uploaded in the background, so the writer of the result only waits for
+// the store once partUploads parts are in flight.
+func (s *S3Store) Put(key string, r io.Reader) (ResultRef, error) {
+	cw := newChecksumWriter()
+	r = io.TeeReader(r, cw)
+
+	part := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, part)
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		// Small enough for a single request
+		resp, err := s.do(http.MethodPut, key, nil, part[:n])
+		if err != nil {
+			return ResultRef{}, errors.Wrap(err, fmt.Sprintf("failed to put result with key %s", key))
+		}
+		resp.Body.Close() // nolint: errcheck
+		return cw.Ref(key), nil
+	case nil:
+	default:
+		return ResultRef{}, errors.Wrap(err, "failed to read result")
+	}
+
+	uploadID, err := s.createMultipartUpload(key)
+	if err != nil {
+		return ResultRef{}, errors.Wrap(err, fmt.Sprintf("failed to put result with key %s", key))
+	}
+
+	if err := s.uploadParts(key, uploadID, r, part); err != nil {
+		s.abortMultipartUpload(key, uploadID)
+		return ResultRef{}, errors.Wrap(err, fmt.Sprintf("failed to put result with key %s", key))
+	}
+
+	return cw.Ref(key), nil
+}
+
+// Get downloads the object for the key
+func (s *S3Store) Get(key string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to get result with key %s", key))
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object for the key
+func (s *S3Store) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to delete result with key %s", key))
+	}
+	resp.Body.Close() // nolint: errcheck
+	return nil
+}
+
+// s3CompletePart is a part entry in the CompleteMultipartUpload request body
+type s3CompletePart struct {
+	PartNumber int
+	ETag       string
+}
+
+// createMultipartUpload initiates a multipart upload and returns its upload ID
+func (s *S3Store) createMultipartUpload(key string) (string, error) {
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", errors.Wrap(err, "failed to decode multipart upload")
+	}
+	return result.UploadID, nil
+}
+
+// uploadParts uploads the already read first part and the rest of the reader
+// in parts, up to partUploads at once, then completes the multipart upload
+func (s *S3Store) uploadParts(key, uploadID string, r io.Reader, part []byte) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		parts    = make([]s3CompletePart, 0)
+		failed   error
+		buffers  = make(chan []byte, s.partUploads)
+		buffered = 1
+	)
+	uploaded := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return failed
+	}
+
+	n := len(part)
+	for num := 1; n > 0 && uploaded() == nil; num++ {
+		wg.Add(1)
+		go func(num int, part []byte) {
+			defer wg.Done()
+			resp, err := s.do(http.MethodPut, key, url.Values{
+				"partNumber": {strconv.Itoa(num)},
+				"uploadId":   {uploadID},
+			}, part)
+
+			mu.Lock()
+			if err != nil && failed == nil {
+				failed = err
+			}
+			if err == nil {
+				resp.Body.Close() // nolint: errcheck
+				parts = append(parts, s3CompletePart{num, resp.Header.Get("ETag")})
+			}
+			mu.Unlock()
+			buffers <- part[:cap(part)]
+		}(num, part[:n])
+
+		// Read the next part into a free buffer, allocating up to one per
+		// part in flight
+		if buffered < s.partUploads {
+			part, buffered = make([]byte, s.partSize), buffered+1
+		} else {
+			part = <-buffers
+		}
+
+		var err error
+		n, err = io.ReadFull(r, part)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			wg.Wait()
+			return errors.Wrap(err, "failed to read result")
+		}
+	}
+	wg.Wait()
+	if failed != nil {
+		return failed
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name         `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletePart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close() // nolint: errcheck
+	return nil
+}
+
+// abortMultipartUpload discards the parts uploaded so far
+func (s *S3Store) abortMultipartUpload(key, uploadID string) {
+	resp, err := s.do(http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil)
+	if err != nil {
+		return
+	}
+	resp.Body.Close() // nolint: errcheck
+}
+
+// do sends a signed request for the object key and returns the response
+// if its status code is 2xx
+func (s *S3Store) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s3Escape(s.cfg.Bucket) + "/" + s3Escape(s.cfg.Prefix+key)
+	u := s.cfg.Endpoint + path
+	if len(query) > 0 {
+		u += "?" + s3CanonicalQuery(query)
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, path, query, body)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close() // nolint: errcheck
+		return nil, fmt.Errorf("s3 responded with %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (s *S3Store) sign(req *http.Request, path string, query url.Values, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format(s3AmzLayout)
+	scope := strings.Join([]string{now.Format(s3DateLayout), s.cfg.Region, s3Service, "aws4_request"}, "/")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		s3CanonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format(s3DateLayout))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.cfg.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// s3CanonicalQuery encodes the query parameters sorted by key, as required
+// for both the request URL and the canonical request
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3EscapeQuery(k)+"="+s3EscapeQuery(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but the unreserved characters and
+// the path separator
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// s3EscapeQuery percent-encodes query components, where the path separator
+// must be encoded as well
+func s3EscapeQuery(s string) string {
+	return strings.Replace(s3Escape(s), "/", "%2F", -1)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data)) // nolint: errcheck
+	return h.Sum(nil)
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Filters of the requests captured as samples
+const (
+	// SampleFilterAll samples any request
+	SampleFilterAll = "all"
+	// SampleFilterErrors samples failed requests, including responses with
+	// a 4xx or 5xx status or failing an assertion
+	SampleFilterErrors = "errors"
+	// SampleFilterNon2xx samples requests without a 2xx response
+	SampleFilterNon2xx = "non-2xx"
+)
+
+// Defaults and bounds of the sample params
+const (
+	defaultSampleMax     = 100
+	maxSampleMax         = 10000
+	defaultSampleMaxBody = 4096
+	maxSampleMaxBody     = 1 << 20
+)
+
+// samplesKeyPrefix prefixes the result store keys of the samples of attacks
+const samplesKeyPrefix = "samples-"
+
+// SampleParams opts an attack in to capturing samples of its requests and
+// responses, stored with the attack for debugging
+type SampleParams struct {
+	// Max is the number of samples kept, the first matching the filter,
+	// defaults to 100
+	Max int `json:"max,omitempty"`
+	// Filter is one of all, errors or non-2xx, defaults to all
+	Filter string `json:"filter,omitempty"`
+	// Percent of the matching requests sampled at random, defaults to 100
+	Percent float64 `json:"percent,omitempty"`
+	// MaxBody truncates the captured bodies, defaults to 4096 bytes
+	MaxBody int `json:"max-body,omitempty"`
+}
+
+// Validate checks the filter of the sample params and their bounds
+func (p SampleParams) Validate() error {
+	switch p.Filter {
+	case "", SampleFilterAll, SampleFilterErrors, SampleFilterNon2xx:
+	default:
+		return fmt.Errorf("unsupported sample filter %q", p.Filter)
+	}
+	if p.Max < 0 || p.Max > maxSampleMax {
+		return fmt.Errorf("sample max must be between 0 and %d", maxSampleMax)
+	}
+	if p.Percent < 0 || p.Percent > 100 {
+		return fmt.Errorf("sample percent must be between 0 and 100")
+	}
+	if p.MaxBody < 0 || p.MaxBody > maxSampleMaxBody {
+		return fmt.Errorf("sample max-body must be between 0 and %d", maxSampleMaxBody)
+	}
+	return nil
+}
+
+// MaxOrDefault returns the number of samples kept, 100 if not set
+func (p SampleParams) MaxOrDefault() int {
+	if p.Max == 0 {
+		return defaultSampleMax
+	}
+	return p.Max
+}
+
+// FilterOrDefault returns the filter of the samples, all if not set
+func (p SampleParams) FilterOrDefault() string {
+	if p.Filter == "" {
+		return SampleFilterAll
+	}
+	return p.Filter
+}
+
+// PercentOrDefault returns the percentage of requests sampled, 100 if not set
+func (p SampleParams) PercentOrDefault() float64 {
+	if p.Percent == 0 {
+		return 100
+	}
+	return p.Percent
+}
+
+// MaxBodyOrDefault returns the size bodies are truncated to, 4096 if not set
+func (p SampleParams) MaxBodyOrDefault() int {
+	if p.MaxBody == 0 {
+		return defaultSampleMaxBody
+	}
+	return p.MaxBody
+}
+
+// Sample is a request of an attack captured along with its response
+type Sample struct {
+	Seq       uint64    `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	// Name is the name of the result, i.e. its target or scenario step
+	Name string `json:"name,omitempty"`
+	// Latency in nanoseconds
+	Latency  time.Duration   `json:"latency"`
+	Code     uint16          `json:"code"`
+	Error    string          `json:"error,omitempty"`
+	Request  *SampleRequest  `json:"request,omitempty"`
+	Response *SampleResponse `json:"response,omitempty"`
+}
+
+// SampleRequest is the request of a sample, missing if it could not be built
+type SampleRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	// Truncated is set if the body was longer than the sample max-body
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// SampleResponse is the response of a sample, missing if the request failed
+type SampleResponse struct {
+	Status  string      `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	// Truncated is set if the body was longer than the sample max-body
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// SamplesKey returns the result store key the samples of an attack are
+// kept under
+func SamplesKey(id string) string {
+	return samplesKeyPrefix + id
+}
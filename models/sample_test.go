@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestSampleParams_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  SampleParams
+		wantErr bool
+	}{
+		{"Defaults", SampleParams{}, false},
+		{"Errors", SampleParams{Filter: SampleFilterErrors, Max: 10, Percent: 5, MaxBody: 1024}, false},
+		{"Non-2xx", SampleParams{Filter: SampleFilterNon2xx}, false},
+		{"Unsupported filter", SampleParams{Filter: "slow"}, true},
+		{"Negative max", SampleParams{Max: -1}, true},
+		{"Too many", SampleParams{Max: maxSampleMax + 1}, true},
+		{"Percent over 100", SampleParams{Percent: 101}, true},
+		{"Too large bodies", SampleParams{MaxBody: maxSampleMaxBody + 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.params.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("SampleParams.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
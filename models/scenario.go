@@ -0,0 +1,163 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+)
+
+// Sources values are extracted from in scenario steps
+const (
+	// ExtractJSONPath extracts a value from a JSON response body by JSONPath
+	ExtractJSONPath = "jsonpath"
+	// ExtractRegex extracts the first submatch, or the whole match, of a
+	// regular expression in the response body
+	ExtractRegex = "regex"
+	// ExtractHeader extracts the value of a response header
+	ExtractHeader = "header"
+)
+
+// variablePattern matches ${name} references to extracted values
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// variableNamePattern restricts the names values are extracted into
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Scenario is an ordered list of requests making up a user journey, run in
+// place of the attack targets. Every iteration of a scenario is run by a
+// new virtual user, with its own cookie jar and extracted values.
+type Scenario struct {
+	Steps []ScenarioStep `json:"steps"`
+}
+
+// ScenarioStep is a single request of a scenario. The URL, header values
+// and body may refer to values extracted by earlier steps as ${name}.
+type ScenarioStep struct {
+	// Name identifies the step in reports
+	Name string `json:"name"`
+	Target
+	// Extract values from the response for later steps
+	Extract []Extraction `json:"extract,omitempty"`
+}
+
+// Extraction captures a value from a step's response into a variable
+type Extraction struct {
+	// Name of the variable the value is stored in
+	Name string `json:"name"`
+	// Source is one of jsonpath, regex or header
+	Source string `json:"source"`
+	// Expression is the JSONPath, regular expression or header name
+	Expression string `json:"expression"`
+}
+
+// ValidateScenario checks that the attack has exactly one of targets, a
+// target file, a replay or a valid scenario
+func ValidateScenario(params AttackParams) error {
+	sources := 0
+	for _, given := range []bool{len(params.Target) > 0, params.TargetFile != nil, params.Replay != nil, params.Scenario != nil} {
+		if given {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		return fmt.Errorf("either target, target-file, replay or scenario is required")
+	case sources > 1:
+		return fmt.Errorf("target, target-file, replay and scenario are mutually exclusive")
+	case params.Scenario == nil:
+		return nil
+	}
+	if params.H2c {
+		return fmt.Errorf("h2c is not supported by scenarios")
+	}
+	return params.Scenario.Validate()
+}
+
+// Validate checks the steps of the scenario, and that variables are only
+// used after being extracted by an earlier step
+func (s Scenario) Validate() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+
+	names := make(map[string]bool)
+	defined := make(map[string]bool)
+	for i, step := range s.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("scenario step %d has no name", i)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("duplicate scenario step %q", step.Name)
+		}
+		names[step.Name] = true
+
+		if step.URL == "" {
+			return fmt.Errorf("scenario step %q has no URL", step.Name)
+		}
+		body, err := base64.StdEncoding.DecodeString(step.Body)
+		if err != nil {
+			return fmt.Errorf("scenario step %q has an invalid body: %v", step.Name, err)
+		}
+
+		used := Variables(step.URL)
+		used = append(used, Variables(string(body))...)
+		for _, h := range step.Headers {
+			used = append(used, Variables(h.Value)...)
+		}
+		for _, name := range used {
+			if !defined[name] {
+				return fmt.Errorf("scenario step %q uses undefined variable %q", step.Name, name)
+			}
+		}
+
+		if err := ValidateAssertions(step.Assert); err != nil {
+			return fmt.Errorf("scenario step %q: %v", step.Name, err)
+		}
+		for _, e := range step.Extract {
+			if err := e.Validate(); err != nil {
+				return fmt.Errorf("scenario step %q: %v", step.Name, err)
+			}
+			defined[e.Name] = true
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the variable name, source and expression of the extraction
+func (e Extraction) Validate() error {
+	if !variableNamePattern.MatchString(e.Name) {
+		return fmt.Errorf("invalid variable name %q", e.Name)
+	}
+	if e.Expression == "" {
+		return fmt.Errorf("extraction of %q has no expression", e.Name)
+	}
+
+	switch e.Source {
+	case ExtractJSONPath:
+		_, err := ParseJSONPath(e.Expression)
+		return err
+	case ExtractRegex:
+		_, err := regexp.Compile(e.Expression)
+		return err
+	case ExtractHeader:
+		return nil
+	}
+	return fmt.Errorf("unsupported extraction source %q", e.Source)
+}
+
+// Variables returns the names of the variables referenced in s
+func Variables(s string) []string {
+	names := make([]string, 0)
+	for _, m := range variablePattern.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// ExpandVariables replaces the ${name} references in s with their values
+func ExpandVariables(s string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		return vars[ref[2:len(ref)-1]]
+	})
+}
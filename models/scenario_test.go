@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestScenario_Validate(t *testing.T) {
+	login := ScenarioStep{
+		Name:   "login",
+		Target: Target{Method: "POST", URL: "http://localhost/login"},
+		Extract: []Extraction{
+			{Name: "token", Source: ExtractJSONPath, Expression: "$.token"},
+		},
+	}
+	fetch := ScenarioStep{
+		Name: "fetch",
+		Target: Target{
+			URL:     "http://localhost/items",
+			Headers: []AttackHeader{{Key: "Authorization", Value: "Bearer ${token}"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		steps   []ScenarioStep
+		wantErr bool
+	}{
+		{"OK", []ScenarioStep{login, fetch}, false},
+		{"No steps", nil, true},
+		{"Undefined variable", []ScenarioStep{fetch, login}, true},
+		{"Duplicate step", []ScenarioStep{login, login}, true},
+		{"No name", []ScenarioStep{{Target: Target{URL: "http://localhost"}}}, true},
+		{"No URL", []ScenarioStep{{Name: "empty"}}, true},
+		{"Invalid body", []ScenarioStep{{Name: "body", Target: Target{URL: "http://localhost", Body: "%%"}}}, true},
+		{
+			"Variable in body",
+			[]ScenarioStep{login, {
+				Name:   "body",
+				Target: Target{URL: "http://localhost", Body: base64.StdEncoding.EncodeToString([]byte(`{"token":"${token}"}`))},
+			}},
+			false,
+		},
+		{
+			"Unsupported source",
+			[]ScenarioStep{{Name: "x", Target: Target{URL: "http://localhost"}, Extract: []Extraction{{Name: "a", Source: "xpath", Expression: "//a"}}}},
+			true,
+		},
+		{
+			"Invalid regex",
+			[]ScenarioStep{{Name: "x", Target: Target{URL: "http://localhost"}, Extract: []Extraction{{Name: "a", Source: ExtractRegex, Expression: "("}}}},
+			true,
+		},
+		{
+			"Invalid variable name",
+			[]ScenarioStep{{Name: "x", Target: Target{URL: "http://localhost"}, Extract: []Extraction{{Name: "1a", Source: ExtractHeader, Expression: "X-Token"}}}},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (Scenario{Steps: tt.steps}).Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Scenario.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateScenario(t *testing.T) {
+	scenario := &Scenario{Steps: []ScenarioStep{{Name: "home", Target: Target{URL: "http://localhost"}}}}
+	target := []Target{{URL: "http://localhost"}}
+
+	tests := []struct {
+		name    string
+		params  AttackParams
+		wantErr bool
+	}{
+		{"Targets", AttackParams{Target: target}, false},
+		{"Scenario", AttackParams{Scenario: scenario}, false},
+		{"Neither", AttackParams{}, true},
+		{"Both", AttackParams{Target: target, Scenario: scenario}, true},
+		{"H2C", AttackParams{Scenario: scenario, H2c: true}, true},
+		{"Target file", AttackParams{TargetFile: &TargetFile{Data: "GET http://localhost"}}, false},
+		{"Target file and targets", AttackParams{Target: target, TargetFile: &TargetFile{}}, true},
+		{"Replay", AttackParams{Replay: &ReplayParams{BaseURL: "http://localhost"}}, false},
+		{"Replay and scenario", AttackParams{Replay: &ReplayParams{}, Scenario: scenario}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateScenario(tt.params); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateScenario() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]string{"token": "abc", "id": "42"}
+	got := ExpandVariables("/items/${id}?token=${token}&missing=${other}", vars)
+	if want := "/items/42?token=abc&missing="; got != want {
+		t.Errorf("ExpandVariables() = %v, want %v", got, want)
+	}
+	if got := Variables("${a}-${b}-$c"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Variables() = %v, want [a b]", got)
+	}
+}
+
+func TestJSONPath_Lookup(t *testing.T) {
+	var doc interface{}
+	err := json.Unmarshal([]byte(`{"data": {"items": [{"id": 1}, {"id": 2, "access-token": "abc"}]}}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		want   interface{}
+		wantOK bool
+	}{
+		{"$.data.items[1].id", float64(2), true},
+		{"$.data.items[1]['access-token']", "abc", true},
+		{`$["data"].items[0].id`, float64(1), true},
+		{"$.data.items[2]", nil, false},
+		{"$.data.missing", nil, false},
+		{"$.data.items.id", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := ParseJSONPath(tt.path)
+			if err != nil {
+				t.Fatalf("ParseJSONPath() error = %v", err)
+			}
+			got, ok := path.Lookup(doc)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JSONPath.Lookup() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseJSONPath_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "data.id", "$..id", "$.items[", "$.items[-1]", "$.items[x]", "$items"} {
+		if _, err := ParseJSONPath(expr); err == nil {
+			t.Errorf("ParseJSONPath(%q) error = nil", expr)
+		}
+	}
+}
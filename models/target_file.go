@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats of target files, as read by vegeta's targeters
+const (
+	// TargetFormatHTTP is vegeta's text format of a request line, followed
+	// by header lines, per target
+	TargetFormatHTTP = "http"
+	// TargetFormatJSON is vegeta's JSON Lines format, with base64 bodies
+	TargetFormatJSON = "json"
+)
+
+// targetFileKeyPrefix prefixes the result store keys of uploaded target files
+const targetFileKeyPrefix = "targets-"
+
+// TargetFile holds targets in one of vegeta's formats, in place of Target.
+// The targets are either given inline or uploaded along with the attack.
+type TargetFile struct {
+	// Format is http or json, defaults to http
+	Format string `json:"format,omitempty"`
+	// Data holds the targets inline
+	Data string `json:"data,omitempty"`
+	// Key of the uploaded target file in the result store, set by the server
+	Key string `json:"key,omitempty"`
+	// Size of the uploaded target file in bytes, set by the server
+	Size int64 `json:"size,omitempty"`
+}
+
+// AttackInputs holds the data an attack reads, and the samples it writes,
+// besides its params
+type AttackInputs struct {
+	// Feed holds the records of the data feeder of templated targets
+	Feed Feed
+	// Targets opens the uploaded target file of the attack
+	Targets func() (io.ReadCloser, error)
+	// Log opens the uploaded access log replayed by the attack
+	Log func() (io.ReadCloser, error)
+	// Samples receives the sampled requests of the attack as JSON Lines
+	Samples io.Writer
+}
+
+// Validate checks the format of the target file and that its targets are
+// given inline, the key being reserved for uploads
+func (f TargetFile) Validate() error {
+	switch f.Format {
+	case "", TargetFormatHTTP, TargetFormatJSON:
+	default:
+		return fmt.Errorf("unsupported target format %q", f.Format)
+	}
+	if f.Key != "" || f.Size != 0 {
+		return fmt.Errorf("target file key and size are set by the server")
+	}
+	return nil
+}
+
+// FormatOrDefault returns the format of the target file, http if not set
+func (f TargetFile) FormatOrDefault() string {
+	if f.Format == "" {
+		return TargetFormatHTTP
+	}
+	return f.Format
+}
+
+// TargetFileKey returns the result store key an uploaded target file is
+// kept under
+func TargetFileKey(id string) string {
+	return targetFileKeyPrefix + id
+}
+
+// IsTargetFileKey reports whether key refers to an uploaded target file
+func IsTargetFileKey(key string) bool {
+	return strings.HasPrefix(key, targetFileKeyPrefix)
+}
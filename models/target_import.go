@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Formats targets are imported from
+const (
+	// ImportFormatHAR is an HTTP Archive, as captured by browsers
+	ImportFormatHAR = "har"
+	// ImportFormatOpenAPI is an OpenAPI 3 document, in JSON or YAML
+	ImportFormatOpenAPI = "openapi"
+	// ImportFormatCurl is a list of cURL commands
+	ImportFormatCurl = "curl"
+)
+
+// TargetImport converts a document of another tool into the targets of an
+// attack, in place of Target
+type TargetImport struct {
+	// Format is one of har, openapi or curl
+	Format string `json:"format"`
+	// Data holds the document
+	Data string `json:"data"`
+	// BaseURL overrides the first server of OpenAPI documents
+	BaseURL string `json:"base-url,omitempty"`
+}
+
+// Validate checks the format of the import and its base URL
+func (i TargetImport) Validate() error {
+	switch i.Format {
+	case ImportFormatHAR, ImportFormatOpenAPI, ImportFormatCurl:
+	default:
+		return fmt.Errorf("unsupported import format %q", i.Format)
+	}
+	if i.BaseURL != "" {
+		if u, err := url.Parse(i.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid base-url %q", i.BaseURL)
+		}
+	}
+	return nil
+}
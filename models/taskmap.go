@@ -0,0 +1,329 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// taskMapShards is the number of independently locked shards in a TaskMap
+const taskMapShards = 32
+
+// taskMapShard holds the attacks whose ID hashes to the shard
+type taskMapShard struct {
+	mu      sync.RWMutex
+	attacks map[string]AttackDetails
+}
+
+// TaskMap stores attack details in memory. Attacks are spread over shards
+// by ID, each guarded by its own lock, so writers to different attacks and
+// readers do not contend on a single lock.
+//
+// Attacks are copied on the way in and out, so callers never share slices
+// or maps with the store.
+type TaskMap struct {
+	shards [taskMapShards]taskMapShard
+
+	// baselines indexes the IDs of baseline attacks. It is only written
+	// under the lock of the attack's shard, taken first.
+	baselinesMu sync.Mutex
+	baselines   map[string]struct{}
+}
+
+// NewTaskMap constructs a new instance of TaskMap
+func NewTaskMap() *TaskMap {
+	tm := &TaskMap{baselines: make(map[string]struct{})}
+	for i := range tm.shards {
+		tm.shards[i].attacks = make(map[string]AttackDetails)
+	}
+	return tm
+}
+
+// shard returns the shard holding the attack ID
+func (tm *TaskMap) shard(id string) *taskMapShard {
+	h := fnv.New32a()
+	h.Write([]byte(id)) // nolint: errcheck
+	return &tm.shards[h.Sum32()%taskMapShards]
+}
+
+// Add attack details by ID to store
+func (tm *TaskMap) Add(attack AttackDetails) error {
+	attack = cloneAttack(attack)
+	attack.Revision++
+
+	s := tm.shard(attack.ID)
+	s.mu.Lock()
+	s.attacks[attack.ID] = attack
+	tm.index(attack.ID, attack.Baseline != nil)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// index adds or removes an attack from the baselines index
+func (tm *TaskMap) index(id string, baseline bool) {
+	tm.baselinesMu.Lock()
+	defer tm.baselinesMu.Unlock()
+
+	if baseline {
+		tm.baselines[id] = struct{}{}
+	} else {
+		delete(tm.baselines, id)
+	}
+}
+
+// GetAll attacks and details from store. Filters run over a snapshot of
+// the store, taken one shard at a time, so no lock is held while filtering
+// or sorting.
+func (tm *TaskMap) GetAll(filterParams FilterParams, opts ListOptions) ([]AttackDetails, int) {
+	attacks := make([]AttackDetails, 0)
+
+	// Malformed filters match nothing, callers validate them upfront
+	filters, err := createFilterChain(filterParams, time.Now())
+	if err != nil {
+		return attacks, 0
+	}
+	for _, attack := range tm.snapshot() {
+		for _, filter := range filters {
+			if !filter(attack) {
+				goto skip
+			}
+		}
+		attacks = append(attacks, cloneAttack(attack))
+	skip:
+	}
+
+	return opts.Apply(attacks), len(attacks)
+}
+
+// snapshot returns the stored attacks. The attacks still share slices and
+// maps with the store and must be cloned before being handed out.
+func (tm *TaskMap) snapshot() []AttackDetails {
+	attacks := make([]AttackDetails, 0)
+	for i := range tm.shards {
+		s := &tm.shards[i]
+		s.mu.RLock()
+		for _, attack := range s.attacks {
+			attacks = append(attacks, attack)
+		}
+		s.mu.RUnlock()
+	}
+	return attacks
+}
+
+// GetBaselines returns the attacks in the baselines index
+func (tm *TaskMap) GetBaselines() []AttackDetails {
+	tm.baselinesMu.Lock()
+	ids := make([]string, 0, len(tm.baselines))
+	for id := range tm.baselines {
+		ids = append(ids, id)
+	}
+	tm.baselinesMu.Unlock()
+
+	attacks := make([]AttackDetails, 0, len(ids))
+	for _, id := range ids {
+		// Deleted or unmarked since reading the index
+		if attack, err := tm.GetByID(id); err == nil && attack.Baseline != nil {
+			attacks = append(attacks, attack)
+		}
+	}
+	return attacks
+}
+
+// GetByID returns an attack detail by ID
+func (tm *TaskMap) GetByID(id string) (AttackDetails, error) {
+	s := tm.shard(id)
+	s.mu.RLock()
+	attack, ok := s.attacks[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		return AttackDetails{}, fmt.Errorf("attack with id %s not found", id)
+	}
+
+	return cloneAttack(attack), nil
+}
+
+// Update an attack detail in the store
+func (tm *TaskMap) Update(id string, attack AttackDetails) error {
+	if attack.ID != id {
+		return fmt.Errorf("update ID %s and attack ID %s do not match", id, attack.ID)
+	}
+	attack = cloneAttack(attack)
+
+	// Compare and swap under the shard's lock
+	s := tm.shard(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.attacks[id]
+	if !ok {
+		return fmt.Errorf("attack with id %s not found", id)
+	}
+	if stored.Revision != attack.Revision {
+		return ErrRevisionConflict
+	}
+
+	attack.Revision++
+	s.attacks[id] = attack
+	tm.index(id, attack.Baseline != nil)
+
+	return nil
+}
+
+// Delete an attack by ID from the store
+func (tm *TaskMap) Delete(id string) error {
+	s := tm.shard(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.attacks[id]; !ok {
+		return fmt.Errorf("attack with id %s not found", id)
+	}
+
+	delete(s.attacks, id)
+	tm.index(id, false)
+
+	return nil
+}
+
+// cloneAttack returns a copy of the attack that shares no slices, maps or
+// pointers with the original
+func cloneAttack(attack AttackDetails) AttackDetails {
+	attack.Params.Labels = cloneLabels(attack.Params.Labels)
+	if attack.Params.RootCerts != nil {
+		attack.Params.RootCerts = append([]string(nil), attack.Params.RootCerts...)
+	}
+	if attack.Params.Target != nil {
+		targets := make([]Target, len(attack.Params.Target))
+		for i, target := range attack.Params.Target {
+			targets[i] = cloneTarget(target)
+		}
+		attack.Params.Target = targets
+	}
+	if attack.Params.Scenario != nil {
+		steps := make([]ScenarioStep, len(attack.Params.Scenario.Steps))
+		for i, step := range attack.Params.Scenario.Steps {
+			step.Target = cloneTarget(step.Target)
+			if step.Extract != nil {
+				step.Extract = append([]Extraction(nil), step.Extract...)
+			}
+			steps[i] = step
+		}
+		attack.Params.Scenario = &Scenario{Steps: steps}
+	}
+	if attack.Params.Feeder != nil {
+		feeder := *attack.Params.Feeder
+		attack.Params.Feeder = &feeder
+	}
+	if attack.Params.TargetFile != nil {
+		targetFile := *attack.Params.TargetFile
+		attack.Params.TargetFile = &targetFile
+	}
+	if attack.Params.Import != nil {
+		targetImport := *attack.Params.Import
+		attack.Params.Import = &targetImport
+	}
+	if attack.Params.Replay != nil {
+		replay := *attack.Params.Replay
+		if replay.Headers != nil {
+			replay.Headers = append([]AttackHeader(nil), replay.Headers...)
+		}
+		if replay.Fields != nil {
+			fields := *replay.Fields
+			replay.Fields = &fields
+		}
+		attack.Params.Replay = &replay
+	}
+	if attack.Params.Samples != nil {
+		samples := *attack.Params.Samples
+		attack.Params.Samples = &samples
+	}
+	if attack.Baseline != nil {
+		baseline := Baseline{Labels: cloneLabels(attack.Baseline.Labels)}
+		if attack.Baseline.Rules != nil {
+			baseline.Rules = make([]BaselineRule, len(attack.Baseline.Rules))
+			for i, rule := range attack.Baseline.Rules {
+				baseline.Rules[i] = cloneBaselineRule(rule)
+			}
+		}
+		attack.Baseline = &baseline
+	}
+	if attack.Verdict != nil {
+		verdict := *attack.Verdict
+		verdict.Labels = cloneLabels(verdict.Labels)
+		if verdict.Checks != nil {
+			verdict.Checks = make([]RuleCheck, len(attack.Verdict.Checks))
+			for i, check := range attack.Verdict.Checks {
+				check.BaselineRule = cloneBaselineRule(check.BaselineRule)
+				check.Regression = cloneFloat(check.Regression)
+				verdict.Checks[i] = check
+			}
+		}
+		if verdict.EvaluatedAt != nil {
+			evaluatedAt := *verdict.EvaluatedAt
+			verdict.EvaluatedAt = &evaluatedAt
+		}
+		attack.Verdict = &verdict
+	}
+	if attack.Result != nil {
+		ref := *attack.Result
+		attack.Result = &ref
+	}
+	if attack.Samples != nil {
+		ref := *attack.Samples
+		attack.Samples = &ref
+	}
+	if attack.Events != nil {
+		attack.Events = append([]AttackEvent(nil), attack.Events...)
+	}
+	return attack
+}
+
+// cloneLabels copies the labels of an attack or a baseline
+func cloneLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(labels))
+	for k, v := range labels {
+		clone[k] = v
+	}
+	return clone
+}
+
+// cloneBaselineRule copies the bounds of a baseline rule
+func cloneBaselineRule(rule BaselineRule) BaselineRule {
+	rule.MaxRegression = cloneFloat(rule.MaxRegression)
+	rule.Min = cloneFloat(rule.Min)
+	rule.Max = cloneFloat(rule.Max)
+	return rule
+}
+
+// cloneFloat copies an optional float
+func cloneFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	clone := *f
+	return &clone
+}
+
+// cloneTarget copies the headers and assertions of a target
+func cloneTarget(target Target) Target {
+	if target.Headers != nil {
+		target.Headers = append([]AttackHeader(nil), target.Headers...)
+	}
+	if target.Assert != nil {
+		assert := make([]Assertion, len(target.Assert))
+		for i, a := range target.Assert {
+			if a.Status != nil {
+				a.Status = append([]int(nil), a.Status...)
+			}
+			assert[i] = a
+		}
+		target.Assert = assert
+	}
+	return target
+}
@@ -0,0 +1,220 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// taskMapOf returns a TaskMap holding the attacks as is, without bumping
+// their revisions
+func taskMapOf(attacks map[string]AttackDetails) *TaskMap {
+	tm := NewTaskMap()
+	for id, attack := range attacks {
+		tm.shard(id).attacks[id] = attack
+		tm.index(id, attack.Baseline != nil)
+	}
+	return tm
+}
+
+func TestTaskMap_CopyOnRead(t *testing.T) {
+	tm := NewTaskMap()
+	maxRegression, regression, evaluatedAt := 0.1, 0.05, time.Now()
+	rule := BaselineRule{Metric: BaselineMetricP99, MaxRegression: &maxRegression}
+	attack := AttackDetails{
+		AttackInfo: AttackInfo{
+			ID: "1",
+			Params: AttackParams{
+				Labels: map[string]string{"team": "payments"},
+				Import: &TargetImport{Format: ImportFormatCurl, Data: "curl http://localhost"},
+			},
+			Baseline: &Baseline{
+				Labels: map[string]string{"team": "payments"},
+				Rules:  []BaselineRule{rule},
+			},
+			Verdict: &BaselineVerdict{
+				Status:      BaselinePassed,
+				Labels:      map[string]string{"team": "payments"},
+				Checks:      []RuleCheck{{BaselineRule: rule, Regression: &regression, Passed: true}},
+				EvaluatedAt: &evaluatedAt,
+			},
+		},
+		Events: []AttackEvent{{Type: AttackEventScheduled}},
+	}
+	if err := tm.Add(attack); err != nil {
+		t.Fatalf("TaskMap.Add() error = %v", err)
+	}
+	attack.Params.Labels["team"] = "search"
+
+	got, _ := tm.GetByID("1")
+	got.Params.Labels["team"] = "search"
+	got.Params.Import.Data = "curl http://example.com"
+	got.Events[0].Type = AttackEventFailed
+	got.Baseline.Labels["team"] = "search"
+	*got.Baseline.Rules[0].MaxRegression = 1
+	got.Verdict.Labels["team"] = "search"
+	got.Verdict.Checks[0].Passed = false
+	*got.Verdict.Checks[0].Regression = 1
+	*got.Verdict.Checks[0].MaxRegression = 1
+	*got.Verdict.EvaluatedAt = time.Time{}
+
+	want, _ := tm.GetByID("1")
+	if want.Params.Labels["team"] != "payments" {
+		t.Errorf("TaskMap.GetByID() labels = %v, want team=payments", want.Params.Labels)
+	}
+	if want.Params.Import.Data != "curl http://localhost" {
+		t.Errorf("TaskMap.GetByID() import = %v, want curl http://localhost", want.Params.Import.Data)
+	}
+	if want.Events[0].Type != AttackEventScheduled {
+		t.Errorf("TaskMap.GetByID() events = %v, want scheduled", want.Events)
+	}
+	if want.Baseline.Labels["team"] != "payments" || *want.Baseline.Rules[0].MaxRegression != 0.1 {
+		t.Errorf("TaskMap.GetByID() baseline = %+v, want team=payments with max regression 0.1", want.Baseline)
+	}
+	check := want.Verdict.Checks[0]
+	if want.Verdict.Labels["team"] != "payments" || !check.Passed || *check.Regression != 0.05 || *check.MaxRegression != 0.1 {
+		t.Errorf("TaskMap.GetByID() verdict = %+v, want unchanged", want.Verdict)
+	}
+	if !want.Verdict.EvaluatedAt.Equal(evaluatedAt) {
+		t.Errorf("TaskMap.GetByID() evaluated at = %v, want %v", want.Verdict.EvaluatedAt, evaluatedAt)
+	}
+}
+
+func TestTaskMap_ConcurrentUpdate(t *testing.T) {
+	tm := NewTaskMap()
+	if err := tm.Add(AttackDetails{AttackInfo: AttackInfo{ID: "1"}}); err != nil {
+		t.Fatalf("TaskMap.Add() error = %v", err)
+	}
+
+	// Each writer appends an event, retrying on conflicts, so no event
+	// may be lost
+	const writers = 16
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				attack, _ := tm.GetByID("1")
+				attack.Events = append(attack.Events, AttackEvent{Type: AttackEventPinned})
+				if err := tm.Update("1", attack); err != ErrRevisionConflict {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := tm.GetByID("1")
+	if len(got.Events) != writers {
+		t.Errorf("TaskMap.Update() events = %d, want %d", len(got.Events), writers)
+	}
+	if got.Revision != writers+1 {
+		t.Errorf("TaskMap.Update() revision = %d, want %d", got.Revision, writers+1)
+	}
+}
+
+func TestTaskMap_GetBaselines(t *testing.T) {
+	tm := NewTaskMap()
+	baseline := &Baseline{Labels: map[string]string{"service": "checkout"}}
+	for _, id := range []string{"1", "2", "3"} {
+		if err := tm.Add(AttackDetails{AttackInfo: AttackInfo{ID: id, Baseline: baseline}}); err != nil {
+			t.Fatalf("TaskMap.Add() error = %v", err)
+		}
+	}
+	_ = tm.Add(AttackDetails{AttackInfo: AttackInfo{ID: "4"}})
+
+	// Unmarked and deleted attacks leave the index
+	unmarked, _ := tm.GetByID("2")
+	unmarked.Baseline = nil
+	if err := tm.Update("2", unmarked); err != nil {
+		t.Fatalf("TaskMap.Update() error = %v", err)
+	}
+	if err := tm.Delete("3"); err != nil {
+		t.Fatalf("TaskMap.Delete() error = %v", err)
+	}
+
+	got := tm.GetBaselines()
+	if len(got) != 1 || got[0].ID != "1" || got[0].Baseline == nil {
+		t.Errorf("TaskMap.GetBaselines() = %+v, want attack 1", got)
+	}
+}
+
+func newBenchmarkTaskMap(b *testing.B, n int) *TaskMap {
+	tm := NewTaskMap()
+	for i := 0; i < n; i++ {
+		status := AttackResponseStatusCompleted
+		if i%2 == 0 {
+			status = AttackResponseStatusRunning
+		}
+		err := tm.Add(AttackDetails{
+			AttackInfo: AttackInfo{
+				ID:     fmt.Sprintf("attack-%d", i),
+				Status: status,
+			},
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return tm
+}
+
+func BenchmarkTaskMap_GetByID(b *testing.B) {
+	tm := newBenchmarkTaskMap(b, 1000)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			tm.GetByID(fmt.Sprintf("attack-%d", i%1000)) // nolint: errcheck
+			i++
+		}
+	})
+}
+
+func BenchmarkTaskMap_GetAll(b *testing.B) {
+	tm := newBenchmarkTaskMap(b, 1000)
+	filters := FilterParams{"status": string(AttackResponseStatusRunning)}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			tm.GetAll(filters, ListOptions{Limit: 20})
+		}
+	})
+}
+
+func BenchmarkTaskMap_Update(b *testing.B) {
+	tm := newBenchmarkTaskMap(b, 1000)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			id := fmt.Sprintf("attack-%d", i%1000)
+			attack, _ := tm.GetByID(id)
+			attack.Status = AttackResponseStatusCompleted
+			tm.Update(id, attack) // nolint: errcheck
+			i++
+		}
+	})
+}
+
+// BenchmarkTaskMap_Mixed polls listings while updating attacks, as the
+// dispatcher and API clients do
+func BenchmarkTaskMap_Mixed(b *testing.B) {
+	tm := newBenchmarkTaskMap(b, 1000)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%10 == 0 {
+				tm.GetAll(FilterParams{}, ListOptions{Limit: 20})
+			} else {
+				id := fmt.Sprintf("attack-%d", i%1000)
+				attack, _ := tm.GetByID(id)
+				tm.Update(id, attack) // nolint: errcheck
+			}
+			i++
+		}
+	})
+}
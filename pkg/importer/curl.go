@@ -0,0 +1,439 @@
+package importer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+)
+
+// curlValueOptions lists the options of cURL that take a value but make no
+// difference to the request, so that their value is not taken for the URL
+var curlValueOptions = map[string]bool{
+	"-o": true, "--output": true, "-m": true, "--max-time": true, "--connect-timeout": true,
+	"-x": true, "--proxy": true, "-w": true, "--write-out": true, "--retry": true,
+	"-c": true, "--cookie-jar": true, "-E": true, "--cert": true, "--key": true,
+	"--cacert": true, "--resolve": true, "--max-redirs": true, "-r": true, "--range": true,
+}
+
+// curlShortFlags lists the short options of cURL that take no value, which
+// may be combined, e.g. -sSL
+const curlShortFlags = "sSLkvifGIgnNq#"
+
+// curlCommand is a request parsed from the options of a cURL command
+type curlCommand struct {
+	method   string
+	url      string
+	headers  []models.AttackHeader
+	data     []string
+	get      bool
+	head     bool
+	jsonData bool
+}
+
+// Curl converts cURL commands, one per line or continued over several lines
+// with a trailing backslash, into targets. Commands are read the way a
+// POSIX shell would split them, including $'...' quoting as written by
+// browsers' "Copy as cURL". Lines not starting with curl are ignored.
+func Curl(commands string) ([]models.Target, error) {
+	lines, err := splitShellLines(commands)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []models.Target
+	for i, words := range lines {
+		if len(words) == 0 || words[0] != "curl" {
+			continue
+		}
+		cmd, err := parseCurl(words[1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid command %d", i+1)
+		}
+		t, err := cmd.target()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid command %d", i+1)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// parseCurl reads the options of a cURL command
+func parseCurl(args []string) (*curlCommand, error) {
+	cmd := &curlCommand{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := func() (string, error) {
+			i++
+			if i >= len(args) {
+				return "", fmt.Errorf("option %s requires a value", arg)
+			}
+			return args[i], nil
+		}
+
+		// Long options may have their value attached, e.g. --request=POST
+		if strings.HasPrefix(arg, "--") {
+			if eq := strings.Index(arg, "="); eq > 0 {
+				args = append(args[:i+1], append([]string{arg[eq+1:]}, args[i+1:]...)...)
+				arg = arg[:eq]
+			}
+		} else if strings.HasPrefix(arg, "-") && len(arg) > 2 {
+			// Short options may be combined, the last one taking the rest
+			// of the word as its value, e.g. -sSXPOST
+			for j := 1; j < len(arg); j++ {
+				if !strings.ContainsRune(curlShortFlags, rune(arg[j])) {
+					rest := arg[j+1:]
+					arg = "-" + string(arg[j])
+					if rest != "" {
+						args = append(args[:i+1], append([]string{rest}, args[i+1:]...)...)
+					}
+					break
+				}
+				cmd.flag("-" + string(arg[j]))
+				if j == len(arg)-1 {
+					arg = ""
+				}
+			}
+			if arg == "" {
+				continue
+			}
+		}
+
+		var err error
+		switch arg {
+		case "-X", "--request":
+			cmd.method, err = value()
+		case "-H", "--header":
+			var h string
+			if h, err = value(); err == nil {
+				err = cmd.header(h)
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			var d string
+			if d, err = value(); err == nil {
+				if strings.HasPrefix(d, "@") {
+					return nil, fmt.Errorf("data read from file %q is not supported", d[1:])
+				}
+				cmd.data = append(cmd.data, d)
+			}
+		case "--data-raw":
+			var d string
+			if d, err = value(); err == nil {
+				cmd.data = append(cmd.data, d)
+			}
+		case "--data-urlencode":
+			var d string
+			if d, err = value(); err == nil {
+				cmd.data = append(cmd.data, urlEncodeData(d))
+			}
+		case "--json":
+			var d string
+			if d, err = value(); err == nil {
+				cmd.data = append(cmd.data, d)
+				cmd.jsonData = true
+			}
+		case "-u", "--user":
+			var u string
+			if u, err = value(); err == nil {
+				cmd.headers = append(cmd.headers, models.AttackHeader{
+					Key:   "Authorization",
+					Value: "Basic " + base64.StdEncoding.EncodeToString([]byte(u)),
+				})
+			}
+		case "-A", "--user-agent":
+			err = cmd.headerValue("User-Agent", value)
+		case "-e", "--referer":
+			err = cmd.headerValue("Referer", value)
+		case "-b", "--cookie":
+			var c string
+			if c, err = value(); err == nil {
+				if !strings.Contains(c, "=") {
+					return nil, fmt.Errorf("cookies read from file %q are not supported", c)
+				}
+				cmd.headers = append(cmd.headers, models.AttackHeader{Key: "Cookie", Value: c})
+			}
+		case "--url":
+			cmd.url, err = value()
+		default:
+			switch {
+			case curlValueOptions[arg]:
+				_, err = value()
+			case strings.HasPrefix(arg, "-"):
+				cmd.flag(arg)
+			default:
+				cmd.url = arg
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
+
+// flag handles an option of cURL taking no value, ignoring those that make
+// no difference to the request
+func (c *curlCommand) flag(arg string) {
+	switch arg {
+	case "-G", "--get":
+		c.get = true
+	case "-I", "--head":
+		c.head = true
+	case "--compressed":
+		if !hasHeader(c.headers, "Accept-Encoding") {
+			c.headers = append(c.headers, models.AttackHeader{Key: "Accept-Encoding", Value: "deflate, gzip"})
+		}
+	}
+}
+
+// header adds a header in the "Key: value" form of -H. A header without
+// value, e.g. "Accept:", removes it in cURL, and is skipped.
+func (c *curlCommand) header(h string) error {
+	colon := strings.Index(h, ":")
+	if colon <= 0 {
+		if strings.HasSuffix(h, ";") {
+			// "Key;" sends the header without a value
+			c.headers = append(c.headers, models.AttackHeader{Key: strings.TrimSuffix(h, ";")})
+			return nil
+		}
+		return fmt.Errorf("invalid header %q", h)
+	}
+	key, value := strings.TrimSpace(h[:colon]), strings.TrimSpace(h[colon+1:])
+	if value == "" {
+		return nil
+	}
+	c.headers = append(c.headers, models.AttackHeader{Key: key, Value: value})
+	return nil
+}
+
+// headerValue sets the header to the value of an option
+func (c *curlCommand) headerValue(key string, value func() (string, error)) error {
+	v, err := value()
+	if err == nil {
+		c.headers = append(c.headers, models.AttackHeader{Key: key, Value: v})
+	}
+	return err
+}
+
+// target returns the target of the command. As with cURL, data is sent as
+// form in a POST unless -G moves it to the query.
+func (c *curlCommand) target() (models.Target, error) {
+	if c.url == "" {
+		return models.Target{}, fmt.Errorf("no URL")
+	}
+	rawURL := c.url
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	method, body := c.method, strings.Join(c.data, "&")
+	switch {
+	case c.get:
+		if body != "" {
+			sep := "?"
+			if strings.Contains(rawURL, "?") {
+				sep = "&"
+			}
+			rawURL += sep + body
+		}
+		body = ""
+		if method == "" {
+			method = "GET"
+		}
+	case c.head && method == "":
+		method = "HEAD"
+	case len(c.data) > 0 && method == "":
+		method = "POST"
+	}
+
+	headers := c.headers
+	if len(c.data) > 0 && !c.get {
+		contentType := "application/x-www-form-urlencoded"
+		if c.jsonData {
+			contentType = "application/json"
+			if !hasHeader(headers, "Accept") {
+				headers = append(headers, models.AttackHeader{Key: "Accept", Value: "application/json"})
+			}
+		}
+		if !hasHeader(headers, "Content-Type") {
+			headers = append(headers, models.AttackHeader{Key: "Content-Type", Value: contentType})
+		}
+	}
+
+	return newTarget(method, rawURL, headers, body)
+}
+
+// urlEncodeData encodes the value of --data-urlencode, given as content,
+// =content or name=content
+func urlEncodeData(d string) string {
+	eq := strings.Index(d, "=")
+	switch {
+	case eq < 0:
+		return url.QueryEscape(d)
+	case eq == 0:
+		return url.QueryEscape(d[1:])
+	default:
+		return d[:eq] + "=" + url.QueryEscape(d[eq+1:])
+	}
+}
+
+// splitShellLines splits the text into the words of its commands, one per
+// line, the way a POSIX shell does. Backslashes at the end of a line
+// continue the command, # starts a comment, and ; or && end the command.
+func splitShellLines(s string) ([][]string, error) {
+	var (
+		lines  [][]string
+		words  []string
+		word   strings.Builder
+		inWord bool
+	)
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endLine := func() {
+		endWord()
+		lines = append(lines, words)
+		words = nil
+	}
+
+	r := []rune(s)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(r) && r[i+1] == '\r' {
+				i++
+			}
+			if i+1 < len(r) && r[i+1] == '\n' {
+				i++
+				continue
+			}
+			if i+1 < len(r) {
+				i++
+				word.WriteRune(r[i])
+				inWord = true
+			}
+		case c == '\n' || c == ';':
+			endLine()
+		case c == '&' && i+1 < len(r) && r[i+1] == '&':
+			i++
+			endLine()
+		case c == ' ' || c == '\t' || c == '\r':
+			endWord()
+		case c == '#' && !inWord:
+			for i+1 < len(r) && r[i+1] != '\n' {
+				i++
+			}
+		case c == '\'':
+			end := indexRune(r, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(string(r[i+1 : end]))
+			inWord, i = true, end
+		case c == '$' && i+1 < len(r) && r[i+1] == '\'':
+			n, err := readANSIQuoted(r, i+2, &word)
+			if err != nil {
+				return nil, err
+			}
+			inWord, i = true, n
+		case c == '"':
+			n, err := readDoubleQuoted(r, i+1, &word)
+			if err != nil {
+				return nil, err
+			}
+			inWord, i = true, n
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	endLine()
+	return lines, nil
+}
+
+// indexRune returns the index of the first c in r from start, or -1
+func indexRune(r []rune, start int, c rune) int {
+	for i := start; i < len(r); i++ {
+		if r[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// readDoubleQuoted writes the double quoted string starting at r[start] to
+// w, returning the index of its closing quote
+func readDoubleQuoted(r []rune, start int, w *strings.Builder) (int, error) {
+	for i := start; i < len(r); i++ {
+		switch r[i] {
+		case '"':
+			return i, nil
+		case '\\':
+			// Within double quotes backslashes only escape these
+			if i+1 < len(r) && strings.ContainsRune("\"\\$`\n", r[i+1]) {
+				i++
+				if r[i] == '\n' {
+					continue
+				}
+			}
+		}
+		w.WriteRune(r[i])
+	}
+	return 0, fmt.Errorf("unterminated double quote")
+}
+
+// readANSIQuoted writes the $'...' string starting at r[start] to w,
+// decoding its backslash escapes, and returns the index of its closing quote
+func readANSIQuoted(r []rune, start int, w *strings.Builder) (int, error) {
+	for i := start; i < len(r); i++ {
+		switch r[i] {
+		case '\'':
+			return i, nil
+		case '\\':
+			if i+1 >= len(r) {
+				return 0, fmt.Errorf("unterminated $' quote")
+			}
+			i++
+			switch c := r[i]; c {
+			case 'n':
+				w.WriteByte('\n')
+			case 't':
+				w.WriteByte('\t')
+			case 'r':
+				w.WriteByte('\r')
+			case 'x', 'u', 'U':
+				size := map[rune]int{'x': 2, 'u': 4, 'U': 8}[c]
+				end := i + 1
+				for end < len(r) && end-i-1 < size && strings.ContainsRune("0123456789abcdefABCDEF", r[end]) {
+					end++
+				}
+				n, err := strconv.ParseUint(string(r[i+1:end]), 16, 32)
+				if err != nil {
+					return 0, fmt.Errorf("invalid escape \\%c in $' quote", c)
+				}
+				if c == 'x' {
+					w.WriteByte(byte(n))
+				} else {
+					w.WriteRune(rune(n))
+				}
+				i = end - 1
+			default:
+				// \\, \', \" and \? stand for themselves
+				w.WriteRune(c)
+			}
+		default:
+			w.WriteRune(r[i])
+		}
+	}
+	return 0, fmt.Errorf("unterminated $' quote")
+}
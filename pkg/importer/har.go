@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+)
+
+// harHeaderSkip lists the headers set by the HTTP client itself, which are
+// not copied from captured requests
+var harHeaderSkip = map[string]bool{
+	"content-length":    true,
+	"host":              true,
+	"connection":        true,
+	"transfer-encoding": true,
+}
+
+// harNameValue is a header, query parameter or form field of a HAR request
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// har holds the requests of an HTTP Archive, as laid out by
+// http://www.softwareishard.com/blog/har-12-spec/
+type har struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method   string         `json:"method"`
+				URL      string         `json:"url"`
+				Headers  []harNameValue `json:"headers"`
+				PostData *struct {
+					MimeType string         `json:"mimeType"`
+					Text     string         `json:"text"`
+					Params   []harNameValue `json:"params"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// HAR converts every http and https request of an HTTP Archive into a
+// target, in capture order. Pseudo-headers of HTTP/2 captures and headers
+// set by the client are dropped.
+func HAR(data []byte) ([]models.Target, error) {
+	var doc har
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed to decode HAR")
+	}
+
+	var targets []models.Target
+	for i, entry := range doc.Log.Entries {
+		req := entry.Request
+		if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+			// data: URLs and the like are not sent over the network
+			continue
+		}
+
+		var headers []models.AttackHeader
+		for _, h := range req.Headers {
+			if strings.HasPrefix(h.Name, ":") || harHeaderSkip[strings.ToLower(h.Name)] {
+				continue
+			}
+			headers = append(headers, models.AttackHeader{Key: h.Name, Value: h.Value})
+		}
+
+		var body string
+		if pd := req.PostData; pd != nil {
+			body = pd.Text
+			if body == "" && len(pd.Params) > 0 {
+				form := url.Values{}
+				for _, p := range pd.Params {
+					form.Add(p.Name, p.Value)
+				}
+				body = form.Encode()
+			}
+			if pd.MimeType != "" && !hasHeader(headers, "Content-Type") {
+				headers = append(headers, models.AttackHeader{Key: "Content-Type", Value: pd.MimeType})
+			}
+		}
+
+		t, err := newTarget(req.Method, req.URL, headers, body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid entry %d", i)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
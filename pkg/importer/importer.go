@@ -0,0 +1,77 @@
+// Package importer converts HAR captures, OpenAPI documents and cURL
+// commands into attack targets
+package importer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoTargets is returned for documents without any requests to import
+var ErrNoTargets = errors.New("no targets to import")
+
+// Import converts the document of the import into targets
+func Import(imp models.TargetImport) ([]models.Target, error) {
+	if err := imp.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		targets []models.Target
+		err     error
+	)
+	switch imp.Format {
+	case models.ImportFormatHAR:
+		targets, err = HAR([]byte(imp.Data))
+	case models.ImportFormatOpenAPI:
+		targets, err = OpenAPI([]byte(imp.Data), imp.BaseURL)
+	case models.ImportFormatCurl:
+		targets, err = Curl(imp.Data)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to import %s", imp.Format)
+	}
+	if len(targets) == 0 {
+		return nil, ErrNoTargets
+	}
+	return targets, nil
+}
+
+// newTarget returns the target of a request, base64 encoding its body
+func newTarget(method, rawURL string, headers []models.AttackHeader, body string) (models.Target, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return models.Target{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return models.Target{}, fmt.Errorf("URL %q is not an absolute http or https URL", rawURL)
+	}
+
+	t := models.Target{
+		Method:  strings.ToUpper(method),
+		URL:     rawURL,
+		Headers: headers,
+	}
+	if t.Method == "" {
+		t.Method = "GET"
+	}
+	if body != "" {
+		t.Body = base64.StdEncoding.EncodeToString([]byte(body))
+	}
+	return t, nil
+}
+
+// hasHeader reports whether the header is set, ignoring the case of its key
+func hasHeader(headers []models.AttackHeader, key string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.Key, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package importer
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"vegeta-server/models"
+)
+
+// body returns the base64 encoded body of a target
+func body(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestHAR(t *testing.T) {
+	data := `{"log": {"entries": [
+		{"request": {"method": "GET", "url": "https://example.com/items?page=2", "headers": [
+			{"name": ":authority", "value": "example.com"},
+			{"name": "Accept", "value": "application/json"},
+			{"name": "Content-Length", "value": "0"}
+		]}},
+		{"request": {"method": "GET", "url": "data:image/png;base64,AAAA"}},
+		{"request": {"method": "POST", "url": "https://example.com/items", "headers": [],
+			"postData": {"mimeType": "application/json", "text": "{\"name\":\"a\"}"}}},
+		{"request": {"method": "POST", "url": "https://example.com/login", "headers": [],
+			"postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "user", "value": "a b"}]}}}
+	]}}`
+
+	want := []models.Target{
+		{
+			Method:  "GET",
+			URL:     "https://example.com/items?page=2",
+			Headers: []models.AttackHeader{{Key: "Accept", Value: "application/json"}},
+		},
+		{
+			Method:  "POST",
+			URL:     "https://example.com/items",
+			Headers: []models.AttackHeader{{Key: "Content-Type", Value: "application/json"}},
+			Body:    body(`{"name":"a"}`),
+		},
+		{
+			Method:  "POST",
+			URL:     "https://example.com/login",
+			Headers: []models.AttackHeader{{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+			Body:    body("user=a+b"),
+		},
+	}
+
+	got, err := HAR([]byte(data))
+	if err != nil {
+		t.Fatalf("HAR() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HAR() = %+v, want %+v", got, want)
+	}
+
+	if _, err := HAR([]byte("<html>")); err == nil {
+		t.Error("HAR() of an invalid document returned no error")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	data := `
+openapi: 3.0.1
+servers:
+  - url: https://{host}/v1
+    variables:
+      host:
+        default: api.example.com
+paths:
+  /items/{id}:
+    parameters:
+      - $ref: '#/components/parameters/ItemID'
+    get:
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: string
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+            enum: [acme]
+    delete: {}
+  /items:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Item'
+components:
+  parameters:
+    ItemID:
+      name: id
+      in: path
+      required: true
+      example: 42
+  schemas:
+    Item:
+      type: object
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+          example: shoes
+        tags:
+          type: array
+          items:
+            type: string
+        child:
+          $ref: '#/components/schemas/Item'
+`
+
+	got, err := OpenAPI([]byte(data), "")
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+
+	want := []models.Target{
+		{
+			Method:  "POST",
+			URL:     "https://api.example.com/v1/items",
+			Headers: []models.AttackHeader{{Key: "Content-Type", Value: "application/json"}},
+		},
+		{
+			Method:  "GET",
+			URL:     "https://api.example.com/v1/items/42",
+			Headers: []models.AttackHeader{{Key: "X-Tenant", Value: "acme"}},
+		},
+		{
+			Method: "DELETE",
+			URL:    "https://api.example.com/v1/items/42",
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("OpenAPI() = %+v, want %d targets", got, len(want))
+	}
+	for i := range want {
+		if got[i].Method != want[i].Method || got[i].URL != want[i].URL || !reflect.DeepEqual(got[i].Headers, want[i].Headers) {
+			t.Errorf("OpenAPI() target %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// The body of the recursive schema is cut off at the maximum depth
+	b, _ := base64.StdEncoding.DecodeString(got[0].Body)
+	if want := `{"child":{"child":`; len(b) < len(want) || string(b[:len(want)]) != want {
+		t.Errorf("OpenAPI() body = %s, want a nested item", b)
+	}
+
+	got, err = OpenAPI([]byte(`{"openapi": "3.0.0", "paths": {"/health": {"get": {}}}}`), "http://localhost:8080/")
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+	if len(got) != 1 || got[0].URL != "http://localhost:8080/health" {
+		t.Errorf("OpenAPI() with base URL = %+v", got)
+	}
+
+	for name, doc := range map[string]string{
+		"swagger 2":  `{"swagger": "2.0", "paths": {}}`,
+		"no servers": `{"openapi": "3.0.0", "paths": {"/health": {"get": {}}}}`,
+		"not yaml":   "a: [b",
+	} {
+		if _, err := OpenAPI([]byte(doc), ""); err == nil {
+			t.Errorf("OpenAPI() of %s returned no error", name)
+		}
+	}
+}
+
+func TestCurl(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		want     []models.Target
+		wantErr  bool
+	}{
+		{
+			name: "Copy as cURL",
+			commands: `curl 'https://example.com/api/items' \
+  -H 'accept: application/json' \
+  -H 'content-type: application/json' \
+  --data-raw $'{"name":"it\'s"}' \
+  --compressed`,
+			want: []models.Target{{
+				Method: "POST",
+				URL:    "https://example.com/api/items",
+				Headers: []models.AttackHeader{
+					{Key: "accept", Value: "application/json"},
+					{Key: "content-type", Value: "application/json"},
+					{Key: "Accept-Encoding", Value: "deflate, gzip"},
+				},
+				Body: body(`{"name":"it's"}`),
+			}},
+		},
+		{
+			name: "Several commands",
+			commands: `# list
+curl -sSXPUT "http://localhost/items/1" -d "name=a" -d 'b=2'
+curl -G localhost/search --data-urlencode "q=red shoes" && curl -I --url=http://localhost/`,
+			want: []models.Target{
+				{
+					Method:  "PUT",
+					URL:     "http://localhost/items/1",
+					Headers: []models.AttackHeader{{Key: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+					Body:    body("name=a&b=2"),
+				},
+				{Method: "GET", URL: "http://localhost/search?q=red+shoes"},
+				{Method: "HEAD", URL: "http://localhost/"},
+			},
+		},
+		{
+			name:     "Basic auth",
+			commands: "curl -u user:pass -o /dev/null https://example.com/",
+			want: []models.Target{{
+				Method:  "GET",
+				URL:     "https://example.com/",
+				Headers: []models.AttackHeader{{Key: "Authorization", Value: "Basic dXNlcjpwYXNz"}},
+			}},
+		},
+		{
+			name:     "Data file",
+			commands: "curl -d @/etc/passwd http://localhost/",
+			wantErr:  true,
+		},
+		{
+			name:     "Unterminated quote",
+			commands: "curl 'http://localhost/",
+			wantErr:  true,
+		},
+		{
+			name:     "No URL",
+			commands: "curl -X GET",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Curl(tt.commands)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Curl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Curl() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	if _, err := Import(models.TargetImport{Format: "postman"}); err == nil {
+		t.Error("Import() of an unsupported format returned no error")
+	}
+	if _, err := Import(models.TargetImport{Format: models.ImportFormatCurl, Data: "# nothing"}); err != ErrNoTargets {
+		t.Errorf("Import() error = %v, want %v", err, ErrNoTargets)
+	}
+}
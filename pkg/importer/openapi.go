@@ -0,0 +1,435 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// openAPIMethods lists the operations of a path item, in the order of the
+// specification
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// maxSchemaDepth bounds the nesting of example bodies made up from schemas,
+// which may be recursive
+const maxSchemaDepth = 8
+
+// openAPI walks an OpenAPI document decoded into generic values, resolving
+// local references on the way
+type openAPI struct {
+	doc map[string]interface{}
+}
+
+// OpenAPI converts every operation of an OpenAPI 3 document, in JSON or
+// YAML, into a target of the base URL, or of the first server of the
+// document if not set. Path, query and header parameters are filled with
+// their examples, and request bodies with their example or one made up from
+// their schema. Optional parameters without an example are left out.
+func OpenAPI(data []byte, baseURL string) ([]models.Target, error) {
+	// YAML being a superset of JSON, both are read the same way
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "failed to decode OpenAPI document")
+	}
+	doc, ok := normalizeYAML(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("not an OpenAPI document")
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, only 3.x documents are supported", version)
+	}
+
+	o := &openAPI{doc: doc}
+	if baseURL == "" {
+		baseURL = o.serverURL()
+	}
+	if u, err := url.Parse(baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("the document has no absolute server URL, a base-url is required")
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	paths := o.object(doc["paths"])
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var targets []models.Target
+	for _, path := range names {
+		item := o.object(paths[path])
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			t, err := o.target(baseURL, path, method, item, op)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid operation %s %s", strings.ToUpper(method), path)
+			}
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// serverURL returns the URL of the first server, with its variables set to
+// their defaults
+func (o *openAPI) serverURL() string {
+	servers, _ := o.doc["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server := o.object(servers[0])
+	u, _ := server["url"].(string)
+	for name, v := range o.object(server["variables"]) {
+		if def, ok := o.object(v)["default"]; ok {
+			u = strings.Replace(u, "{"+name+"}", fmt.Sprint(def), -1)
+		}
+	}
+	return u
+}
+
+// target returns the target of the operation
+func (o *openAPI) target(baseURL, path, method string, item, op map[string]interface{}) (models.Target, error) {
+	var (
+		query   = url.Values{}
+		headers []models.AttackHeader
+		cookies []string
+	)
+	for _, p := range o.parameters(item, op) {
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		required, _ := p["required"].(bool)
+
+		example, ok := o.parameterExample(p)
+		if !ok && !required && in != "path" {
+			continue
+		}
+		value := formatValue(example)
+
+		switch in {
+		case "path":
+			path = strings.Replace(path, "{"+name+"}", url.PathEscape(value), -1)
+		case "query":
+			query.Add(name, value)
+		case "header":
+			headers = append(headers, models.AttackHeader{Key: name, Value: value})
+		case "cookie":
+			cookies = append(cookies, name+"="+value)
+		}
+	}
+	if len(cookies) > 0 {
+		headers = append(headers, models.AttackHeader{Key: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+
+	rawURL := baseURL + path
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+
+	body, contentType, err := o.requestBody(op)
+	if err != nil {
+		return models.Target{}, err
+	}
+	if contentType != "" && !hasHeader(headers, "Content-Type") {
+		headers = append(headers, models.AttackHeader{Key: "Content-Type", Value: contentType})
+	}
+
+	return newTarget(method, rawURL, headers, body)
+}
+
+// parameters returns the parameters of the path item, overridden by those
+// of the operation with the same name and location
+func (o *openAPI) parameters(item, op map[string]interface{}) []map[string]interface{} {
+	var params []map[string]interface{}
+	index := map[string]int{}
+	for _, list := range []interface{}{item["parameters"], op["parameters"]} {
+		l, _ := list.([]interface{})
+		for _, v := range l {
+			p := o.object(v)
+			key := fmt.Sprint(p["in"], "/", p["name"])
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// parameterExample returns the example of the parameter, or a value made up
+// from its schema. ok reports whether the document gives the value.
+func (o *openAPI) parameterExample(p map[string]interface{}) (interface{}, bool) {
+	if v, ok := o.example(p); ok {
+		return v, true
+	}
+	schema := o.object(p["schema"])
+	if v, ok := o.example(schema); ok {
+		return v, true
+	}
+	for _, key := range []string{"default", "enum"} {
+		if _, ok := schema[key]; ok {
+			return o.schemaExample(schema, 0), true
+		}
+	}
+	return o.schemaExample(schema, 0), false
+}
+
+// requestBody returns the example body of the operation, in the JSON,
+// form or text content type it prefers
+func (o *openAPI) requestBody(op map[string]interface{}) (string, string, error) {
+	content := o.object(o.object(op["requestBody"])["content"])
+	contentType := preferredContentType(content)
+	if contentType == "" {
+		return "", "", nil
+	}
+
+	media := o.object(content[contentType])
+	example, ok := o.example(media)
+	if !ok {
+		example = o.schemaExample(o.object(media["schema"]), 0)
+	}
+	if example == nil {
+		return "", contentType, nil
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+	case mediaType == "application/x-www-form-urlencoded":
+		if fields, ok := example.(map[string]interface{}); ok {
+			form := url.Values{}
+			for k, v := range fields {
+				form.Set(k, formatValue(v))
+			}
+			return form.Encode(), contentType, nil
+		}
+	default:
+		if s, ok := example.(string); ok {
+			return s, contentType, nil
+		}
+	}
+
+	b, err := json.Marshal(example)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to encode example body")
+	}
+	return string(b), contentType, nil
+}
+
+// preferredContentType picks JSON over form over any other content type of
+// a request body. Multipart bodies are never picked, as they need a boundary.
+func preferredContentType(content map[string]interface{}) string {
+	types := make([]string, 0, len(content))
+	for t := range content {
+		if !strings.HasPrefix(strings.ToLower(t), "multipart/") {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+
+	rank := func(t string) int {
+		t = strings.ToLower(t)
+		switch {
+		case strings.HasPrefix(t, "application/json"):
+			return 0
+		case strings.Contains(t, "json"):
+			return 1
+		case strings.HasPrefix(t, "application/x-www-form-urlencoded"):
+			return 2
+		default:
+			return 3
+		}
+	}
+	sort.SliceStable(types, func(i, j int) bool {
+		return rank(types[i]) < rank(types[j])
+	})
+
+	if len(types) == 0 {
+		return ""
+	}
+	return types[0]
+}
+
+// example returns the example of a parameter, media type or schema, or the
+// value of its first named example
+func (o *openAPI) example(v map[string]interface{}) (interface{}, bool) {
+	if example, ok := v["example"]; ok {
+		return example, true
+	}
+	examples := o.object(v["examples"])
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, ok := o.object(examples[name])["value"]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// schemaExample makes up a value of the schema from its examples, defaults
+// and types
+func (o *openAPI) schemaExample(schema map[string]interface{}, depth int) interface{} {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	if v, ok := o.example(schema); ok {
+		return v
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+	if enum, _ := schema["enum"].([]interface{}); len(enum) > 0 {
+		return enum[0]
+	}
+
+	if all, _ := schema["allOf"].([]interface{}); len(all) > 0 {
+		merged := map[string]interface{}{}
+		for _, s := range all {
+			if fields, ok := o.schemaExample(o.object(s), depth+1).(map[string]interface{}); ok {
+				for k, v := range fields {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if some, _ := schema[key].([]interface{}); len(some) > 0 {
+			return o.schemaExample(o.object(some[0]), depth+1)
+		}
+	}
+
+	typ, _ := schema["type"].(string)
+	if typ == "" && schema["properties"] != nil {
+		typ = "object"
+	}
+	switch typ {
+	case "object":
+		fields := map[string]interface{}{}
+		for name, s := range o.object(schema["properties"]) {
+			prop := o.object(s)
+			if readOnly, _ := prop["readOnly"].(bool); readOnly {
+				continue
+			}
+			fields[name] = o.schemaExample(prop, depth+1)
+		}
+		return fields
+	case "array":
+		item := o.schemaExample(o.object(schema["items"]), depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "string":
+		switch schema["format"] {
+		case "date":
+			return "2020-01-01"
+		case "date-time":
+			return "2020-01-01T00:00:00Z"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "http://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// object returns v as an object, following its reference if it is a
+// reference object. Anything else, or a reference that cannot be resolved
+// within the document, returns nil.
+func (o *openAPI) object(v interface{}) map[string]interface{} {
+	for i := 0; i < maxSchemaDepth; i++ {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj
+		}
+		v = o.lookup(ref)
+	}
+	return nil
+}
+
+// lookup returns the value of the local reference, e.g.
+// #/components/schemas/Item
+func (o *openAPI) lookup(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var v interface{} = o.doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = obj[token]
+	}
+	return v
+}
+
+// formatValue formats a parameter value, joining arrays with commas as
+// OpenAPI's default simple and form styles do
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalizeYAML turns the maps decoded by the YAML package into maps with
+// string keys, as decoded from JSON
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalizeYAML(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,145 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"time"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// asserter checks a response, reporting whether it passed
+type asserter struct {
+	name  string
+	check func(res *vegeta.Result, r *http.Response, body []byte) bool
+}
+
+// assertions are the compiled assertions of a target
+type assertions struct {
+	// status holds the expected status codes, if any
+	status   map[uint16]bool
+	statusOf string
+	checks   []asserter
+	// readBody is set if the checks look at the whole body
+	readBody bool
+}
+
+// compileAssertions compiles the assertions of a target, which were
+// validated along with the attack params
+func compileAssertions(list []models.Assertion) (assertions, error) {
+	var as assertions
+	for _, a := range list {
+		name := a.ReportName()
+		switch a.Type {
+		case models.AssertStatus:
+			if as.status == nil {
+				as.status, as.statusOf = make(map[uint16]bool), name
+			}
+			for _, code := range a.Status {
+				as.status[uint16(code)] = true
+			}
+			continue
+		case models.AssertBody:
+			value := []byte(a.Value)
+			as.add(name, true, func(_ *vegeta.Result, _ *http.Response, body []byte) bool {
+				return bytes.Contains(body, value)
+			})
+		case models.AssertRegex:
+			re, err := regexp.Compile(a.Expression)
+			if err != nil {
+				return as, err
+			}
+			as.add(name, true, func(_ *vegeta.Result, _ *http.Response, body []byte) bool {
+				return re.Match(body)
+			})
+		case models.AssertJSONPath:
+			path, err := models.ParseJSONPath(a.Expression)
+			if err != nil {
+				return as, err
+			}
+			value := a.Value
+			as.add(name, true, func(_ *vegeta.Result, _ *http.Response, body []byte) bool {
+				var doc interface{}
+				dec := json.NewDecoder(bytes.NewReader(body))
+				dec.UseNumber()
+				if err := dec.Decode(&doc); err != nil {
+					return false
+				}
+				v, ok := path.Lookup(doc)
+				if !ok || value == "" {
+					return ok
+				}
+				s, err := jsonString(v)
+				return err == nil && s == value
+			})
+		case models.AssertHeader:
+			key, value := textproto.CanonicalMIMEHeaderKey(a.Expression), a.Value
+			as.add(name, false, func(_ *vegeta.Result, r *http.Response, _ []byte) bool {
+				values, ok := r.Header[key]
+				if !ok || value == "" {
+					return ok
+				}
+				for _, v := range values {
+					if v == value {
+						return true
+					}
+				}
+				return false
+			})
+		case models.AssertLatency:
+			max, err := time.ParseDuration(a.Value)
+			if err != nil {
+				return as, err
+			}
+			as.add(name, false, func(res *vegeta.Result, _ *http.Response, _ []byte) bool {
+				return res.Latency <= max
+			})
+		default:
+			return as, fmt.Errorf("unsupported assertion type %q", a.Type)
+		}
+	}
+	return as, nil
+}
+
+// add appends a check of the response
+func (as *assertions) add(name string, readBody bool, check func(*vegeta.Result, *http.Response, []byte) bool) {
+	as.checks = append(as.checks, asserter{name: name, check: check})
+	as.readBody = as.readBody || readBody
+}
+
+// apply fails the result of a response at its first failing assertion. The
+// status assertions replace the default success of 2xx and 3xx codes. Results
+// of failed requests, or of failed statuses, are not checked any further.
+func (as assertions) apply(res *vegeta.Result, r *http.Response, body []byte) {
+	if r == nil {
+		return
+	}
+	if as.status != nil {
+		if !as.status[res.Code] {
+			res.Error = assertionError(as.statusOf)
+			return
+		}
+		res.Error = ""
+	}
+	if res.Error != "" {
+		return
+	}
+	for _, a := range as.checks {
+		if !a.check(res, r, body) {
+			res.Error = assertionError(a.name)
+			return
+		}
+	}
+}
+
+// assertionError is the error of results failing the named assertion. It
+// leaves out the actual values, so that reports list every failing
+// assertion once.
+func assertionError(name string) string {
+	return "assertion failed: " + name
+}
@@ -0,0 +1,140 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestAssertions_Apply(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}
+	body := []byte(`{"status": "error", "items": [{"id": 1}]}`)
+
+	tests := []struct {
+		name      string
+		assert    []models.Assertion
+		code      uint16
+		err       string
+		wantError string
+	}{
+		{
+			name:   "Pass",
+			code:   200,
+			assert: []models.Assertion{{Type: models.AssertBody, Value: "items"}, {Type: models.AssertJSONPath, Expression: "$.items[0].id", Value: "1"}},
+		},
+		{
+			name:      "JSONPath value",
+			code:      200,
+			assert:    []models.Assertion{{Type: models.AssertJSONPath, Expression: "$.status", Value: "ok"}},
+			wantError: `assertion failed: $.status == "ok"`,
+		},
+		{
+			name:      "Named",
+			code:      200,
+			assert:    []models.Assertion{{Name: "no error payload", Type: models.AssertRegex, Expression: `"status":\s*"ok"`}},
+			wantError: "assertion failed: no error payload",
+		},
+		{
+			name:   "Header",
+			code:   200,
+			assert: []models.Assertion{{Type: models.AssertHeader, Expression: "content-type", Value: "application/json"}},
+		},
+		{
+			name:      "Missing header",
+			code:      200,
+			assert:    []models.Assertion{{Type: models.AssertHeader, Expression: "ETag"}},
+			wantError: "assertion failed: header ETag exists",
+		},
+		{
+			name:      "Latency",
+			code:      200,
+			assert:    []models.Assertion{{Type: models.AssertLatency, Value: "100ms"}},
+			wantError: "assertion failed: latency <= 100ms",
+		},
+		{
+			name:      "Unexpected status",
+			code:      200,
+			assert:    []models.Assertion{{Type: models.AssertStatus, Status: []int{201}}},
+			wantError: "assertion failed: status in [201]",
+		},
+		{
+			name:   "Expected error status",
+			code:   404,
+			err:    "404 Not Found",
+			assert: []models.Assertion{{Type: models.AssertStatus, Status: []int{200, 404}}},
+		},
+		{
+			name:      "Error status",
+			code:      500,
+			err:       "500 Internal Server Error",
+			assert:    []models.Assertion{{Type: models.AssertBody, Value: "items"}},
+			wantError: "500 Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as, err := compileAssertions(tt.assert)
+			if err != nil {
+				t.Fatalf("compileAssertions() error = %v", err)
+			}
+			res := &vegeta.Result{Code: tt.code, Error: tt.err, Latency: 150 * time.Millisecond}
+			as.apply(res, resp, body)
+			if res.Error != tt.wantError {
+				t.Errorf("apply() error = %q, want %q", res.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestAttack_Assertions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Errors are reported with a 200 status
+		status := "ok"
+		if r.URL.Path == "/bad" {
+			status = "error"
+		}
+		w.Write([]byte(`{"status": "` + status + `"}`)) // nolint: errcheck
+	}))
+	defer srv.Close()
+
+	assert := []models.Assertion{{Type: models.AssertJSONPath, Expression: "$.status", Value: "ok"}}
+	params := models.AttackParams{
+		Rate:     40,
+		Duration: "250ms",
+		Target: []models.Target{
+			{Method: "GET", URL: srv.URL + "/good", Assert: assert},
+			{Method: "GET", URL: srv.URL + "/bad", Assert: assert},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Attack("assert", params, models.AttackInputs{}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	b, err := CreateReportFromReader(bytes.NewReader(buf.Bytes()), "assert", NewFormat(JSONFormatString))
+	if err != nil {
+		t.Fatalf("CreateReportFromReader() error = %v", err)
+	}
+	var report models.JSONReportResponse
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every response has a 200 status, but half of them fail the assertion
+	if report.Success < 0.4 || report.Success > 0.6 {
+		t.Errorf("report success = %v, want about 0.5", report.Success)
+	}
+	if want := []string{`assertion failed: $.status == "ok"`}; len(report.Errors) != 1 || report.Errors[0] != want[0] {
+		t.Errorf("report errors = %v, want %v", report.Errors, want)
+	}
+}
@@ -0,0 +1,375 @@
+package vegeta
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	vegeta "github.com/tsenart/vegeta/lib"
+	"golang.org/x/net/http2"
+)
+
+// attacker is implemented by the target and scenario attackers
+type attacker interface {
+	Stop()
+}
+
+// httpAttacker sends requests at the rate of a pacer the way vegeta's
+// attacker does, leaving what every iteration sends to its users. Unlike
+// vegeta's attacker it lets them name every result, and look at responses.
+type httpAttacker struct {
+	client     http.Client
+	workers    uint64
+	maxWorkers uint64
+	maxBody    int64
+	samples    *sampler
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	stopch chan struct{}
+	once   sync.Once
+
+	seqmu sync.Mutex
+	seq   uint64
+	began time.Time
+}
+
+// newHTTPAttacker sets up the HTTP client the same way vegeta's attacker does
+func newHTTPAttacker(opts *AttackOpts, c *tls.Config) *httpAttacker {
+	dialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: opts.Laddr.IP, Zone: opts.Laddr.Zone},
+		KeepAlive: 30 * time.Second,
+	}
+	if !opts.Keepalive {
+		dialer.KeepAlive = 0
+	}
+
+	var tr http.RoundTripper
+	if opts.H2c {
+		tr = &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer.Dial(network, addr)
+			},
+		}
+	} else {
+		htr := &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialer.DialContext,
+			TLSClientConfig:     c,
+			MaxIdleConnsPerHost: opts.Connections,
+			DisableKeepAlives:   !opts.Keepalive,
+			ForceAttemptHTTP2:   opts.HTTP2,
+		}
+		if !opts.HTTP2 {
+			htr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		tr = htr
+	}
+
+	var samples *sampler
+	if opts.Samples != nil && opts.SampleWriter != nil {
+		samples = newSampler(*opts.Samples, opts.SampleWriter)
+	}
+
+	maxWorkers := opts.MaxWorkers
+	if maxWorkers == 0 {
+		maxWorkers = DefaultMaxWorkers
+	}
+
+	redirects := opts.Redirects
+	ctx, cancel := context.WithCancel(context.Background())
+	return &httpAttacker{
+		client: http.Client{
+			Timeout:   opts.Timeout,
+			Transport: tr,
+			CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+				switch {
+				case redirects == vegeta.NoFollow:
+					return http.ErrUseLastResponse
+				case redirects < len(via):
+					return fmt.Errorf("stopped after %d redirects", redirects)
+				default:
+					return nil
+				}
+			},
+		},
+		workers:    opts.Workers,
+		maxWorkers: maxWorkers,
+		maxBody:    opts.MaxBody,
+		samples:    samples,
+		ctx:        ctx,
+		cancel:     cancel,
+		stopch:     make(chan struct{}),
+		began:      time.Now(),
+	}
+}
+
+// attack runs iterations at the rate of the pacer for the given duration,
+// sending their results on the returned channel. Like vegeta, it starts more
+// workers than the configured ones whenever all of them are busy, up to the
+// max workers, after which iterations wait for a worker and the rate drops.
+func (a *httpAttacker) attack(p vegeta.Pacer, du time.Duration, iterate func(chan<- *vegeta.Result)) <-chan *vegeta.Result {
+	var wg sync.WaitGroup
+	results := make(chan *vegeta.Result)
+	ticks := make(chan struct{})
+
+	work := func() {
+		defer wg.Done()
+		for range ticks {
+			iterate(results)
+		}
+	}
+	workers := a.workers
+	if workers > a.maxWorkers {
+		workers = a.maxWorkers
+	}
+	for i := uint64(0); i < workers; i++ {
+		wg.Add(1)
+		go work()
+	}
+
+	go func() {
+		defer a.cancel()
+		defer close(results)
+		defer wg.Wait()
+		defer close(ticks)
+
+		began, count := time.Now(), uint64(0)
+		for {
+			elapsed := time.Since(began)
+			if du > 0 && elapsed > du {
+				return
+			}
+
+			wait, stop := p.Pace(elapsed, count)
+			if stop {
+				return
+			}
+
+			select {
+			case <-time.After(wait):
+			case <-a.stopch:
+				return
+			}
+
+			select {
+			case ticks <- struct{}{}:
+				count++
+				continue
+			case <-a.stopch:
+				return
+			default:
+				// All workers are busy, start one more unless there are
+				// max workers already, and wait for one of them
+				if workers < a.maxWorkers {
+					workers++
+					wg.Add(1)
+					go work()
+				}
+			}
+
+			select {
+			case ticks <- struct{}{}:
+				count++
+			case <-a.stopch:
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// Stop the attack, aborting requests in flight
+func (a *httpAttacker) Stop() {
+	a.once.Do(func() {
+		close(a.stopch)
+		a.cancel()
+	})
+}
+
+// send hands a result to the reader of the attack, reporting false if the
+// attack was stopped instead
+func (a *httpAttacker) send(results chan<- *vegeta.Result, res *vegeta.Result) bool {
+	select {
+	case results <- res:
+		return true
+	case <-a.stopch:
+		return false
+	}
+}
+
+// hit sends the request made by newRequest with the client, recording it in
+// a result of the given name. Only max-body bytes of the response body are
+// kept in the result, the request, the response and its whole body are
+// returned as well if readAll is set. Otherwise only the start of the body
+// the samples of the attack may keep is returned. The request is nil if it
+// could not be built, the response if the request failed.
+func (a *httpAttacker) hit(client *http.Client, name string, newRequest func(context.Context) (*http.Request, error),
+	readAll bool) (*vegeta.Result, *http.Request, *http.Response, []byte) {
+	res := vegeta.Result{Attack: name}
+
+	a.seqmu.Lock()
+	res.Timestamp = a.began.Add(time.Since(a.began))
+	res.Seq = a.seq
+	a.seq++
+	a.seqmu.Unlock()
+
+	var req *http.Request
+	fail := func(err error) (*vegeta.Result, *http.Request, *http.Response, []byte) {
+		res.Latency = time.Since(res.Timestamp)
+		res.Error = err.Error()
+		return &res, req, nil, nil
+	}
+
+	req, err := newRequest(a.ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	r, err := client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer r.Body.Close() // nolint: errcheck
+
+	var body []byte
+	if readAll {
+		body, err = ioutil.ReadAll(r.Body)
+		res.BytesIn = uint64(len(body))
+		res.Body = body
+		if err == nil && a.maxBody >= 0 && int64(len(body)) > a.maxBody {
+			res.Body = body[:a.maxBody]
+		}
+	} else {
+		limit := a.maxBody
+		if n := a.samples.bodyLimit(r.StatusCode); limit >= 0 && n > limit {
+			limit = n
+		}
+		var src io.Reader = r.Body
+		if limit >= 0 {
+			src = io.LimitReader(r.Body, limit)
+		}
+		if body, err = ioutil.ReadAll(src); err == nil {
+			var rest int64
+			rest, err = io.Copy(ioutil.Discard, r.Body)
+			res.BytesIn = uint64(len(body)) + uint64(rest)
+		}
+		res.Body = body
+		if a.maxBody >= 0 && int64(len(body)) > a.maxBody {
+			res.Body = body[:a.maxBody]
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+	res.Latency = time.Since(res.Timestamp)
+
+	if req.ContentLength != -1 {
+		res.BytesOut = uint64(req.ContentLength)
+	}
+	if res.Code = uint16(r.StatusCode); res.Code < 200 || res.Code >= 400 {
+		res.Error = r.Status
+	}
+
+	return &res, req, r, body
+}
+
+// targetAttacker hits the targets handed out by a targeter, naming every
+// result after its target and checking it against the target's assertions
+type targetAttacker struct {
+	*httpAttacker
+	next       func(*vegeta.Target) (string, assertions, error)
+	assertions []assertions
+}
+
+// newTargetAttacker returns an attacker of the targets of the options, or
+// of their target file. Results of target files are named after the attack.
+func newTargetAttacker(opts *AttackOpts, c *tls.Config) (*targetAttacker, error) {
+	a := &targetAttacker{httpAttacker: newHTTPAttacker(opts, c)}
+
+	if opts.OpenTargets != nil {
+		tr := newFileTargeter(opts.TargetFormat, opts.OpenTargets)
+		go func() {
+			<-a.ctx.Done()
+			tr.Close()
+		}()
+		a.next = func(tgt *vegeta.Target) (string, assertions, error) {
+			return opts.Name, assertions{}, tr.Next(tgt)
+		}
+		return a, nil
+	}
+
+	compiled := make([]assertions, len(opts.TargetAsserts))
+	for i, list := range opts.TargetAsserts {
+		as, err := compileAssertions(list)
+		if err != nil {
+			a.cancel()
+			return nil, errors.Wrap(err, "failed to compile assertions")
+		}
+		compiled[i] = as
+	}
+
+	order := ""
+	if opts.Feeder != nil {
+		order = opts.Feeder.Order
+	}
+	tr, err := NewTargeter(opts.Target, opts.TargetWeights, opts.Feed, order)
+	if err != nil {
+		a.cancel()
+		return nil, err
+	}
+	a.next = func(tgt *vegeta.Target) (string, assertions, error) {
+		i, err := tr.Next(tgt)
+		var (
+			name string
+			as   assertions
+		)
+		if i < len(opts.TargetNames) {
+			name = opts.TargetNames[i]
+		}
+		if i < len(compiled) {
+			as = compiled[i]
+		}
+		return name, as, err
+	}
+	return a, nil
+}
+
+// Attack hits the targets at the rate of the pacer for the given duration
+func (a *targetAttacker) Attack(p vegeta.Pacer, du time.Duration) <-chan *vegeta.Result {
+	return a.attack(p, du, a.iterate)
+}
+
+// iterate hits the next target. Like vegeta, it stops the attack if the
+// targeter fails, e.g. because a template could not be rendered.
+func (a *targetAttacker) iterate(results chan<- *vegeta.Result) {
+	var tgt vegeta.Target
+	name, as, err := a.next(&tgt)
+
+	res, req, r, body := a.hit(&a.client, name, func(ctx context.Context) (*http.Request, error) {
+		if err != nil {
+			return nil, err
+		}
+		req, err := tgt.Request()
+		if err != nil {
+			return nil, err
+		}
+		return req.WithContext(ctx), nil
+	}, as.readBody)
+	as.apply(res, r, body)
+	a.samples.sample(res, req, r, body)
+
+	a.send(results, res)
+	if err != nil {
+		a.Stop()
+	}
+}
@@ -0,0 +1,213 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestNewTargeter_Weights(t *testing.T) {
+	targets := []vegeta.Target{{URL: "/reads"}, {URL: "/searches"}, {URL: "/writes"}}
+	tr, err := NewTargeter(targets, []int{14, 5, 1}, nil, "")
+	if err != nil {
+		t.Fatalf("NewTargeter() error = %v", err)
+	}
+
+	counts := make([]int, len(targets))
+	burst, longest := 0, 0
+	for i := 0; i < 200; i++ {
+		var tgt vegeta.Target
+		next, err := tr.Next(&tgt)
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if tgt.URL != targets[next].URL {
+			t.Errorf("Next() = %d with URL %s, want %s", next, tgt.URL, targets[next].URL)
+		}
+		counts[next]++
+
+		if burst = burst + 1; next != 0 {
+			burst = 0
+		}
+		if burst > longest {
+			longest = burst
+		}
+	}
+
+	for i, want := range []int{140, 50, 10} {
+		if counts[i] != want {
+			t.Errorf("Next() picked %s %d times, want %d", targets[i].URL, counts[i], want)
+		}
+	}
+	// Smooth weighted round-robin spreads the other targets between the picks
+	// of the heaviest one
+	if longest > 5 {
+		t.Errorf("Next() picked %s %d times in a row", targets[0].URL, longest)
+	}
+}
+
+func TestAttack_WeightedTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path)) // nolint: errcheck
+	}))
+	defer srv.Close()
+
+	params := models.AttackParams{
+		Rate:     40,
+		Duration: "500ms",
+		MaxBody:  -1,
+		Target: []models.Target{
+			{Method: "GET", URL: srv.URL + "/reads", Weight: 3},
+			{Name: "writes", Method: "POST", URL: srv.URL + "/writes"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Attack("weighted", params, models.AttackInputs{}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	counts := make(map[string]int)
+	dec := vegeta.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		var r vegeta.Result
+		if err := dec.Decode(&r); err != nil {
+			break
+		}
+		if r.Error != "" {
+			t.Errorf("target %s failed: %s", r.Attack, r.Error)
+		}
+		counts[r.Attack]++
+	}
+	reads, writes := counts["GET "+srv.URL+"/reads"], counts["writes"]
+	if writes == 0 || reads < 3*(writes-1) || reads > 3*writes {
+		t.Fatalf("Attack() target results = %v, want three reads per write", counts)
+	}
+
+	report, err := CreateReportFromReader(bytes.NewReader(buf.Bytes()), "weighted", NewJSONFormat())
+	if err != nil {
+		t.Fatalf("CreateReportFromReader() error = %v", err)
+	}
+	var jsonReport models.JSONReportResponse
+	if err := json.Unmarshal(report, &jsonReport); err != nil {
+		t.Fatal(err)
+	}
+	if len(jsonReport.Targets) != 2 {
+		t.Fatalf("CreateReportFromReader() targets = %v, want 2", jsonReport.Targets)
+	}
+	read := jsonReport.Targets[0]
+	if read.Name != "GET "+srv.URL+"/reads" || read.Requests != reads ||
+		read.Share != float64(reads)/float64(reads+writes) {
+		t.Errorf("CreateReportFromReader() targets[0] = %+v, want %d reads", read, reads)
+	}
+	if read.StatusCodes["200"] != reads || read.Success != 1 || read.Latencies.Max == 0 {
+		t.Errorf("CreateReportFromReader() targets[0] metrics = %+v, want the metrics of the reads", read.JSONMetrics)
+	}
+
+	text, err := CreateReportFromReader(bytes.NewReader(buf.Bytes()), "weighted", NewTextFormat())
+	if err != nil {
+		t.Fatalf("CreateReportFromReader() error = %v", err)
+	}
+	if !bytes.Contains(text, []byte("\nTargets  ")) || !bytes.Contains(text, []byte("writes  ")) ||
+		!bytes.Contains(text, []byte("\nTarget writes\nRequests")) {
+		t.Errorf("CreateReportFromReader() text report = %s, want the targets", text)
+	}
+}
+
+func newTestHTTPAttacker(workers, maxWorkers uint64) *httpAttacker {
+	return newHTTPAttacker(&AttackOpts{
+		Workers:    workers,
+		MaxWorkers: maxWorkers,
+		Laddr:      struct{ *net.IPAddr }{&net.IPAddr{}},
+	}, nil)
+}
+
+func TestHTTPAttacker_Pacing(t *testing.T) {
+	a := newTestHTTPAttacker(2, 0)
+
+	iterate := func(results chan<- *vegeta.Result) {
+		a.send(results, &vegeta.Result{})
+	}
+	hits := 0
+	for range a.attack(vegeta.ConstantPacer{Freq: 100, Per: time.Second}, 500*time.Millisecond, iterate) {
+		hits++
+	}
+
+	if hits < 45 || hits > 51 {
+		t.Errorf("attack() sent %d hits at 100/s for 500ms, want about 50", hits)
+	}
+}
+
+func TestHTTPAttacker_MaxWorkers(t *testing.T) {
+	tests := []struct {
+		name       string
+		workers    uint64
+		maxWorkers uint64
+		min, max   int64
+	}{
+		{"Capped", 1, 3, 3, 3},
+		{"Workers above max", 5, 2, 2, 2},
+		{"Default max", 1, 0, 18, 21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestHTTPAttacker(tt.workers, tt.maxWorkers)
+
+			// Iterations outlast the attack, so all of them are in flight
+			var busy, most int64
+			var mu sync.Mutex
+			iterate := func(results chan<- *vegeta.Result) {
+				mu.Lock()
+				if busy++; busy > most {
+					most = busy
+				}
+				mu.Unlock()
+				time.Sleep(300 * time.Millisecond)
+				mu.Lock()
+				busy--
+				mu.Unlock()
+				a.send(results, &vegeta.Result{})
+			}
+			for range a.attack(vegeta.ConstantPacer{Freq: 100, Per: time.Second}, 200*time.Millisecond, iterate) {
+			}
+
+			if most < tt.min || most > tt.max {
+				t.Errorf("attack() ran %d iterations at once, want %d to %d", most, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestHTTPAttacker_Stop(t *testing.T) {
+	a := newTestHTTPAttacker(1, 1)
+
+	iterate := func(results chan<- *vegeta.Result) {
+		select {
+		case <-a.ctx.Done():
+		case <-time.After(time.Minute):
+		}
+		a.send(results, &vegeta.Result{})
+	}
+	results := a.attack(vegeta.ConstantPacer{Freq: 100, Per: time.Second}, time.Minute, iterate)
+	time.AfterFunc(50*time.Millisecond, a.Stop)
+
+	done := make(chan struct{})
+	go func() {
+		for range results {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("attack() still running after Stop()")
+	}
+}
@@ -0,0 +1,233 @@
+package vegeta
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// latencySampleSize caps the latencies of each attack kept for the
+// significance test, bounding its memory and sorting cost
+const latencySampleSize = 10000
+
+// CompareResults compares the results of a candidate attack against those of
+// a base attack, read compressed or not. Their latency distributions are
+// compared with a two-sided Mann-Whitney U test at the significance level
+// alpha, run on a uniform random sample of at most latencySampleSize
+// latencies of each attack.
+func CompareResults(baseID string, base io.Reader, candidateID string, candidate io.Reader,
+	alpha float64) (*models.ComparisonResponse, error) {
+	bm, bl, err := summarize(base)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to read results of attack %s", baseID))
+	}
+	cm, cl, err := summarize(candidate)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to read results of attack %s", candidateID))
+	}
+	if bm.Requests == 0 {
+		return nil, fmt.Errorf("attack %s has no results", baseID)
+	}
+	if cm.Requests == 0 {
+		return nil, fmt.Errorf("attack %s has no results", candidateID)
+	}
+
+	resp := &models.ComparisonResponse{
+		Base:        models.ComparedAttack{ID: baseID, Throughput: bm.Throughput},
+		Candidate:   models.ComparedAttack{ID: candidateID, Throughput: cm.Throughput},
+		StatusCodes: make(map[string]models.Delta),
+		Errors:      diffErrors(bm.Errors, cm.Errors),
+		LatencyTest: mannWhitneyU(bl, cl, alpha),
+	}
+	if err := jsonMetrics(&bm.Metrics, &resp.Base.JSONMetrics); err != nil {
+		return nil, err
+	}
+	if err := jsonMetrics(&cm.Metrics, &resp.Candidate.JSONMetrics); err != nil {
+		return nil, err
+	}
+
+	d := &resp.Deltas
+	d.Requests = models.NewDelta(float64(bm.Requests), float64(cm.Requests))
+	d.Rate = models.NewDelta(bm.Rate, cm.Rate)
+	d.Throughput = models.NewDelta(bm.Throughput, cm.Throughput)
+	d.Success = models.NewDelta(bm.Success, cm.Success)
+	latency := func(b, c time.Duration) models.Delta {
+		return models.NewDelta(float64(b), float64(c))
+	}
+	d.Latencies.Mean = latency(bm.Latencies.Mean, cm.Latencies.Mean)
+	d.Latencies.P50th = latency(bm.Latencies.P50, cm.Latencies.P50)
+	d.Latencies.P95th = latency(bm.Latencies.P95, cm.Latencies.P95)
+	d.Latencies.P99th = latency(bm.Latencies.P99, cm.Latencies.P99)
+	d.Latencies.Max = latency(bm.Latencies.Max, cm.Latencies.Max)
+
+	for code := range bm.StatusCodes {
+		resp.StatusCodes[code] = models.NewDelta(float64(bm.StatusCodes[code]), float64(cm.StatusCodes[code]))
+	}
+	for code := range cm.StatusCodes {
+		resp.StatusCodes[code] = models.NewDelta(float64(bm.StatusCodes[code]), float64(cm.StatusCodes[code]))
+	}
+
+	return resp, nil
+}
+
+// summarize decodes the results into their metrics and a sample of their
+// latencies
+func summarize(reader io.Reader) (*metrics, []time.Duration, error) {
+	rc, err := NewDecompressReader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rc.Close() // nolint: errcheck
+
+	m := &metrics{}
+	latencies := newReservoir(latencySampleSize)
+
+	// No decoder is found for an empty result
+	if dec := vegeta.DecoderFor(rc); dec != nil {
+		for {
+			var r vegeta.Result
+			if err := dec.Decode(&r); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, nil, errors.Wrap(err, "failed to decode result")
+			}
+			m.Add(&r)
+			latencies.add(r.Latency)
+		}
+	}
+	m.Close()
+
+	return m, latencies.latencies, nil
+}
+
+// reservoir keeps a uniform random sample of the latencies added to it, of
+// at most size latencies. Its source is seeded with a constant so that
+// comparing the same attacks gives the same result.
+type reservoir struct {
+	size      int
+	seen      int
+	latencies []time.Duration
+	rand      *rand.Rand
+}
+
+// newReservoir returns an empty reservoir of the given size
+func newReservoir(size int) *reservoir {
+	return &reservoir{
+		size:      size,
+		latencies: make([]time.Duration, 0),
+		rand:      rand.New(rand.NewSource(1)), // nolint: gosec
+	}
+}
+
+// add offers a latency to the sample, replacing a kept one at random once
+// the reservoir is full
+func (r *reservoir) add(latency time.Duration) {
+	r.seen++
+	if len(r.latencies) < r.size {
+		r.latencies = append(r.latencies, latency)
+		return
+	}
+	if i := r.rand.Intn(r.seen); i < r.size {
+		r.latencies[i] = latency
+	}
+}
+
+// diffErrors splits the errors of the attacks into new, resolved and common
+// ones, each in the order of the attack they occurred in
+func diffErrors(base, candidate []string) models.ErrorDiff {
+	diff := models.ErrorDiff{New: []string{}, Resolved: []string{}, Common: []string{}}
+	inBase := make(map[string]bool, len(base))
+	for _, e := range base {
+		inBase[e] = true
+	}
+	inCandidate := make(map[string]bool, len(candidate))
+	for _, e := range candidate {
+		inCandidate[e] = true
+		if inBase[e] {
+			diff.Common = append(diff.Common, e)
+		} else {
+			diff.New = append(diff.New, e)
+		}
+	}
+	for _, e := range base {
+		if !inCandidate[e] {
+			diff.Resolved = append(diff.Resolved, e)
+		}
+	}
+	return diff
+}
+
+// mannWhitneyU tests whether the candidate latencies tend to differ from the
+// base latencies. It uses the normal approximation of the U statistic with a
+// continuity and tie correction, which is accurate for the sample sizes of
+// attacks.
+func mannWhitneyU(base, candidate []time.Duration, alpha float64) models.SignificanceTest {
+	test := models.SignificanceTest{Test: "mann-whitney-u", PValue: 1, Alpha: alpha, Effect: 0.5, Verdict: models.VerdictNoChange}
+
+	type sample struct {
+		latency   time.Duration
+		candidate bool
+	}
+	samples := make([]sample, 0, len(base)+len(candidate))
+	for _, l := range base {
+		samples = append(samples, sample{l, false})
+	}
+	for _, l := range candidate {
+		samples = append(samples, sample{l, true})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].latency < samples[j].latency })
+
+	// Rank the latencies, ties getting the mean of their ranks
+	var rankSum, ties float64
+	for i := 0; i < len(samples); {
+		j := i
+		for j < len(samples) && samples[j].latency == samples[i].latency {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if samples[k].candidate {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n1, n2 := float64(len(base)), float64(len(candidate))
+	n := n1 + n2
+	test.U = rankSum - n2*(n2+1)/2
+	test.Effect = test.U / (n1 * n2)
+
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return test
+	}
+	diff := test.U - n1*n2/2
+	switch {
+	case diff > 0.5:
+		diff -= 0.5
+	case diff < -0.5:
+		diff += 0.5
+	default:
+		diff = 0
+	}
+	test.Z = diff / sigma
+	test.PValue = math.Erfc(math.Abs(test.Z) / math.Sqrt2)
+
+	if test.Significant = test.PValue < alpha; test.Significant {
+		test.Verdict = models.VerdictFaster
+		if test.Effect > 0.5 {
+			test.Verdict = models.VerdictSlower
+		}
+	}
+	return test
+}
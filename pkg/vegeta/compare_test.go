@@ -0,0 +1,127 @@
+package vegeta
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestCompareResults(t *testing.T) {
+	encode := func(latency time.Duration, code uint16, errs ...string) *bytes.Buffer {
+		var buf bytes.Buffer
+		enc := vegeta.NewEncoder(&buf)
+		began := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 50; i++ {
+			r := vegeta.Result{
+				Seq:       uint64(i),
+				Code:      code,
+				Timestamp: began.Add(time.Duration(i) * 10 * time.Millisecond),
+				Latency:   latency + time.Duration(i)*time.Millisecond,
+			}
+			if i < len(errs) {
+				r.Code, r.Error = 0, errs[i]
+			}
+			if err := enc.Encode(&r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return &buf
+	}
+
+	base := encode(10*time.Millisecond, 200, "timeout", "reset")
+	candidate := encode(100*time.Millisecond, 500, "reset", "refused")
+	got, err := CompareResults("base", base, "candidate", candidate, models.DefaultComparisonAlpha)
+	if err != nil {
+		t.Fatalf("CompareResults() error = %v", err)
+	}
+
+	if got.Base.ID != "base" || got.Candidate.ID != "candidate" {
+		t.Errorf("CompareResults() ids = %s, %s", got.Base.ID, got.Candidate.ID)
+	}
+	if d := got.Deltas.Latencies.P50th; d.Delta != float64(90*time.Millisecond) || d.Relative == nil {
+		t.Errorf("CompareResults() p50 delta = %+v", d)
+	}
+	if d := got.StatusCodes["200"]; d.Base != 48 || d.Candidate != 0 || *d.Relative != -1 {
+		t.Errorf("CompareResults() 200 delta = %+v", d)
+	}
+	if d := got.StatusCodes["500"]; d.Base != 0 || d.Candidate != 48 || d.Relative != nil {
+		t.Errorf("CompareResults() 500 delta = %+v", d)
+	}
+	wantErrors := models.ErrorDiff{New: []string{"refused"}, Resolved: []string{"timeout"}, Common: []string{"reset"}}
+	if !reflect.DeepEqual(got.Errors, wantErrors) {
+		t.Errorf("CompareResults() errors = %+v, want %+v", got.Errors, wantErrors)
+	}
+	if got.LatencyTest.Verdict != models.VerdictSlower {
+		t.Errorf("CompareResults() verdict = %s, want %s", got.LatencyTest.Verdict, models.VerdictSlower)
+	}
+}
+
+func TestCompareResults_Empty(t *testing.T) {
+	_, err := CompareResults("base", bytes.NewReader(nil), "candidate", bytes.NewReader(nil), models.DefaultComparisonAlpha)
+	if err == nil {
+		t.Error("CompareResults() error = nil, want an error for empty results")
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	latencies := func(from, n int) []time.Duration {
+		l := make([]time.Duration, n)
+		for i := range l {
+			l[i] = time.Duration(from+i) * time.Millisecond
+		}
+		return l
+	}
+
+	tests := []struct {
+		name        string
+		base        []time.Duration
+		candidate   []time.Duration
+		wantVerdict string
+		wantEffect  float64
+	}{
+		{"Identical", latencies(0, 30), latencies(0, 30), models.VerdictNoChange, 0.5},
+		{"Slower", latencies(0, 30), latencies(20, 30), models.VerdictSlower, 0},
+		{"Faster", latencies(20, 30), latencies(0, 30), models.VerdictFaster, 0},
+		{"Separated", latencies(0, 10), latencies(100, 10), models.VerdictSlower, 1},
+		{"All tied", []time.Duration{5, 5, 5}, []time.Duration{5, 5}, models.VerdictNoChange, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mannWhitneyU(tt.base, tt.candidate, models.DefaultComparisonAlpha)
+			if got.Verdict != tt.wantVerdict {
+				t.Errorf("mannWhitneyU() verdict = %s (p %v), want %s", got.Verdict, got.PValue, tt.wantVerdict)
+			}
+			if tt.wantEffect != 0 && got.Effect != tt.wantEffect {
+				t.Errorf("mannWhitneyU() effect = %v, want %v", got.Effect, tt.wantEffect)
+			}
+			if got.Significant != (got.PValue < got.Alpha) {
+				t.Errorf("mannWhitneyU() significant = %v with p %v", got.Significant, got.PValue)
+			}
+		})
+	}
+}
+
+func TestReservoir(t *testing.T) {
+	r := newReservoir(100)
+	for i := 0; i < 10000; i++ {
+		r.add(time.Duration(i))
+	}
+	if len(r.latencies) != 100 {
+		t.Fatalf("reservoir kept %d latencies, want 100", len(r.latencies))
+	}
+
+	// The sample is spread over all added latencies, not only the first
+	var late int
+	for _, l := range r.latencies {
+		if l >= 5000 {
+			late++
+		}
+	}
+	if late < 25 || late > 75 {
+		t.Errorf("reservoir kept %d latencies of the second half, want about 50", late)
+	}
+}
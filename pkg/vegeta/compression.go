@@ -0,0 +1,100 @@
+package vegeta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Compression defines the type of compression applied to encoded results
+type Compression string
+
+const (
+	// CompressionNone stores encoded results as is
+	CompressionNone Compression = "none"
+	// CompressionGzip compresses encoded results using gzip
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd compresses encoded results using zstd
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ContentType returns the MIME type of results stored with this compression
+func (c Compression) ContentType() string {
+	switch c {
+	case CompressionGzip:
+		return "application/gzip"
+	case CompressionZstd:
+		return "application/zstd"
+	}
+	return "application/octet-stream"
+}
+
+// DetectCompression returns the compression of an encoded result by looking
+// at its leading magic bytes
+func DetectCompression(head []byte) Compression {
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(head, zstdMagic):
+		return CompressionZstd
+	}
+	return CompressionNone
+}
+
+// NewCompressWriter returns a writer compressing everything written to it into w.
+// The writer must be closed to flush the compressed stream.
+func NewCompressWriter(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	case CompressionNone, "":
+		return nopWriteCloser{w}, nil
+	}
+	return nil, fmt.Errorf("compression %s not supported", c)
+}
+
+// NewDecompressReader returns a reader over the decompressed encoded result,
+// detecting the compression from the stream itself. Uncompressed results are
+// passed through unchanged.
+func NewDecompressReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(zstdMagic))
+
+	switch DetectCompression(head) {
+	case CompressionGzip:
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read gzip result")
+		}
+		return gr, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read zstd result")
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return ioutil.NopCloser(br), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close implements io.Closer
+func (nopWriteCloser) Close() error {
+	return nil
+}
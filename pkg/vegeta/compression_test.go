@@ -0,0 +1,91 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func encodeResults(t *testing.T, c Compression, n int) []byte {
+	buf := bytes.NewBuffer(nil)
+	w, err := NewCompressWriter(buf, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc := vegeta.NewEncoder(w)
+	for i := 0; i < n; i++ {
+		err := enc.Encode(&vegeta.Result{
+			Attack:    "id",
+			Seq:       uint64(i),
+			Code:      200,
+			Timestamp: time.Unix(0, 0).Add(time.Duration(i) * time.Millisecond),
+			Latency:   time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompression_RoundTrip(t *testing.T) {
+	raw := encodeResults(t, CompressionNone, 100)
+
+	for _, c := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		t.Run(string(c), func(t *testing.T) {
+			stored := encodeResults(t, c, 100)
+			if got := DetectCompression(stored); got != c {
+				t.Errorf("DetectCompression() = %s, want %s", got, c)
+			}
+			if c != CompressionNone && len(stored) >= len(raw) {
+				t.Errorf("compressed size %d not smaller than raw size %d", len(stored), len(raw))
+			}
+
+			rc, err := NewDecompressReader(bytes.NewReader(stored))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := ioutil.ReadAll(rc)
+			if !bytes.Equal(got, raw) {
+				t.Errorf("NewDecompressReader() did not restore the raw result")
+			}
+		})
+	}
+}
+
+func TestCreateReportFromReader_Compressed(t *testing.T) {
+	for _, c := range []Compression{CompressionGzip, CompressionZstd} {
+		t.Run(string(c), func(t *testing.T) {
+			b, err := CreateReportFromReader(bytes.NewReader(encodeResults(t, c, 10)), "id", NewJSONFormat())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var report models.JSONReportResponse
+			if err := json.Unmarshal(b, &report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Requests != 10 {
+				t.Errorf("report requests = %d, want 10", report.Requests)
+			}
+
+			h, err := CreateHistogramFromReader(bytes.NewReader(encodeResults(t, c, 10)), "id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var results []models.SeqResult
+			if err := json.Unmarshal(h, &results); err != nil || len(results) != 10 {
+				t.Errorf("histogram results = %d, err %v", len(results), err)
+			}
+		})
+	}
+}
@@ -25,6 +25,8 @@ func NewFormat(typ string) Format {
 		return NewHistogramFormat()
 	case "hdrplot":
 		return NewHDRHistogramFormat()
+	case "html":
+		return NewHTMLFormat()
 	}
 	return NewJSONFormat() // default
 }
@@ -80,28 +82,32 @@ func (j *TextFormat) Meta() (m MetaInfo) {
 }
 
 // BinaryFormat typedef for query param "binary"
-type BinaryFormat string
+type BinaryFormat struct {
+	repr string
+	meta MetaInfo
+}
 
 // NewBinaryFormat returns a new Format of Binary type
 func NewBinaryFormat() *BinaryFormat {
-	f := BinaryFormat("binary")
-	return &f
+	return &BinaryFormat{
+		repr: "binary",
+		meta: make(MetaInfo),
+	}
 }
 
 // SetMeta will set the meta information
-// no-op method
 func (b *BinaryFormat) SetMeta(key, value string) {
+	b.meta[key] = value
 }
 
 // String implements Stringer for BinaryFormat
 func (b *BinaryFormat) String() string {
-	return string(*b)
+	return b.repr
 }
 
 // Meta returns the meta information stored in the Format
-// no-op method
-func (b *BinaryFormat) Meta() (m MetaInfo) {
-	return
+func (b *BinaryFormat) Meta() MetaInfo {
+	return b.meta
 }
 
 // HistogramFormat typedef for query param "histogram"
@@ -162,3 +168,32 @@ func (p *HDRHistogramFormat) String() string {
 func (p *HDRHistogramFormat) Meta() (m MetaInfo) {
 	return nil
 }
+
+// HTMLFormat typedef for query param "html"
+type HTMLFormat struct {
+	repr string
+	meta MetaInfo
+}
+
+// NewHTMLFormat returns a new Format of HTML type
+func NewHTMLFormat() *HTMLFormat {
+	return &HTMLFormat{
+		repr: "html",
+		meta: make(MetaInfo),
+	}
+}
+
+// SetMeta will set the meta information
+func (h *HTMLFormat) SetMeta(key, value string) {
+	h.meta[key] = value
+}
+
+// String implements Stringer for HTMLFormat
+func (h *HTMLFormat) String() string {
+	return h.repr
+}
+
+// Meta returns the meta information stored in the Format
+func (h *HTMLFormat) Meta() MetaInfo {
+	return h.meta
+}
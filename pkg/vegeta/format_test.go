@@ -63,11 +63,13 @@ func TestNewFormat(t *testing.T) {
 			},
 			want: want{
 				typ: "binary",
-				mta: nil,
+				mta: MetaInfo{"bucket": DefaultBucketString},
 			},
 			setup: func(w *want) {
-				x := BinaryFormat("binary")
-				w.frm = &x
+				w.frm = &BinaryFormat{
+					repr: "binary",
+					meta: make(MetaInfo),
+				}
 			},
 		},
 		{
@@ -88,6 +90,24 @@ func TestNewFormat(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "type: html",
+			args: args{
+				typ: "html",
+				key: "description",
+				val: "nightly",
+			},
+			want: want{
+				typ: "html",
+				mta: MetaInfo{"description": "nightly"},
+			},
+			setup: func(w *want) {
+				w.frm = &HTMLFormat{
+					repr: "html",
+					meta: make(MetaInfo),
+				}
+			},
+		},
 		{
 			name: "type: unknown",
 			args: args{
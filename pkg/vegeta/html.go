@@ -0,0 +1,560 @@
+package vegeta
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	vegeta "github.com/tsenart/vegeta/lib"
+	"github.com/tsenart/vegeta/lib/lttb"
+)
+
+// maxPlotPoints bounds the points of each series of the latency plot. The
+// results are bucketed over time as they are added, each bucket keeping its
+// lowest and highest latency, so spikes survive the downsampling.
+const maxPlotPoints = 4000
+
+// Dimensions of the charts of the HTML report, in pixels
+const (
+	chartWidth, chartHeight       = 960.0, 360.0
+	chartMarginLeft, chartMarginX = 72.0, 16.0
+	chartMarginTop, chartMarginY  = 16.0, 44.0
+)
+
+// Colors of the series of the charts, successful results of the latency plot
+// in greens and failed ones in reds
+var (
+	okColors    = []string{"#488A3A", "#84C068", "#2F7027", "#A6DA83", "#185717", "#64A550"}
+	errorColors = []string{"#CA4E3E", "#EE7860", "#9F2823", "#DD624E", "#6F050E", "#B63A30"}
+	curveColors = []string{"#3366CC", "#DC3912", "#FF9900", "#109618", "#990099", "#0099C6", "#DD4477"}
+)
+
+// latencyPlot collects the latencies of the results over time, by result name
+// and whether the result failed
+type latencyPlot struct {
+	series map[plotKey]*plotSeries
+}
+
+type plotKey struct {
+	name   string
+	failed bool
+}
+
+type plotPoint struct {
+	t  time.Time
+	ms float64
+}
+
+// plotSeries holds at most maxPlotPoints/2 buckets of points, spanning width
+// each. Buckets are keyed by their index since the zero time, in any order,
+// and are merged pairwise by doubling the width once there are too many.
+type plotSeries struct {
+	width   time.Duration
+	buckets map[int64]*plotBucket
+}
+
+// plotBucket holds the points of the lowest and highest latency in a bucket
+type plotBucket struct {
+	min, max plotPoint
+}
+
+func newLatencyPlot() *latencyPlot {
+	return &latencyPlot{series: make(map[plotKey]*plotSeries)}
+}
+
+// Add implements vegeta.Report
+func (p *latencyPlot) Add(r *vegeta.Result) {
+	key := plotKey{r.Attack, r.Error != ""}
+	s, ok := p.series[key]
+	if !ok {
+		s = &plotSeries{width: time.Millisecond, buckets: make(map[int64]*plotBucket)}
+		p.series[key] = s
+	}
+	s.add(plotPoint{r.Timestamp, milliseconds(r.Latency)})
+}
+
+func (s *plotSeries) add(p plotPoint) {
+	i := floorDiv(p.t.UnixNano(), int64(s.width))
+	if b, ok := s.buckets[i]; ok {
+		b.merge(&plotBucket{p, p})
+		return
+	}
+	s.buckets[i] = &plotBucket{p, p}
+
+	for len(s.buckets) > maxPlotPoints/2 {
+		s.width *= 2
+		merged := make(map[int64]*plotBucket, len(s.buckets)/2+1)
+		for i, b := range s.buckets {
+			if m, ok := merged[floorDiv(i, 2)]; ok {
+				m.merge(b)
+			} else {
+				merged[floorDiv(i, 2)] = b
+			}
+		}
+		s.buckets = merged
+	}
+}
+
+func (b *plotBucket) merge(o *plotBucket) {
+	if o.min.ms < b.min.ms {
+		b.min = o.min
+	}
+	if o.max.ms > b.max.ms {
+		b.max = o.max
+	}
+}
+
+// points returns the kept points in time order
+func (s *plotSeries) points() []plotPoint {
+	points := make([]plotPoint, 0, 2*len(s.buckets))
+	for _, b := range s.buckets {
+		points = append(points, b.min)
+		if b.max != b.min {
+			points = append(points, b.max)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].t.Before(points[j].t) })
+	return points
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// chart is an SVG line chart, laid out in pixels
+type chart struct {
+	XLabel, YLabel string
+	XTicks, YTicks []chartTick
+	Series         []chartSeries
+	// Axes map the plot area back to data for the cursor readout
+	X, Y chartAxis
+	// Percentiles is set if the x axis holds 1/(1-percentile)
+	Percentiles bool
+
+	Width, Height            float64
+	Left, Right, Top, Bottom float64
+}
+
+type chartTick struct {
+	Pos   float64
+	Label string
+}
+
+type chartSeries struct {
+	Label  string
+	Color  string
+	Points string
+}
+
+// chartAxis maps values between min and max to the pixels from and to, on a
+// logarithmic scale if log is set
+type chartAxis struct {
+	Min, Max float64
+	From, To float64
+	Log      bool
+}
+
+func (a chartAxis) pos(v float64) float64 {
+	min, max := a.Min, a.Max
+	if a.Log {
+		v, min, max = math.Log10(v), math.Log10(min), math.Log10(max)
+	}
+	if max == min {
+		return (a.From + a.To) / 2
+	}
+	return a.From + (v-min)/(max-min)*(a.To-a.From)
+}
+
+func newChart(xLabel, yLabel string) chart {
+	return chart{
+		XLabel: xLabel,
+		YLabel: yLabel,
+		Width:  chartWidth,
+		Height: chartHeight,
+		Left:   chartMarginLeft,
+		Right:  chartWidth - chartMarginX,
+		Top:    chartMarginTop,
+		Bottom: chartHeight - chartMarginY,
+	}
+}
+
+// points lays out the points of a series
+func (c chart) points(points []lttb.Point) string {
+	var b strings.Builder
+	for i, p := range points {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%.1f,%.1f", c.X.pos(p.X), c.Y.pos(p.Y))
+	}
+	return b.String()
+}
+
+// latencyChart plots the latency of the results over the seconds elapsed
+// since the earliest result, on a logarithmic scale
+func latencyChart(plot *latencyPlot) chart {
+	c := newChart("Seconds elapsed", "Latency (ms)")
+
+	keys := make([]plotKey, 0, len(plot.series))
+	series := make(map[plotKey][]plotPoint, len(plot.series))
+	names := make(map[string]bool)
+	var earliest time.Time
+	minMs, maxMs, maxX := math.Inf(1), 0.0, 0.0
+	for key, s := range plot.series {
+		keys = append(keys, key)
+		names[key.name] = true
+		points := s.points()
+		series[key] = points
+		if earliest.IsZero() || points[0].t.Before(earliest) {
+			earliest = points[0].t
+		}
+		for _, p := range points {
+			if p.ms > 0 {
+				minMs = math.Min(minMs, p.ms)
+			}
+			maxMs = math.Max(maxMs, p.ms)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].failed != keys[j].failed {
+			return !keys[i].failed
+		}
+		return keys[i].name < keys[j].name
+	})
+	if len(keys) == 0 {
+		minMs, maxMs = 1, 1
+	}
+
+	// Whole decades, so that every tick is a power of ten
+	lo := math.Pow(10, math.Floor(math.Log10(math.Max(minMs, 1e-3))))
+	hi := math.Pow(10, math.Ceil(math.Log10(math.Max(maxMs, lo))))
+	if hi <= lo {
+		hi = lo * 10
+	}
+	c.Y = chartAxis{Min: lo, Max: hi, From: c.Bottom, To: c.Top, Log: true}
+	for v := lo; v <= hi*1.001; v *= 10 {
+		c.YTicks = append(c.YTicks, chartTick{c.Y.pos(v), formatMs(v)})
+	}
+
+	laidOut := make([][]lttb.Point, len(keys))
+	for i, key := range keys {
+		points := series[key]
+		laidOut[i] = make([]lttb.Point, len(points))
+		for j, p := range points {
+			laidOut[i][j] = lttb.Point{X: p.t.Sub(earliest).Seconds(), Y: math.Min(math.Max(p.ms, lo), hi)}
+		}
+		maxX = math.Max(maxX, laidOut[i][len(points)-1].X)
+	}
+
+	xTicks := linearTicks(maxX)
+	c.X = chartAxis{Min: 0, Max: xTicks[len(xTicks)-1], From: c.Left, To: c.Right}
+	for _, v := range xTicks {
+		c.XTicks = append(c.XTicks, chartTick{c.X.pos(v), strconv.FormatFloat(v, 'f', -1, 64)})
+	}
+
+	var ok, failed int
+	for i, key := range keys {
+		label, color := "OK", ""
+		if key.failed {
+			label, color = "ERROR", errorColors[failed%len(errorColors)]
+			failed++
+		} else {
+			color = okColors[ok%len(okColors)]
+			ok++
+		}
+		if len(names) > 1 {
+			label = key.name + " " + label
+		}
+		c.Series = append(c.Series, chartSeries{label, color, c.points(laidOut[i])})
+	}
+	return c
+}
+
+// percentileChart plots the latency percentiles of the attack, and of each of
+// its targets or steps, on a logarithmic scale of 1/(1-percentile) like the
+// HdrHistogram plotter
+func percentileChart(all *vegeta.Metrics, breakdown []stepMetrics) chart {
+	c := newChart("Percentile", "Latency (ms)")
+	c.Percentiles = true
+
+	curves := []stepMetrics{{"All", all}}
+	curves = append(curves, breakdown...)
+
+	quantiles := hdrQuantiles(all.Requests)
+	maxX := 1 / (1 - quantiles[len(quantiles)-1])
+	maxMs := 0.0
+	for _, curve := range curves {
+		maxMs = math.Max(maxMs, milliseconds(curve.metrics.Latencies.Max))
+	}
+
+	c.X = chartAxis{Min: 1, Max: math.Max(maxX, 10), From: c.Left, To: c.Right, Log: true}
+	for nines := 0.0; math.Pow(10, nines) <= c.X.Max*1.001; nines++ {
+		label := strconv.FormatFloat(100-100/math.Pow(10, nines), 'f', -1, 64) + "%"
+		c.XTicks = append(c.XTicks, chartTick{c.X.pos(math.Pow(10, nines)), label})
+	}
+	ticks := linearTicks(maxMs)
+	c.Y = chartAxis{Min: 0, Max: ticks[len(ticks)-1], From: c.Bottom, To: c.Top}
+	for _, v := range ticks {
+		c.YTicks = append(c.YTicks, chartTick{c.Y.pos(v), formatMs(v)})
+	}
+
+	for i, curve := range curves {
+		if curve.metrics.Requests == 0 {
+			continue
+		}
+		points := make([]lttb.Point, 0, len(quantiles))
+		for _, q := range quantiles {
+			points = append(points, lttb.Point{X: 1 / (1 - q), Y: milliseconds(curve.metrics.Latencies.Quantile(q))})
+		}
+		c.Series = append(c.Series, chartSeries{curve.name, curveColors[i%len(curveColors)], c.points(points)})
+	}
+	return c
+}
+
+// hdrQuantiles returns the quantiles of the percentile curve, ten per nine,
+// up to the resolution of the number of requests
+func hdrQuantiles(requests uint64) []float64 {
+	quantiles := []float64{0}
+	for i := 1; i <= 60; i++ {
+		q := 1 - math.Pow(10, -float64(i)/10)
+		if 1/(1-q) > math.Max(float64(requests), 10)*1.001 {
+			break
+		}
+		quantiles = append(quantiles, q)
+	}
+	return quantiles
+}
+
+// linearTicks returns about five evenly spaced round ticks from 0 to at
+// least max
+func linearTicks(max float64) []float64 {
+	if max <= 0 {
+		return []float64{0, 1}
+	}
+	step := math.Pow(10, math.Floor(math.Log10(max/5)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if max/(step*m) <= 6 {
+			step *= m
+			break
+		}
+	}
+	ticks := []float64{0}
+	for v := step; ticks[len(ticks)-1] < max; v += step {
+		ticks = append(ticks, math.Round(v/step)*step)
+	}
+	return ticks
+}
+
+// formatMs formats a tick in milliseconds
+func formatMs(ms float64) string {
+	return strconv.FormatFloat(ms, 'g', 4, 64)
+}
+
+// milliseconds returns the duration in fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// htmlReport is the data of the HTML report template
+type htmlReport struct {
+	ID          string
+	Description string
+	Labels      string
+	Metrics     *vegeta.Metrics
+	StatusCodes []statusCount
+	// Breakdown names the rows, Targets or Steps
+	Breakdown   string
+	Rows        []htmlRow
+	Latency     chart
+	Percentiles chart
+}
+
+type statusCount struct {
+	Code  string
+	Count int
+}
+
+type htmlRow struct {
+	Name    string
+	Share   float64
+	Metrics *vegeta.Metrics
+}
+
+// writeHTMLReport writes a self-contained HTML report, with the summary of
+// the metrics, their breakdown by step or target, the latency of the results
+// over time and the latency percentiles
+func writeHTMLReport(w io.Writer, id string, meta MetaInfo, m *vegeta.Metrics, plot *latencyPlot,
+	steps, targets []stepMetrics) error {
+	report := htmlReport{
+		ID:          id,
+		Description: meta["description"],
+		Labels:      meta["labels"],
+		Metrics:     m,
+		Latency:     latencyChart(plot),
+	}
+
+	for code, count := range m.StatusCodes {
+		report.StatusCodes = append(report.StatusCodes, statusCount{code, count})
+	}
+	sort.Slice(report.StatusCodes, func(i, j int) bool {
+		return report.StatusCodes[i].Code < report.StatusCodes[j].Code
+	})
+
+	breakdown := steps
+	report.Breakdown = "Steps"
+	if len(targets) > 0 {
+		breakdown, report.Breakdown = targets, "Targets"
+	}
+	for _, row := range breakdown {
+		report.Rows = append(report.Rows, htmlRow{row.name, share(row.metrics, m), row.metrics})
+	}
+	report.Percentiles = percentileChart(m, breakdown)
+
+	return errors.Wrap(htmlTemplate.Execute(w, report), "failed to render HTML report")
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Microsecond).String()
+	},
+	"percent": func(f float64) string {
+		return strconv.FormatFloat(100*f, 'f', 2, 64) + "%"
+	},
+	"float": func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 2, 64)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Attack {{.ID}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 1000px; color: #222; }
+h1 { font-size: 1.4em; word-break: break-all; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { padding: .3em .8em; border-bottom: 1px solid #ddd; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.meta { color: #555; }
+.chart { position: relative; }
+.chart svg { font-size: 11px; }
+.chart .grid { stroke: #eee; }
+.chart .frame { stroke: #999; fill: none; }
+.chart polyline { fill: none; stroke-width: 1.3; }
+.chart .readout { position: absolute; top: 0; right: 16px; color: #555; font-size: 12px; }
+.legend span { cursor: pointer; margin-right: 1.2em; user-select: none; }
+.legend span.off { opacity: .35; }
+.legend i { display: inline-block; width: 12px; height: 3px; margin: 0 .4em 3px 0; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>Attack {{.ID}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .Labels}}<p class="meta">Labels {{.Labels}}</p>{{end}}
+
+<h2>Summary</h2>
+<table>
+<tr><th>Requests</th><td>{{.Metrics.Requests}}</td><th>Rate</th><td>{{float .Metrics.Rate}}/s</td><th>Throughput</th><td>{{float .Metrics.Throughput}}/s</td></tr>
+<tr><th>Duration</th><td>{{duration .Metrics.Duration}}</td><th>Wait</th><td>{{duration .Metrics.Wait}}</td><th>Success</th><td>{{percent .Metrics.Success}}</td></tr>
+<tr><th>Bytes in</th><td>{{.Metrics.BytesIn.Total}}</td><th>Mean</th><td>{{float .Metrics.BytesIn.Mean}}</td><td></td><td></td></tr>
+<tr><th>Bytes out</th><td>{{.Metrics.BytesOut.Total}}</td><th>Mean</th><td>{{float .Metrics.BytesOut.Mean}}</td><td></td><td></td></tr>
+</table>
+
+<h2>Latencies</h2>
+<table>
+<tr><th>Mean</th><th>50th</th><th>95th</th><th>99th</th><th>Max</th></tr>
+<tr><td>{{duration .Metrics.Latencies.Mean}}</td><td>{{duration .Metrics.Latencies.P50}}</td><td>{{duration .Metrics.Latencies.P95}}</td><td>{{duration .Metrics.Latencies.P99}}</td><td>{{duration .Metrics.Latencies.Max}}</td></tr>
+</table>
+
+<h2>Status codes</h2>
+<table>
+<tr><th>Code</th><th>Count</th></tr>
+{{range .StatusCodes}}<tr><td>{{.Code}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{if .Metrics.Errors}}
+<h2>Errors</h2>
+<ul>
+{{range .Metrics.Errors}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}
+{{if .Rows}}
+<h2>{{.Breakdown}}</h2>
+<table>
+<tr><th>Name</th><th>Requests</th><th>Share</th><th>Success</th><th>Mean</th><th>50th</th><th>95th</th><th>99th</th><th>Max</th></tr>
+{{range .Rows}}<tr><td>{{.Name}}</td><td>{{.Metrics.Requests}}</td><td>{{percent .Share}}</td><td>{{percent .Metrics.Success}}</td><td>{{duration .Metrics.Latencies.Mean}}</td><td>{{duration .Metrics.Latencies.P50}}</td><td>{{duration .Metrics.Latencies.P95}}</td><td>{{duration .Metrics.Latencies.P99}}</td><td>{{duration .Metrics.Latencies.Max}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Latency over time</h2>
+{{template "chart" .Latency}}
+
+<h2>Latency percentiles</h2>
+{{template "chart" .Percentiles}}
+
+<script>
+document.querySelectorAll(".chart").forEach(function (chart) {
+  var svg = chart.querySelector("svg"), readout = chart.querySelector(".readout");
+  chart.querySelectorAll(".legend span").forEach(function (item, i) {
+    item.addEventListener("click", function () {
+      item.classList.toggle("off");
+      svg.querySelectorAll("polyline")[i].classList.toggle("hidden");
+    });
+  });
+  function value(axis, px) {
+    var from = +svg.dataset[axis + "From"], to = +svg.dataset[axis + "To"];
+    var min = +svg.dataset[axis + "Min"], max = +svg.dataset[axis + "Max"], log = svg.dataset[axis + "Log"] === "true";
+    if (log) { min = Math.log10(min); max = Math.log10(max); }
+    var v = min + (px - from) / (to - from) * (max - min);
+    return log ? Math.pow(10, v) : v;
+  }
+  svg.addEventListener("mousemove", function (e) {
+    var box = svg.getBoundingClientRect(), scale = +svg.getAttribute("width") / box.width;
+    var x = value("x", (e.clientX - box.left) * scale), y = value("y", (e.clientY - box.top) * scale);
+    if (svg.dataset.percentiles === "true") {
+      x = (100 - 100 / x).toPrecision(6) + "%";
+    } else {
+      x = x.toFixed(3) + "s";
+    }
+    readout.textContent = x + ", " + y.toPrecision(4) + "ms";
+  });
+  svg.addEventListener("mouseleave", function () { readout.textContent = ""; });
+});
+</script>
+</body>
+</html>
+{{define "chart"}}<div class="chart">
+<div class="readout"></div>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" style="max-width: 100%; height: auto"
+ data-x-min="{{.X.Min}}" data-x-max="{{.X.Max}}" data-x-from="{{.X.From}}" data-x-to="{{.X.To}}" data-x-log="{{.X.Log}}"
+ data-y-min="{{.Y.Min}}" data-y-max="{{.Y.Max}}" data-y-from="{{.Y.From}}" data-y-to="{{.Y.To}}" data-y-log="{{.Y.Log}}"
+ data-percentiles="{{.Percentiles}}">
+{{$c := .}}{{range .XTicks}}<line class="grid" x1="{{.Pos}}" x2="{{.Pos}}" y1="{{$c.Top}}" y2="{{$c.Bottom}}"/><text x="{{.Pos}}" y="{{$c.Bottom}}" dy="16" text-anchor="middle">{{.Label}}</text>
+{{end}}{{range .YTicks}}<line class="grid" x1="{{$c.Left}}" x2="{{$c.Right}}" y1="{{.Pos}}" y2="{{.Pos}}"/><text x="{{$c.Left}}" y="{{.Pos}}" dx="-6" dy="4" text-anchor="end">{{.Label}}</text>
+{{end}}<rect class="frame" x="{{.Left}}" y="{{.Top}}" width="{{.PlotWidth}}" height="{{.PlotHeight}}"/>
+<text x="{{.Right}}" y="{{.Height}}" dy="-4" text-anchor="end">{{.XLabel}}</text>
+<text x="14" y="{{.Top}}" transform="rotate(-90 14 {{.Top}})" text-anchor="end">{{.YLabel}}</text>
+{{range .Series}}<polyline stroke="{{.Color}}" points="{{.Points}}"><title>{{.Label}}</title></polyline>
+{{end}}</svg>
+<div class="legend">{{range .Series}}<span><i style="background: {{.Color}}"></i>{{.Label}}</span>{{end}}</div>
+</div>{{end}}`))
+
+// PlotWidth is the width of the plot area
+func (c chart) PlotWidth() float64 {
+	return c.Right - c.Left
+}
+
+// PlotHeight is the height of the plot area
+func (c chart) PlotHeight() float64 {
+	return c.Bottom - c.Top
+}
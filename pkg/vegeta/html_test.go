@@ -0,0 +1,129 @@
+package vegeta
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestCreateReportFromReader_HTML(t *testing.T) {
+	var in bytes.Buffer
+	enc := vegeta.NewEncoder(&in)
+	began := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for seq := uint64(0); seq < 5000; seq++ {
+		r := vegeta.Result{
+			Attack:    "reads",
+			Seq:       seq,
+			Code:      200,
+			Timestamp: began.Add(time.Duration(seq) * time.Millisecond),
+			Latency:   time.Duration(1+seq%50) * time.Millisecond,
+		}
+		if seq%10 == 0 {
+			r.Attack = "writes"
+		}
+		if seq%100 == 0 {
+			r.Code, r.Error = 500, "500 Internal Server Error"
+		}
+		if err := enc.Encode(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	format := NewHTMLFormat()
+	format.SetMeta("description", "<nightly>")
+	format.SetMeta("labels", "service=checkout")
+	report, err := CreateReportFromReader(&in, "id", format)
+	if err != nil {
+		t.Fatalf("CreateReportFromReader() error = %v", err)
+	}
+	html := string(report)
+
+	for _, want := range []string{
+		"<title>Attack id</title>",
+		"&lt;nightly&gt;",
+		"Labels service=checkout",
+		"<h2>Targets</h2>",
+		"<td>writes</td><td>500</td><td>10.00%</td>",
+		"<li>500 Internal Server Error</li>",
+		"<title>reads OK</title>",
+		"<title>writes ERROR</title>",
+		"<title>All</title>",
+		"99.9%",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("CreateReportFromReader() HTML is missing %q", want)
+		}
+	}
+
+	// The report is self-contained
+	for _, external := range []string{"src=", "href=", "http://", "https://"} {
+		if strings.Contains(html, external) {
+			t.Errorf("CreateReportFromReader() HTML references %q", external)
+		}
+	}
+
+	// The series are downsampled
+	for _, points := range strings.Split(html, `points="`)[1:] {
+		if n := strings.Count(points[:strings.IndexByte(points, '"')], " ") + 1; n > maxPlotPoints {
+			t.Errorf("series has %d points, want at most %d", n, maxPlotPoints)
+		}
+	}
+}
+
+func TestLatencyPlot_Add(t *testing.T) {
+	plot := newLatencyPlot()
+	began := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 100000; i > 0; i-- {
+		latency := time.Millisecond
+		if i == 54321 {
+			latency = time.Second
+		}
+		plot.Add(&vegeta.Result{Attack: "reads", Timestamp: began.Add(time.Duration(i) * time.Millisecond), Latency: latency})
+	}
+
+	// Buckets are merged as results come in, keeping the spike
+	s := plot.series[plotKey{"reads", false}]
+	if len(s.buckets) > maxPlotPoints/2 {
+		t.Errorf("series has %d buckets, want at most %d", len(s.buckets), maxPlotPoints/2)
+	}
+	points := s.points()
+	var spike bool
+	for i, p := range points {
+		if i > 0 && p.t.Before(points[i-1].t) {
+			t.Fatalf("points are not in time order at %d", i)
+		}
+		spike = spike || p.ms == 1000
+	}
+	if !spike {
+		t.Error("series lost the latency spike")
+	}
+}
+
+func Test_linearTicks(t *testing.T) {
+	tests := []struct {
+		max  float64
+		want []float64
+	}{
+		{0, []float64{0, 1}},
+		{4.2, []float64{0, 1, 2, 3, 4, 5}},
+		{50, []float64{0, 10, 20, 30, 40, 50}},
+		{130, []float64{0, 50, 100, 150}},
+	}
+	for _, tt := range tests {
+		if got := linearTicks(tt.max); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("linearTicks(%v) = %v, want %v", tt.max, got, tt.want)
+		}
+	}
+}
+
+func Test_hdrQuantiles(t *testing.T) {
+	// Up to the 99th percentile for 100 requests
+	got := hdrQuantiles(100)
+	if got[0] != 0 || len(got) != 21 || 1/(1-got[len(got)-1]) > 100.1 {
+		t.Errorf("hdrQuantiles(100) = %v", got)
+	}
+}
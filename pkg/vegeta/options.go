@@ -2,6 +2,8 @@ package vegeta
 
 import (
 	"encoding/base64"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"strings"
@@ -14,20 +16,39 @@ import (
 	vegeta "github.com/tsenart/vegeta/lib"
 )
 
+// DefaultMaxWorkers caps the workers of attacks which set no max workers, so
+// a slow target cannot make them open connections without bounds
+const DefaultMaxWorkers = 1000
+
 // AttackOpts aggregates the attack function command options
 type AttackOpts struct {
-	Target      []vegeta.Target
-	Name        string
-	Cert        string
-	Key         string
-	RootCerts   []string
-	HTTP2       bool
-	H2c         bool
-	Insecure    bool
-	Duration    time.Duration
-	Timeout     time.Duration
-	Rate        vegeta.Rate
-	Workers     uint64
+	Target        []vegeta.Target
+	TargetNames   []string
+	TargetWeights []int
+	TargetAsserts [][]models.Assertion
+	Scenario      *models.Scenario
+	Feeder        *models.FeederParams
+	Feed          models.Feed
+	TargetFormat  string
+	OpenTargets   func() (io.ReadCloser, error)
+	Replay        *models.ReplayParams
+	OpenLog       func() (io.ReadCloser, error)
+	Samples       *models.SampleParams
+	SampleWriter  io.Writer
+	Name          string
+	Cert          string
+	Key           string
+	RootCerts     []string
+	HTTP2         bool
+	H2c           bool
+	Insecure      bool
+	Duration      time.Duration
+	Timeout       time.Duration
+	Rate          vegeta.Rate
+	Workers       uint64
+	// MaxWorkers caps the workers started when all of them are busy,
+	// DefaultMaxWorkers if unset
+	MaxWorkers  uint64
 	Connections int
 	Redirects   int
 	MaxBody     int64
@@ -59,6 +80,73 @@ func NewAttackOptsFromAttackParams(name string, params models.AttackParams) (*At
 	}
 
 	// Set Target
+	tgt, err := targetsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(params.Target))
+	weights := make([]int, len(params.Target))
+	asserts := make([][]models.Assertion, len(params.Target))
+	for i, t := range params.Target {
+		names[i], weights[i], asserts[i] = t.ReportName(), t.Weight, t.Assert
+	}
+
+	opts := &AttackOpts{
+		Name:          name,
+		Target:        tgt,
+		TargetNames:   names,
+		TargetWeights: weights,
+		TargetAsserts: asserts,
+		Scenario:      params.Scenario,
+		Replay:        params.Replay,
+		Feeder:        params.Feeder,
+		Samples:       params.Samples,
+		Duration:      dur,
+		Timeout:       timeout,
+		Rate:          rate,
+		Redirects:     int(params.Redirects),
+		MaxBody:       params.MaxBody,
+		Keepalive:     params.Keepalive,
+		Resolvers:     resolvers,
+		Laddr:         struct{ *net.IPAddr }{laddr},
+		Cert:          params.Cert,
+		Key:           params.Key,
+		RootCerts:     params.RootCerts,
+		Insecure:      params.Insecure,
+		HTTP2:         params.HTTP2,
+		H2c:           params.H2c,
+		Workers:       uint64(params.Workers),
+	}
+
+	if params.MaxWorkers > 0 {
+		opts.MaxWorkers = uint64(params.MaxWorkers)
+	}
+
+	// Targets given inline in one of vegeta's formats are read like uploads
+	if f := params.TargetFile; f != nil {
+		opts.TargetFormat = f.FormatOrDefault()
+		if f.Data != "" {
+			data := f.Data
+			opts.OpenTargets = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader(data)), nil
+			}
+		}
+	}
+
+	// Access logs given inline are read like uploads too
+	if r := params.Replay; r != nil && r.Data != "" {
+		data := r.Data
+		opts.OpenLog = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(data)), nil
+		}
+	}
+
+	return opts, nil
+}
+
+// targetsFromParams adapts the models targets to vegeta targets
+func targetsFromParams(params models.AttackParams) ([]vegeta.Target, error) {
 	tgt := make([]vegeta.Target, len(params.Target))
 
 	for index, element := range params.Target {
@@ -83,25 +171,5 @@ func NewAttackOptsFromAttackParams(name string, params models.AttackParams) (*At
 		}
 	}
 
-	opts := &AttackOpts{
-		Name:      name,
-		Target:    tgt,
-		Duration:  dur,
-		Timeout:   timeout,
-		Rate:      rate,
-		Redirects: int(params.Redirects),
-		MaxBody:   params.MaxBody,
-		Keepalive: params.Keepalive,
-		Resolvers: resolvers,
-		Laddr:     struct{ *net.IPAddr }{laddr},
-		Cert:      params.Cert,
-		Key:       params.Key,
-		RootCerts: params.RootCerts,
-		Insecure:  params.Insecure,
-		HTTP2:     params.HTTP2,
-		H2c:       params.H2c,
-		Workers:   uint64(params.Workers),
-	}
-
-	return opts, nil
+	return tgt, nil
 }
@@ -0,0 +1,465 @@
+package vegeta
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// maxLogLine bounds the length of access log lines
+const maxLogLine = 1024 * 1024
+
+// combinedLine matches the remote address, user, time and request line of
+// combined and common log format lines. Quotes in the request line are
+// escaped by a backslash in Apache logs, nginx logs escape them as \x22.
+var combinedLine = regexp.MustCompile(`^\S+ \S+ .*?\[([^\]]+)\] "((?:[^"\\]|\\.)*)"`)
+
+// combinedTime is the layout of times in combined log format lines
+const combinedTime = "02/Jan/2006:15:04:05 -0700"
+
+// jsonLogTimeLayouts are tried in turn on the time fields of json logs
+var jsonLogTimeLayouts = []string{time.RFC3339Nano, combinedTime, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"}
+
+// Usual names of the fields of json logs, the first one found being used.
+// Dots refer to nested fields.
+var (
+	jsonLogTimeFields    = []string{"time", "timestamp", "@timestamp", "time_iso8601", "time_local", "ts"}
+	jsonLogMethodFields  = []string{"method", "request_method", "verb", "http.request.method"}
+	jsonLogPathFields    = []string{"path", "request_uri", "uri", "url", "url.original"}
+	jsonLogRequestFields = []string{"request"}
+)
+
+// replayEntry is a request read from an access log, or the error reading
+// the log failed with
+type replayEntry struct {
+	time   time.Time
+	method string
+	path   string
+	err    error
+}
+
+// parseRequestLine returns the method and path of a request line, e.g.
+// "GET /items?page=2 HTTP/1.1"
+func parseRequestLine(line string) (string, string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return "", "", false
+	}
+	return fields[0], fields[1], true
+}
+
+// newReplayEntry checks the logged method and path, turning absolute URLs,
+// as logged by proxies, into paths
+func newReplayEntry(t time.Time, method, path string) (replayEntry, bool) {
+	for _, c := range method {
+		if c < 'A' || c > 'Z' {
+			return replayEntry{}, false
+		}
+	}
+	if method == "" {
+		return replayEntry{}, false
+	}
+	if !strings.HasPrefix(path, "/") {
+		u, err := url.Parse(path)
+		if err != nil || u.Host == "" {
+			// e.g. * of OPTIONS, or host:port of CONNECT
+			return replayEntry{}, false
+		}
+		path = u.RequestURI()
+	}
+	return replayEntry{time: t, method: method, path: path}, true
+}
+
+// parseCombinedLine reads the time and request of a combined log format line
+func parseCombinedLine(line []byte) (replayEntry, bool) {
+	m := combinedLine.FindSubmatch(line)
+	if m == nil {
+		return replayEntry{}, false
+	}
+	method, path, ok := parseRequestLine(string(m[2]))
+	if !ok {
+		return replayEntry{}, false
+	}
+	t, err := time.Parse(combinedTime, string(m[1]))
+	if err != nil {
+		return replayEntry{}, false
+	}
+	return newReplayEntry(t, method, path)
+}
+
+// jsonLogParser returns a parser of json log lines, reading the fields
+// named by fields or else the first of the usual fields found
+func jsonLogParser(fields *models.ReplayFields) func([]byte) (replayEntry, bool) {
+	names := func(name string, usual []string) []string {
+		if name != "" {
+			return []string{name}
+		}
+		return usual
+	}
+	var f models.ReplayFields
+	if fields != nil {
+		f = *fields
+	}
+	timeFields := names(f.Time, jsonLogTimeFields)
+	methodFields := names(f.Method, jsonLogMethodFields)
+	pathFields := names(f.Path, jsonLogPathFields)
+	requestFields := names(f.Request, jsonLogRequestFields)
+
+	return func(line []byte) (replayEntry, bool) {
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return replayEntry{}, false
+		}
+
+		method, _ := lookupJSONField(record, methodFields).(string)
+		path, _ := lookupJSONField(record, pathFields).(string)
+		if method == "" || path == "" {
+			request, _ := lookupJSONField(record, requestFields).(string)
+			var ok bool
+			if method, path, ok = parseRequestLine(request); !ok {
+				return replayEntry{}, false
+			}
+		}
+		return newReplayEntry(parseJSONLogTime(lookupJSONField(record, timeFields)), method, path)
+	}
+}
+
+// lookupJSONField returns the value of the first of the fields found in
+// the record, following dots into nested objects. Objects are not values,
+// e.g. url in {"url": {"original": "/"}}.
+func lookupJSONField(record map[string]interface{}, fields []string) interface{} {
+	for _, field := range fields {
+		if v, ok := record[field]; ok && !isJSONObject(v) {
+			return v
+		}
+		var v interface{} = record
+		for _, name := range strings.Split(field, ".") {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				v = nil
+				break
+			}
+			v = obj[name]
+		}
+		if v != nil && !isJSONObject(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// isJSONObject reports whether v was decoded from a JSON object
+func isJSONObject(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
+
+// parseJSONLogTime reads a time in one of the usual layouts, or as seconds,
+// or milliseconds, since the epoch. It returns the zero time otherwise.
+func parseJSONLogTime(v interface{}) time.Time {
+	switch v := v.(type) {
+	case string:
+		for _, layout := range jsonLogTimeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return parseJSONLogTime(f)
+		}
+	case float64:
+		if v > 1e11 {
+			v /= 1000
+		}
+		sec := int64(v)
+		return time.Unix(sec, int64((v-float64(sec))*1e9))
+	}
+	return time.Time{}
+}
+
+// replayReader reads the requests of an access log one line at a time,
+// skipping lines that are not requests, e.g. garbage sent to the server.
+// Lines without a time are skipped too if timed is set.
+type replayReader struct {
+	sc      *bufio.Scanner
+	parse   func([]byte) (replayEntry, bool)
+	timed   bool
+	skipped int
+}
+
+// newReplayReader returns a reader of the access log of the replay
+func newReplayReader(p models.ReplayParams, r io.Reader) *replayReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxLogLine)
+
+	parse := parseCombinedLine
+	if p.FormatOrDefault() == models.ReplayFormatJSON {
+		parse = jsonLogParser(p.Fields)
+	}
+	return &replayReader{sc: sc, parse: parse, timed: p.ModeOrDefault() == models.ReplayModeTiming}
+}
+
+// next returns the next request of the log, or io.EOF at its end
+func (r *replayReader) next() (replayEntry, error) {
+	for r.sc.Scan() {
+		line := bytes.TrimSpace(r.sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		e, ok := r.parse(line)
+		if !ok || r.timed && e.time.IsZero() {
+			r.skipped++
+			continue
+		}
+		return e, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return replayEntry{}, errors.Wrap(err, "failed to read access log")
+	}
+	return replayEntry{}, io.EOF
+}
+
+// ValidateReplayLog reads all requests of the access log of the replay from
+// r, returning their number and the number of skipped lines
+func ValidateReplayLog(p models.ReplayParams, r io.Reader) (int, int, error) {
+	rd := newReplayReader(p, r)
+
+	n := 0
+	for {
+		_, err := rd.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, rd.skipped, err
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, rd.skipped, fmt.Errorf("no requests found in the %s access log", p.FormatOrDefault())
+	}
+	return n, rd.skipped, nil
+}
+
+// replaySource reads the requests of the access log opened by open. In
+// pool mode it opens the log anew whenever all of its requests were read.
+type replaySource struct {
+	mu     sync.Mutex
+	params models.ReplayParams
+	open   func() (io.ReadCloser, error)
+	loop   bool
+	rc     io.ReadCloser
+	rd     *replayReader
+	read   int
+	closed bool
+}
+
+// next returns the next request of the log, or io.EOF at its end
+func (s *replaySource) next() (replayEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for !s.closed {
+		if s.rd == nil {
+			rc, err := s.open()
+			if err != nil {
+				return replayEntry{}, errors.Wrap(err, "failed to open access log")
+			}
+			s.rc, s.rd, s.read = rc, newReplayReader(s.params, rc), 0
+		}
+
+		e, err := s.rd.next()
+		if err == io.EOF {
+			empty := s.read == 0
+			s.rewind()
+			if !s.loop || empty {
+				s.closed = true
+			}
+			continue
+		}
+		if err != nil {
+			return replayEntry{}, err
+		}
+		s.read++
+		return e, nil
+	}
+	return replayEntry{}, io.EOF
+}
+
+// rewind closes the log, to be opened again by the next call to next
+func (s *replaySource) rewind() {
+	if s.rc != nil {
+		s.rc.Close() // nolint: errcheck
+	}
+	s.rc, s.rd = nil, nil
+}
+
+// close the log, ending the requests
+func (s *replaySource) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rewind()
+	s.closed = true
+}
+
+// replayPacer paces the requests of an access log at their recorded times,
+// relative to the first one and divided by the speed. It reads the request
+// of every hit, queueing it for the worker the hit is handed to, and stops
+// at the end of the log.
+type replayPacer struct {
+	src   *replaySource
+	speed float64
+
+	mu      sync.Mutex
+	first   time.Time
+	pending []replayEntry
+}
+
+// Pace implements vegeta.Pacer
+func (p *replayPacer) Pace(elapsed time.Duration, _ uint64) (time.Duration, bool) {
+	e, err := p.src.next()
+	if err == io.EOF {
+		return 0, true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		// Handed to a worker to end the attack with the error
+		p.pending = append(p.pending, replayEntry{err: err})
+		return 0, false
+	}
+	if p.first.IsZero() {
+		p.first = e.time
+	}
+	p.pending = append(p.pending, e)
+
+	if offset := time.Duration(float64(e.time.Sub(p.first)) / p.speed); offset > elapsed {
+		return offset - elapsed, false
+	}
+	// Logs are written as responses complete, so requests may be out of order
+	return 0, false
+}
+
+// pop returns the oldest request queued for a hit
+func (p *replayPacer) pop() replayEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.pending) == 0 {
+		return replayEntry{err: vegeta.ErrNoTargets}
+	}
+	e := p.pending[0]
+	p.pending = p.pending[1:]
+	return e
+}
+
+// replayAttacker sends the requests of an access log to the base URL of
+// the replay, naming results after the attack
+type replayAttacker struct {
+	*httpAttacker
+	name    string
+	baseURL string
+	header  http.Header
+	pacer   vegeta.Pacer
+	next    func() replayEntry
+}
+
+// newReplayAttacker returns an attacker replaying the access log of the
+// options in the mode of their replay
+func newReplayAttacker(opts *AttackOpts, c *tls.Config) (*replayAttacker, error) {
+	if opts.OpenLog == nil {
+		return nil, fmt.Errorf("replay has no access log")
+	}
+	r := *opts.Replay
+
+	header := make(http.Header)
+	for _, h := range r.Headers {
+		header.Add(h.Key, h.Value)
+	}
+
+	a := &replayAttacker{
+		httpAttacker: newHTTPAttacker(opts, c),
+		name:         opts.Name,
+		baseURL:      strings.TrimSuffix(r.BaseURL, "/"),
+		header:       header,
+	}
+
+	src := &replaySource{params: r, open: opts.OpenLog, loop: r.ModeOrDefault() == models.ReplayModePool}
+	go func() {
+		<-a.ctx.Done()
+		src.close()
+	}()
+
+	if src.loop {
+		a.next = func() replayEntry {
+			e, err := src.next()
+			if err == io.EOF {
+				err = vegeta.ErrNoTargets
+			}
+			if err != nil {
+				return replayEntry{err: err}
+			}
+			return e
+		}
+		return a, nil
+	}
+
+	p := &replayPacer{src: src, speed: r.SpeedOrDefault()}
+	a.pacer, a.next = p, p.pop
+	return a, nil
+}
+
+// Attack replays the log for the given duration, or until its end in
+// timing mode. The pacer only applies to pool mode, timing mode following
+// the times of the log.
+func (a *replayAttacker) Attack(p vegeta.Pacer, du time.Duration) <-chan *vegeta.Result {
+	if a.pacer != nil {
+		p = a.pacer
+	}
+	return a.attack(p, du, a.iterate)
+}
+
+// iterate sends the next request of the log. It stops the attack if the log
+// cannot be read.
+func (a *replayAttacker) iterate(results chan<- *vegeta.Result) {
+	e := a.next()
+
+	res, req, r, body := a.hit(&a.client, a.name, func(ctx context.Context) (*http.Request, error) {
+		if e.err != nil {
+			return nil, e.err
+		}
+		req, err := http.NewRequest(e.method, a.baseURL+e.path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = a.header.Clone()
+		if host := req.Header.Get("Host"); host != "" {
+			req.Host = host
+		}
+		return req.WithContext(ctx), nil
+	}, false)
+	a.samples.sample(res, req, r, body)
+
+	a.send(results, res)
+	if e.err != nil {
+		a.Stop()
+	}
+}
@@ -0,0 +1,227 @@
+package vegeta
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestParseCombinedLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		want   replayEntry
+		wantOK bool
+	}{
+		{
+			name:   "Combined",
+			line:   `10.0.0.1 - frank [10/Oct/2020:13:55:36 -0700] "GET /items?page=2 HTTP/1.1" 200 2326 "http://example.com/" "Mozilla/5.0"`,
+			want:   replayEntry{time: time.Date(2020, 10, 10, 20, 55, 36, 0, time.UTC), method: "GET", path: "/items?page=2"},
+			wantOK: true,
+		},
+		{
+			name:   "Common, absolute URL",
+			line:   `10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "POST http://example.com/orders HTTP/1.0" 201 12`,
+			want:   replayEntry{time: time.Date(2020, 10, 10, 13, 55, 36, 0, time.UTC), method: "POST", path: "/orders"},
+			wantOK: true,
+		},
+		{
+			name:   "Escaped quote",
+			line:   `10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "GET /search?q=\"a\" HTTP/1.1" 200 12`,
+			want:   replayEntry{time: time.Date(2020, 10, 10, 13, 55, 36, 0, time.UTC), method: "GET", path: `/search?q=\"a\"`},
+			wantOK: true,
+		},
+		{
+			name: "Garbage request",
+			line: `10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "\x16\x03\x01" 400 157 "-" "-"`,
+		},
+		{
+			name: "Not a log line",
+			line: `nginx: [warn] could not build optimal types_hash`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseCombinedLine([]byte(tt.line))
+			if ok != tt.wantOK {
+				t.Fatalf("parseCombinedLine() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (!got.time.Equal(tt.want.time) || got.method != tt.want.method || got.path != tt.want.path) {
+				t.Errorf("parseCombinedLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLogParser(t *testing.T) {
+	at := time.Date(2020, 10, 10, 13, 55, 36, 500000000, time.UTC)
+	tests := []struct {
+		name   string
+		fields *models.ReplayFields
+		line   string
+		wantOK bool
+	}{
+		{
+			name:   "Usual fields",
+			line:   `{"time": "2020-10-10T13:55:36.5Z", "method": "GET", "path": "/items"}`,
+			wantOK: true,
+		},
+		{
+			name:   "Request line and epoch",
+			line:   `{"ts": 1602338136.5, "request": "GET /items HTTP/2.0", "status": 200}`,
+			wantOK: true,
+		},
+		{
+			name:   "Nested fields",
+			line:   `{"@timestamp": "2020-10-10T13:55:36.5Z", "http": {"request": {"method": "GET"}}, "url": {"original": "/items"}}`,
+			wantOK: true,
+		},
+		{
+			name:   "Custom fields",
+			fields: &models.ReplayFields{Time: "at", Method: "m", Path: "p"},
+			line:   `{"at": 1602338136500, "m": "GET", "p": "/items"}`,
+			wantOK: true,
+		},
+		{
+			name: "No request",
+			line: `{"time": "2020-10-10T13:55:36.5Z", "msg": "starting"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := jsonLogParser(tt.fields)([]byte(tt.line))
+			if ok != tt.wantOK {
+				t.Fatalf("jsonLogParser() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (!got.time.Equal(at) || got.method != "GET" || got.path != "/items") {
+				t.Errorf("jsonLogParser() = %+v, want GET /items at %s", got, at)
+			}
+		})
+	}
+}
+
+func TestValidateReplayLog(t *testing.T) {
+	log := `10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "GET /a HTTP/1.1" 200 1
+10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "-" 400 0
+
+10.0.0.1 - - [10/Oct/2020:13:55:37 +0000] "GET /b HTTP/1.1" 200 1
+`
+	n, skipped, err := ValidateReplayLog(models.ReplayParams{}, strings.NewReader(log))
+	if err != nil || n != 2 || skipped != 1 {
+		t.Errorf("ValidateReplayLog() = %d, %d, %v, want 2, 1, nil", n, skipped, err)
+	}
+
+	// Timing mode needs the time of every request
+	json := `{"method": "GET", "path": "/a"}`
+	if _, _, err := ValidateReplayLog(models.ReplayParams{Format: models.ReplayFormatJSON}, strings.NewReader(json)); err == nil {
+		t.Error("ValidateReplayLog() of a log without times returned no error in timing mode")
+	}
+	p := models.ReplayParams{Format: models.ReplayFormatJSON, Mode: models.ReplayModePool}
+	if n, _, err := ValidateReplayLog(p, strings.NewReader(json)); err != nil || n != 1 {
+		t.Errorf("ValidateReplayLog() = %d, %v, want 1, nil in pool mode", n, err)
+	}
+}
+
+// replayServer records the paths and arrival times of requests
+type replayServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	paths []string
+	times []time.Time
+}
+
+func newReplayServer() *replayServer {
+	s := &replayServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.paths = append(s.paths, r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("X-Replay"))
+		s.times = append(s.times, time.Now())
+	}))
+	return s
+}
+
+func TestAttack_ReplayTiming(t *testing.T) {
+	srv := newReplayServer()
+	defer srv.Close()
+
+	// 400ms of recorded traffic, replayed twice as fast
+	log := strings.Join([]string{
+		`10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "GET /a HTTP/1.1" 200 1`,
+		`{"time": "2020-10-10T13:55:36.2Z", "method": "POST", "path": "/b"}`,
+		`{"time": "2020-10-10T13:55:36.4Z", "method": "DELETE", "path": "/c?x=1"}`,
+	}, "\n")
+	params := models.AttackParams{
+		Rate:     1,
+		Duration: "0s",
+		Replay: &models.ReplayParams{
+			Format:  models.ReplayFormatJSON,
+			Speed:   2,
+			BaseURL: srv.URL,
+			Headers: []models.AttackHeader{{Key: "X-Replay", Value: "1"}},
+			Data:    log,
+		},
+	}
+
+	var buf bytes.Buffer
+	began := time.Now()
+	if err := Attack("replay", params, models.AttackInputs{}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	// The combined format line is skipped in a json log
+	want := []string{"POST /b 1", "DELETE /c?x=1 1"}
+	if strings.Join(srv.paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("Attack() requests = %v, want %v", srv.paths, want)
+	}
+	if gap := srv.times[1].Sub(srv.times[0]); gap < 70*time.Millisecond || gap > 300*time.Millisecond {
+		t.Errorf("Attack() requests %s apart, want about 100ms", gap)
+	}
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("Attack() took %s, want it to end with the log", elapsed)
+	}
+}
+
+func TestAttack_ReplayPool(t *testing.T) {
+	srv := newReplayServer()
+	defer srv.Close()
+
+	params := models.AttackParams{
+		Rate:     50,
+		Duration: "200ms",
+		Replay: &models.ReplayParams{
+			Mode:    models.ReplayModePool,
+			BaseURL: srv.URL + "/",
+			Data: `10.0.0.1 - - [10/Oct/2020:13:55:36 +0000] "GET /a HTTP/1.1" 200 1
+10.0.0.1 - - [10/Oct/2020:13:59:36 +0000] "GET /b HTTP/1.1" 200 1`,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Attack("replay", params, models.AttackInputs{}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	// The log is cycled through at the attack rate, regardless of its times
+	if len(srv.paths) < 6 {
+		t.Fatalf("Attack() sent %d requests, want about 10", len(srv.paths))
+	}
+	for i, p := range srv.paths[:6] {
+		if want := []string{"GET /a ", "GET /b "}[i%2]; p != want {
+			t.Errorf("Attack() request %d = %q, want %q", i, p, want)
+		}
+	}
+
+	dec := vegeta.NewDecoder(bytes.NewReader(buf.Bytes()))
+	var r vegeta.Result
+	if err := dec.Decode(&r); err != nil || r.Attack != "replay" || r.Error != "" {
+		t.Errorf("Attack() result = %+v, %v, want a success of attack replay", r, err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"text/tabwriter"
 	"vegeta-server/models"
 
 	"github.com/pkg/errors"
@@ -24,27 +26,88 @@ const (
 	DefaultBucketString string = "0,500ms,1s,1.5s,2s,2.5s,3s"
 	//Histogram prometheus values
 	HistogramPlotString string = "hdrplot"
+	// HTMLFormatString typedef for query param "html"
+	HTMLFormatString string = "html"
 )
 
 // CreateReportFromReader takes in an io.Reader with the vegeta gob, encoded result and
-// returns the decoded result as a byte array
+// returns the decoded result as a byte array. Compressed results are decompressed transparently.
+// JSON, text and HTML reports of attacks on several targets break the metrics down by target.
 func CreateReportFromReader(reader io.Reader, id string, format Format) ([]byte, error) {
-	dec := vegeta.DecoderFor(reader)
+	return createReport(reader, id, format, false)
+}
+
+// CreateScenarioReportFromReader creates a report like CreateReportFromReader, breaking the
+// metrics of JSON, text and HTML reports down by scenario step
+func CreateScenarioReportFromReader(reader io.Reader, id string, format Format) ([]byte, error) {
+	return createReport(reader, id, format, true)
+}
+
+// stepMetrics are the metrics of the results of a single scenario step or
+// target
+type stepMetrics struct {
+	name    string
+	metrics *vegeta.Metrics
+}
 
-	m := vegeta.Metrics{}
+// metrics are vegeta's metrics, counting only results without an error as
+// successes. vegeta counts any 2xx or 3xx status as a success, including
+// responses that failed their assertions.
+type metrics struct {
+	vegeta.Metrics
+	successes uint64
+}
+
+// Add implements vegeta.Report
+func (m *metrics) Add(r *vegeta.Result) {
+	m.Metrics.Add(r)
+	if r.Error == "" {
+		m.successes++
+	}
+}
+
+// Close implements vegeta.Closer, computing the success ratio and the
+// throughput from the results without an error
+func (m *metrics) Close() {
+	m.Metrics.Close()
+	if m.Requests == 0 {
+		return
+	}
+	m.Success = float64(m.successes) / float64(m.Requests)
+	m.Throughput = float64(m.successes)
+	if m.Duration.Seconds() > 0 {
+		m.Throughput /= (m.Duration + m.Wait).Seconds()
+	}
+}
+
+// createReport decodes the results into the report of the given format,
+// along with metrics per step if asked for, or per target otherwise
+func createReport(reader io.Reader, id string, format Format, byStep bool) ([]byte, error) {
+	rc, err := NewDecompressReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() // nolint: errcheck
+
+	dec := vegeta.DecoderFor(rc)
+
+	m := metrics{}
 
 	var report vegeta.Report = &m
 
 	var rep vegeta.Reporter
 
+	// The latency over time of the HTML report
+	var plot *latencyPlot
+
 	fs := format.String()
 
 	switch fs {
 	case JSONFormatString:
 		// Create a new reporter with the metrics
-		rep = vegeta.NewJSONReporter(&m)
+		rep = vegeta.NewJSONReporter(&m.Metrics)
 	case TextFormatString:
-		rep = vegeta.NewTextReporter(&m)
+		rep = vegeta.NewTextReporter(&m.Metrics)
 	case HistogramFormatString:
 		var hist vegeta.Histogram
 		meta := format.Meta()
@@ -57,11 +120,19 @@ func CreateReportFromReader(reader io.Reader, id string, format Format) ([]byte,
 	case HistogramPlotString:
 		var hist vegeta.Metrics
 		rep, report = vegeta.NewHDRHistogramPlotReporter(&hist), &hist
+	case HTMLFormatString:
+		// Rendered once the steps or targets are known, below
+		plot = newLatencyPlot()
+		rep = func(io.Writer) error { return nil }
 	default:
 		return nil, fmt.Errorf("format %s not supported", format)
 	}
 
-	rc, _ := report.(vegeta.Closer)
+	// Results are named after their scenario step or target
+	byName := fs == JSONFormatString || fs == TextFormatString || fs == HTMLFormatString
+	named := make(map[string]*metrics)
+
+	closer, _ := report.(vegeta.Closer)
 decode:
 	for {
 		var r vegeta.Result
@@ -74,14 +145,31 @@ decode:
 		}
 
 		report.Add(&r)
+		if plot != nil {
+			plot.Add(&r)
+		}
+		if byName {
+			if named[r.Attack] == nil {
+				named[r.Attack] = &metrics{}
+			}
+			named[r.Attack].Add(&r)
+		}
+	}
+	if closer != nil {
+		closer.Close()
 	}
-	if rc != nil {
-		rc.Close()
+
+	var stepReports, targetReports []stepMetrics
+	switch {
+	case byStep:
+		stepReports = sortSteps(named)
+	case len(named) > 1:
+		targetReports = sortTargets(named)
 	}
 
 	var b []byte
 	buf := bytes.NewBuffer(b)
-	err := rep.Report(buf)
+	err = rep.Report(buf)
 	if err != nil {
 		return nil, errors.Wrap(err, "reporter failed")
 	}
@@ -95,18 +183,119 @@ decode:
 			return nil, errors.Wrap(err, "failed to unmarshal JSONReportResponse")
 		}
 		jsonReportResponse.ID = id
+		for _, step := range stepReports {
+			stepReport := models.StepReportResponse{Name: step.name}
+			if err := jsonMetrics(step.metrics, &stepReport.JSONMetrics); err != nil {
+				return nil, err
+			}
+			jsonReportResponse.Steps = append(jsonReportResponse.Steps, stepReport)
+		}
+		for _, target := range targetReports {
+			targetReport := models.TargetReportResponse{Name: target.name, Share: share(target.metrics, &m.Metrics)}
+			if err := jsonMetrics(target.metrics, &targetReport.JSONMetrics); err != nil {
+				return nil, err
+			}
+			jsonReportResponse.Targets = append(jsonReportResponse.Targets, targetReport)
+		}
 		return json.Marshal(jsonReportResponse)
-	case TextFormatString, HistogramFormatString:
+	case TextFormatString:
+		for _, step := range stepReports {
+			fmt.Fprintf(buf, "\nStep %s\n", step.name)
+			if err := vegeta.NewTextReporter(step.metrics).Report(buf); err != nil {
+				return nil, errors.Wrap(err, "reporter failed")
+			}
+		}
+		if len(targetReports) > 0 {
+			tw := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+			fmt.Fprintf(tw, "\nTargets\t[requests, share]\n")
+			for _, target := range targetReports {
+				fmt.Fprintf(tw, "%s\t%d, %.2f%%\n", target.name, target.metrics.Requests, 100*share(target.metrics, &m.Metrics))
+			}
+			if err := tw.Flush(); err != nil {
+				return nil, errors.Wrap(err, "reporter failed")
+			}
+		}
+		for _, target := range targetReports {
+			fmt.Fprintf(buf, "\nTarget %s\n", target.name)
+			if err := vegeta.NewTextReporter(target.metrics).Report(buf); err != nil {
+				return nil, errors.Wrap(err, "reporter failed")
+			}
+		}
+		return addID(buf, id), nil
+	case HistogramFormatString:
 		return addID(buf, id), nil
+	case HTMLFormatString:
+		if err := writeHTMLReport(buf, id, format.Meta(), &m.Metrics, plot, stepReports, targetReports); err != nil {
+			return nil, err
+		}
 	}
 
 	return buf.Bytes(), nil
 }
 
+// sortSteps closes the metrics of every step and orders the steps by their
+// earliest result, which follows the order of the scenario
+func sortSteps(steps map[string]*metrics) []stepMetrics {
+	sorted := make([]stepMetrics, 0, len(steps))
+	for name, m := range steps {
+		m.Close()
+		sorted = append(sorted, stepMetrics{name, &m.Metrics})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i].metrics.Earliest, sorted[j].metrics.Earliest
+		if a.Equal(b) {
+			return sorted[i].name < sorted[j].name
+		}
+		return a.Before(b)
+	})
+	return sorted
+}
+
+// sortTargets closes the metrics of every target and orders the targets by
+// their number of requests, most first
+func sortTargets(targets map[string]*metrics) []stepMetrics {
+	sorted := make([]stepMetrics, 0, len(targets))
+	for name, m := range targets {
+		m.Close()
+		sorted = append(sorted, stepMetrics{name, &m.Metrics})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i].metrics.Requests, sorted[j].metrics.Requests
+		if a == b {
+			return sorted[i].name < sorted[j].name
+		}
+		return a > b
+	})
+	return sorted
+}
+
+// share returns the share of the requests of m in the total
+func share(m, total *vegeta.Metrics) float64 {
+	if total.Requests == 0 {
+		return 0
+	}
+	return float64(m.Requests) / float64(total.Requests)
+}
+
+// jsonMetrics decodes the metrics as written by the vegeta JSON reporter
+func jsonMetrics(m *vegeta.Metrics, out *models.JSONMetrics) error {
+	var buf bytes.Buffer
+	if err := vegeta.NewJSONReporter(m).Report(&buf); err != nil {
+		return errors.Wrap(err, "reporter failed")
+	}
+	return errors.Wrap(json.Unmarshal(buf.Bytes(), out), "failed to unmarshal JSONMetrics")
+}
+
 // CreateHistogramFromReader takes in an io.Reader with the vegeta gob, encoded result and
-// returns the decoded result as a byte array
+// returns the decoded result as a byte array. Compressed results are decompressed transparently.
 func CreateHistogramFromReader(reader io.Reader, id string) ([]byte, error) {
-	dec := vegeta.DecoderFor(reader)
+	rc, err := NewDecompressReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() // nolint: errcheck
+
+	dec := vegeta.DecoderFor(rc)
 
 	var metrics []vegeta.Result
 
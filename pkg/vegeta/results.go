@@ -0,0 +1,125 @@
+package vegeta
+
+import (
+	"container/heap"
+	"io"
+	"sort"
+	"strings"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// QueryResults decodes the results read from reader, compressed or not, and
+// writes the page of those matching the query to w in vegeta's JSON format,
+// one result per line. It returns the number of matches, regardless of the
+// page. Only the slowest results asked for are held in memory.
+func QueryResults(reader io.Reader, q models.ResultQuery, w io.Writer) (int, error) {
+	rc, err := NewDecompressReader(reader)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close() // nolint: errcheck
+
+	// No decoder is found for an empty result
+	dec := vegeta.DecoderFor(rc)
+	if dec == nil {
+		return 0, nil
+	}
+
+	enc := vegeta.NewJSONEncoder(w)
+	slowest := make(byLatency, 0, q.Slowest)
+	matches := 0
+	for {
+		r := new(vegeta.Result)
+		if err := dec.Decode(r); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, errors.Wrap(err, "failed to decode result")
+		}
+		if !matchResult(q, r) {
+			continue
+		}
+
+		if q.Slowest > 0 {
+			heap.Push(&slowest, r)
+			if slowest.Len() > q.Slowest {
+				heap.Pop(&slowest)
+			}
+			continue
+		}
+		if inPage(q, matches) {
+			if err := enc.Encode(r); err != nil {
+				return 0, errors.Wrap(err, "failed to encode result")
+			}
+		}
+		matches++
+	}
+
+	if q.Slowest == 0 {
+		return matches, nil
+	}
+	sort.Sort(sort.Reverse(slowest))
+	for i, r := range slowest {
+		if !inPage(q, i) {
+			continue
+		}
+		if err := enc.Encode(r); err != nil {
+			return 0, errors.Wrap(err, "failed to encode result")
+		}
+	}
+	return len(slowest), nil
+}
+
+// matchResult reports whether the result passes the filters of the query
+func matchResult(q models.ResultQuery, r *vegeta.Result) bool {
+	if len(q.Codes) > 0 {
+		found := false
+		for _, code := range q.Codes {
+			found = found || r.Code == code
+		}
+		if !found {
+			return false
+		}
+	}
+	switch {
+	case q.Error != "" && !strings.Contains(r.Error, q.Error),
+		q.Name != "" && r.Attack != q.Name,
+		r.Latency < q.MinLatency,
+		!q.From.IsZero() && r.Timestamp.Before(q.From),
+		!q.To.IsZero() && r.Timestamp.After(q.To):
+		return false
+	}
+	return true
+}
+
+// inPage reports whether the match of the given index is on the page of the
+// query
+func inPage(q models.ResultQuery, i int) bool {
+	return i >= q.Offset && (q.Limit <= 0 || i < q.Offset+q.Limit)
+}
+
+// byLatency is a min-heap of results by latency, ties broken by sequence
+// number, so that the slowest results are kept
+type byLatency []*vegeta.Result
+
+func (h byLatency) Len() int { return len(h) }
+func (h byLatency) Less(i, j int) bool {
+	if h[i].Latency != h[j].Latency {
+		return h[i].Latency < h[j].Latency
+	}
+	return h[i].Seq > h[j].Seq
+}
+func (h byLatency) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+// Push implements heap.Interface
+func (h *byLatency) Push(x interface{}) { *h = append(*h, x.(*vegeta.Result)) }
+
+// Pop implements heap.Interface
+func (h *byLatency) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
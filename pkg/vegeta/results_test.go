@@ -0,0 +1,76 @@
+package vegeta
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestQueryResults(t *testing.T) {
+	began := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	results := []vegeta.Result{
+		{Attack: "GET /a", Seq: 0, Code: 200, Timestamp: began, Latency: 10 * time.Millisecond},
+		{Attack: "GET /b", Seq: 1, Code: 500, Timestamp: began.Add(time.Second), Latency: 300 * time.Millisecond, Error: "500 Internal Server Error"},
+		{Attack: "GET /a", Seq: 2, Code: 0, Timestamp: began.Add(2 * time.Second), Latency: time.Second, Error: "context deadline exceeded"},
+		{Attack: "GET /b", Seq: 3, Code: 200, Timestamp: began.Add(3 * time.Second), Latency: 300 * time.Millisecond},
+	}
+
+	tests := []struct {
+		name      string
+		query     models.ResultQuery
+		wantSeqs  []uint64
+		wantTotal int
+	}{
+		{"All", models.ResultQuery{}, []uint64{0, 1, 2, 3}, 4},
+		{"Codes", models.ResultQuery{Codes: []uint16{0, 500}}, []uint64{1, 2}, 2},
+		{"Error", models.ResultQuery{Error: "deadline"}, []uint64{2}, 1},
+		{"Name", models.ResultQuery{Name: "GET /b"}, []uint64{1, 3}, 2},
+		{"Min latency", models.ResultQuery{MinLatency: 300 * time.Millisecond}, []uint64{1, 2, 3}, 3},
+		{"Time range", models.ResultQuery{From: began.Add(time.Second), To: began.Add(2 * time.Second)}, []uint64{1, 2}, 2},
+		{"Page", models.ResultQuery{Offset: 1, Limit: 2}, []uint64{1, 2}, 4},
+		{"Slowest", models.ResultQuery{Slowest: 3}, []uint64{2, 1, 3}, 3},
+		{"Slowest page", models.ResultQuery{Slowest: 3, Offset: 1, Limit: 1}, []uint64{1}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in bytes.Buffer
+			enc := vegeta.NewEncoder(&in)
+			for i := range results {
+				if err := enc.Encode(&results[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var out bytes.Buffer
+			total, err := QueryResults(&in, tt.query, &out)
+			if err != nil {
+				t.Fatalf("QueryResults() error = %v", err)
+			}
+
+			seqs := make([]uint64, 0)
+			dec := vegeta.NewJSONDecoder(&out)
+			for {
+				var r vegeta.Result
+				if err := dec.Decode(&r); err != nil {
+					break
+				}
+				seqs = append(seqs, r.Seq)
+			}
+			if total != tt.wantTotal || !reflect.DeepEqual(seqs, tt.wantSeqs) {
+				t.Errorf("QueryResults() = %v, %d, want %v, %d", seqs, total, tt.wantSeqs, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestQueryResults_Empty(t *testing.T) {
+	var out bytes.Buffer
+	total, err := QueryResults(bytes.NewReader(nil), models.ResultQuery{}, &out)
+	if err != nil || total != 0 || out.Len() != 0 {
+		t.Errorf("QueryResults() = %q, %d, %v, want no results", out.String(), total, err)
+	}
+}
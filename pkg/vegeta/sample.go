@@ -0,0 +1,139 @@
+package vegeta
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+	"vegeta-server/models"
+
+	log "github.com/sirupsen/logrus"
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// sampler captures the requests of an attack matching the sample params,
+// writing them to the samples of the attack as JSON Lines
+type sampler struct {
+	filter  string
+	percent float64
+	maxBody int
+
+	mu   sync.Mutex
+	left int
+	enc  *json.Encoder
+	rand *rand.Rand
+}
+
+// newSampler returns a sampler writing to w
+func newSampler(p models.SampleParams, w io.Writer) *sampler {
+	return &sampler{
+		filter:  p.FilterOrDefault(),
+		percent: p.PercentOrDefault(),
+		maxBody: p.MaxBodyOrDefault(),
+		left:    p.MaxOrDefault(),
+		enc:     json.NewEncoder(w),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())), // nolint: gosec
+	}
+}
+
+// matches reports whether the result passes the filter of the sampler
+func (s *sampler) matches(res *vegeta.Result) bool {
+	switch s.filter {
+	case models.SampleFilterErrors:
+		return res.Error != ""
+	case models.SampleFilterNon2xx:
+		return res.Code < 200 || res.Code >= 300
+	}
+	return true
+}
+
+// bodyLimit returns how many bytes of the body of a response with the given
+// status code are needed to sample it, one past the max body of the sampler
+// to tell whether it was truncated. It is 0 if the response cannot be
+// sampled, because its status does not pass the filter or the max samples
+// were taken, so that bodies are only kept while they may be sampled.
+func (s *sampler) bodyLimit(code int) int64 {
+	if s == nil || (s.filter == models.SampleFilterNon2xx && code >= 200 && code < 300) {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.left <= 0 {
+		return 0
+	}
+	return int64(s.maxBody) + 1
+}
+
+// sample captures the request of a result, if it passes the filter and is
+// picked at the sample percentage, until the max samples were taken. The
+// request is nil if it could not be built, the response if it failed, and
+// body holds at least the start of the response body up to its bodyLimit. Samples failing to be written are
+// logged, and stop the sampling.
+func (s *sampler) sample(res *vegeta.Result, req *http.Request, r *http.Response, body []byte) {
+	if s == nil || !s.matches(res) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.left <= 0 || (s.percent < 100 && s.rand.Float64()*100 >= s.percent) {
+		return
+	}
+	s.left--
+
+	sample := models.Sample{
+		Seq:       res.Seq,
+		Timestamp: res.Timestamp,
+		Name:      res.Attack,
+		Latency:   res.Latency,
+		Code:      res.Code,
+		Error:     res.Error,
+	}
+	if req != nil {
+		sample.Request = &models.SampleRequest{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header,
+		}
+		sample.Request.Body, sample.Request.Truncated = s.truncate(requestBody(req))
+	}
+	if r != nil {
+		sample.Response = &models.SampleResponse{
+			Status:  r.Status,
+			Headers: r.Header,
+		}
+		sample.Response.Body, sample.Response.Truncated = s.truncate(body)
+	}
+
+	if err := s.enc.Encode(sample); err != nil {
+		log.WithError(err).Error("failed to write sample, sampling stopped")
+		s.left = 0
+	}
+}
+
+// truncate cuts a body to the max body of the sampler
+func (s *sampler) truncate(body []byte) (string, bool) {
+	if len(body) > s.maxBody {
+		return string(body[:s.maxBody]), true
+	}
+	return string(body), false
+}
+
+// requestBody returns a copy of the body of a sent request, if it can be
+// read again
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close() // nolint: errcheck
+	body, _ := ioutil.ReadAll(rc)
+	return body
+}
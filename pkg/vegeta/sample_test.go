@@ -0,0 +1,136 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// decodeSamples reads the samples written as JSON Lines
+func decodeSamples(t *testing.T, b []byte) []models.Sample {
+	samples := make([]models.Sample, 0)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for dec.More() {
+		var s models.Sample
+		if err := dec.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+func TestSampler_sample(t *testing.T) {
+	ok := &vegeta.Result{Code: 200}
+	notFound := &vegeta.Result{Code: 404, Error: "404 Not Found"}
+	redirect := &vegeta.Result{Code: 302}
+	failed := &vegeta.Result{Error: "connection refused"}
+
+	tests := []struct {
+		name    string
+		params  models.SampleParams
+		results []*vegeta.Result
+		want    int
+	}{
+		{"All", models.SampleParams{}, []*vegeta.Result{ok, notFound, redirect, failed}, 4},
+		{"Max", models.SampleParams{Max: 2}, []*vegeta.Result{ok, notFound, redirect, failed}, 2},
+		{"Errors", models.SampleParams{Filter: models.SampleFilterErrors}, []*vegeta.Result{ok, notFound, redirect, failed}, 2},
+		{"Non-2xx", models.SampleParams{Filter: models.SampleFilterNon2xx}, []*vegeta.Result{ok, notFound, redirect, failed}, 3},
+		{"Percent", models.SampleParams{Percent: 0.001}, []*vegeta.Result{ok, notFound, redirect, failed}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := newSampler(tt.params, &buf)
+			for _, res := range tt.results {
+				s.sample(res, nil, nil, nil)
+			}
+			if got := decodeSamples(t, buf.Bytes()); len(got) != tt.want {
+				t.Errorf("sample() took %d samples, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSampler_bodyLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		params models.SampleParams
+		taken  int
+		code   int
+		want   int64
+	}{
+		{"All", models.SampleParams{MaxBody: 10}, 0, 200, 11},
+		{"Non-2xx", models.SampleParams{MaxBody: 10, Filter: models.SampleFilterNon2xx}, 0, 500, 11},
+		{"Non-2xx filtered", models.SampleParams{MaxBody: 10, Filter: models.SampleFilterNon2xx}, 0, 200, 0},
+		{"Errors", models.SampleParams{MaxBody: 10, Filter: models.SampleFilterErrors}, 0, 200, 11},
+		{"Max taken", models.SampleParams{MaxBody: 10, Max: 1}, 1, 200, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := newSampler(tt.params, &buf)
+			for i := 0; i < tt.taken; i++ {
+				s.sample(&vegeta.Result{Code: 200}, nil, nil, nil)
+			}
+			if got := s.bodyLimit(tt.code); got != tt.want {
+				t.Errorf("bodyLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	var s *sampler
+	if got := s.bodyLimit(200); got != 0 {
+		t.Errorf("bodyLimit() of no sampler = %d, want 0", got)
+	}
+}
+
+func TestAttack_Samples(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("response body")) // nolint: errcheck
+	}))
+	defer srv.Close()
+
+	headers := []models.AttackHeader{{Key: "X-Request", Value: "1"}}
+	params := models.AttackParams{
+		Rate:     40,
+		Duration: "250ms",
+		Target: []models.Target{
+			{Method: "POST", URL: srv.URL + "/good", Body: "cmVxdWVzdCBib2R5", Headers: headers},
+			{Method: "POST", URL: srv.URL + "/bad", Body: "cmVxdWVzdCBib2R5", Headers: headers},
+		},
+		Samples: &models.SampleParams{Max: 3, Filter: models.SampleFilterErrors, MaxBody: 7},
+	}
+
+	var buf, samples bytes.Buffer
+	if err := Attack("samples", params, models.AttackInputs{Samples: &samples}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	got := decodeSamples(t, samples.Bytes())
+	if len(got) != 3 {
+		t.Fatalf("Attack() took %d samples, want 3", len(got))
+	}
+	for _, s := range got {
+		if s.Code != 500 || s.Name != "POST "+srv.URL+"/bad" {
+			t.Errorf("sample %d = %d %s, want failures of /bad only", s.Seq, s.Code, s.Name)
+		}
+		if req := s.Request; req == nil || req.Body != "request" || !req.Truncated || req.Headers.Get("X-Request") != "1" {
+			t.Errorf("sample %d request = %+v, want its truncated body and headers", s.Seq, req)
+		}
+		if r := s.Response; r == nil || r.Body != "respons" || !r.Truncated || r.Headers.Get("X-Path") != "/bad" ||
+			!strings.HasPrefix(r.Status, "500") {
+			t.Errorf("sample %d response = %+v, want its truncated body and headers", s.Seq, r)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package vegeta
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// extractor captures a value from a response into a variable
+type extractor struct {
+	name    string
+	extract func(*http.Response, []byte) (string, error)
+}
+
+// scenarioStep is a scenario step with its body decoded and its
+// extractions compiled
+type scenarioStep struct {
+	models.ScenarioStep
+	body       []byte
+	extract    []extractor
+	assertions assertions
+}
+
+// request builds the step's request, expanding the variables extracted so far
+func (s scenarioStep) request(ctx context.Context, vars map[string]string) (*http.Request, error) {
+	body := models.ExpandVariables(string(s.body), vars)
+	req, err := http.NewRequest(s.Method, models.ExpandVariables(s.URL, vars), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range s.Headers {
+		req.Header.Add(h.Key, models.ExpandVariables(h.Value, vars))
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	return req.WithContext(ctx), nil
+}
+
+// scenarioAttacker runs the iterations of a scenario at the attack rate.
+// Every iteration is run by a new virtual user, with its own cookie jar and
+// extracted values, and stops at the first failing step since later steps
+// may depend on its values. Results are named after their step.
+type scenarioAttacker struct {
+	*httpAttacker
+	steps []scenarioStep
+}
+
+// newScenarioAttacker compiles the scenario and sets up its HTTP client
+func newScenarioAttacker(opts *AttackOpts, c *tls.Config) (*scenarioAttacker, error) {
+	steps := make([]scenarioStep, len(opts.Scenario.Steps))
+	for i, step := range opts.Scenario.Steps {
+		compiled, err := compileStep(step)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to compile scenario step %s", step.Name))
+		}
+		steps[i] = compiled
+	}
+
+	return &scenarioAttacker{
+		httpAttacker: newHTTPAttacker(opts, c),
+		steps:        steps,
+	}, nil
+}
+
+// compileStep decodes the body and compiles the extractions of a step
+func compileStep(step models.ScenarioStep) (scenarioStep, error) {
+	body, err := base64.StdEncoding.DecodeString(step.Body)
+	if err != nil {
+		return scenarioStep{}, errors.Wrap(err, "failed to decode body")
+	}
+
+	compiled := scenarioStep{ScenarioStep: step, body: body}
+	if compiled.assertions, err = compileAssertions(step.Assert); err != nil {
+		return scenarioStep{}, err
+	}
+	for _, e := range step.Extract {
+		ex, err := compileExtraction(e)
+		if err != nil {
+			return scenarioStep{}, err
+		}
+		compiled.extract = append(compiled.extract, ex)
+	}
+	return compiled, nil
+}
+
+// compileExtraction returns the extractor for an extraction
+func compileExtraction(e models.Extraction) (extractor, error) {
+	ex := extractor{name: e.Name}
+
+	switch e.Source {
+	case models.ExtractJSONPath:
+		path, err := models.ParseJSONPath(e.Expression)
+		if err != nil {
+			return ex, err
+		}
+		ex.extract = func(_ *http.Response, body []byte) (string, error) {
+			var doc interface{}
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			if err := dec.Decode(&doc); err != nil {
+				return "", errors.Wrap(err, "failed to decode JSON body")
+			}
+			v, ok := path.Lookup(doc)
+			if !ok {
+				return "", fmt.Errorf("%s not found", e.Expression)
+			}
+			return jsonString(v)
+		}
+	case models.ExtractRegex:
+		re, err := regexp.Compile(e.Expression)
+		if err != nil {
+			return ex, err
+		}
+		ex.extract = func(_ *http.Response, body []byte) (string, error) {
+			m := re.FindSubmatch(body)
+			switch {
+			case m == nil:
+				return "", fmt.Errorf("%s did not match", e.Expression)
+			case len(m) > 1:
+				return string(m[1]), nil
+			}
+			return string(m[0]), nil
+		}
+	case models.ExtractHeader:
+		key := textproto.CanonicalMIMEHeaderKey(e.Expression)
+		ex.extract = func(r *http.Response, _ []byte) (string, error) {
+			values, ok := r.Header[key]
+			if !ok || len(values) == 0 {
+				return "", fmt.Errorf("header %s not found", key)
+			}
+			return values[0], nil
+		}
+	default:
+		return ex, fmt.Errorf("unsupported extraction source %q", e.Source)
+	}
+
+	return ex, nil
+}
+
+// jsonString formats an extracted JSON value: strings and numbers as is,
+// anything else as JSON
+func jsonString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// Attack starts iterations of the scenario at the rate of the pacer for the
+// given duration, sending the result of every step on the returned channel
+func (a *scenarioAttacker) Attack(p vegeta.Pacer, du time.Duration) <-chan *vegeta.Result {
+	return a.attack(p, du, a.iterate)
+}
+
+// iterate runs the steps of the scenario as a new virtual user
+func (a *scenarioAttacker) iterate(results chan<- *vegeta.Result) {
+	client := a.client
+	client.Jar, _ = cookiejar.New(nil) // never fails without options
+	vars := make(map[string]string)
+
+	for _, step := range a.steps {
+		res, ok := a.hitStep(&client, step, vars)
+		if !a.send(results, res) || !ok {
+			return
+		}
+	}
+}
+
+// hitStep sends the request of a step, checks its assertions and extracts
+// values from its response, reporting whether the iteration may go on
+func (a *scenarioAttacker) hitStep(client *http.Client, step scenarioStep, vars map[string]string) (*vegeta.Result, bool) {
+	res, req, r, body := a.hit(client, step.Name, func(ctx context.Context) (*http.Request, error) {
+		return step.request(ctx, vars)
+	}, true)
+	step.assertions.apply(res, r, body)
+	ok := step.extractInto(vars, res, r, body)
+	a.samples.sample(res, req, r, body)
+	return res, ok
+}
+
+// extractInto stores the values extracted from the response of the step,
+// failing its result if one cannot be extracted. It reports whether the
+// iteration may go on.
+func (s scenarioStep) extractInto(vars map[string]string, res *vegeta.Result, r *http.Response, body []byte) bool {
+	if r == nil || res.Error != "" {
+		return false
+	}
+
+	for _, e := range s.extract {
+		v, err := e.extract(r, body)
+		if err != nil {
+			res.Error = errors.Wrap(err, fmt.Sprintf("failed to extract %s", e.name)).Error()
+			return false
+		}
+		vars[e.name] = v
+	}
+
+	return true
+}
@@ -0,0 +1,155 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func newScenarioServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		w.Header().Set("X-Request-Id", "r1")
+		w.Write([]byte(`{"data": {"token": "t1", "user": {"id": 7}}}`)) // nolint: errcheck
+	})
+	mux.HandleFunc("/users/7", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t1" || r.Header.Get("X-Request-Id") != "r1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`<a href="/orders/42">orders</a>`)) // nolint: errcheck
+	})
+	mux.HandleFunc("/orders/42", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return httptest.NewServer(mux)
+}
+
+func scenarioParams(url string) models.AttackParams {
+	return models.AttackParams{
+		Rate:     20,
+		Duration: "200ms",
+		MaxBody:  -1,
+		Scenario: &models.Scenario{
+			Steps: []models.ScenarioStep{
+				{
+					Name:   "login",
+					Target: models.Target{Method: "POST", URL: url + "/login"},
+					Extract: []models.Extraction{
+						{Name: "token", Source: models.ExtractJSONPath, Expression: "$.data.token"},
+						{Name: "user", Source: models.ExtractJSONPath, Expression: "$.data.user.id"},
+						{Name: "request", Source: models.ExtractHeader, Expression: "x-request-id"},
+					},
+				},
+				{
+					Name: "profile",
+					Target: models.Target{
+						Method: "GET",
+						URL:    url + "/users/${user}",
+						Headers: []models.AttackHeader{
+							{Key: "Authorization", Value: "Bearer ${token}"},
+							{Key: "X-Request-Id", Value: "${request}"},
+						},
+					},
+					Extract: []models.Extraction{
+						{Name: "orders", Source: models.ExtractRegex, Expression: `href="([^"]+)"`},
+					},
+				},
+				{
+					Name:   "orders",
+					Target: models.Target{Method: "GET", URL: url + "${orders}"},
+				},
+			},
+		},
+	}
+}
+
+func TestAttack_Scenario(t *testing.T) {
+	srv := newScenarioServer()
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	if err := Attack("scenario", scenarioParams(srv.URL), models.AttackInputs{}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	counts := make(map[string]int)
+	dec := vegeta.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		var r vegeta.Result
+		if err := dec.Decode(&r); err != nil {
+			break
+		}
+		if r.Error != "" {
+			t.Errorf("step %s failed: %s", r.Attack, r.Error)
+		}
+		counts[r.Attack]++
+	}
+	if counts["login"] == 0 || counts["login"] != counts["profile"] || counts["profile"] != counts["orders"] {
+		t.Errorf("Attack() step results = %v, want the same number per step", counts)
+	}
+
+	report, err := CreateScenarioReportFromReader(bytes.NewReader(buf.Bytes()), "scenario", NewJSONFormat())
+	if err != nil {
+		t.Fatalf("CreateScenarioReportFromReader() error = %v", err)
+	}
+	var jsonReport models.JSONReportResponse
+	if err := json.Unmarshal(report, &jsonReport); err != nil {
+		t.Fatal(err)
+	}
+	if len(jsonReport.Steps) != 3 {
+		t.Fatalf("CreateScenarioReportFromReader() steps = %v, want 3", jsonReport.Steps)
+	}
+	for i, name := range []string{"login", "profile", "orders"} {
+		step := jsonReport.Steps[i]
+		if step.Name != name || step.Requests != counts[name] || step.Success != 1 {
+			t.Errorf("step %d = %s with %d requests, %v success, want %s with %d requests",
+				i, step.Name, step.Requests, step.Success, name, counts[name])
+		}
+	}
+	if jsonReport.Requests != counts["login"]*3 {
+		t.Errorf("report requests = %d, want %d", jsonReport.Requests, counts["login"]*3)
+	}
+}
+
+func TestAttack_ScenarioFailedStep(t *testing.T) {
+	srv := newScenarioServer()
+	defer srv.Close()
+
+	params := scenarioParams(srv.URL)
+	params.Scenario.Steps[0].Extract[0].Expression = "$.data.missing"
+	params.Scenario.Steps[0].Body = base64.StdEncoding.EncodeToString([]byte("user=alice"))
+
+	var buf bytes.Buffer
+	if err := Attack("scenario", params, models.AttackInputs{}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	// Iterations stop at the step whose extraction failed
+	dec := vegeta.NewDecoder(bytes.NewReader(buf.Bytes()))
+	n := 0
+	for {
+		var r vegeta.Result
+		if err := dec.Decode(&r); err != nil {
+			break
+		}
+		n++
+		if r.Attack != "login" || r.Error == "" || r.BytesOut != uint64(len("user=alice")) {
+			t.Errorf("result = %s %q %d, want a failed login", r.Attack, r.Error, r.BytesOut)
+		}
+	}
+	if n == 0 {
+		t.Error("Attack() returned no results")
+	}
+}
@@ -0,0 +1,160 @@
+package vegeta
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// errBodyFile is returned for http format targets whose body refers to a
+// file, which vegeta would read from the server's file system
+var errBodyFile = errors.New("body files are not supported, use the json format for targets with a body")
+
+// bodyFileGuard passes on http format targets line by line, failing at the
+// first line that refers to a body file
+type bodyFileGuard struct {
+	r   *bufio.Reader
+	buf []byte
+	err error
+}
+
+// Read implements io.Reader
+func (g *bodyFileGuard) Read(p []byte) (int, error) {
+	for len(g.buf) == 0 {
+		if g.err != nil {
+			return 0, g.err
+		}
+		g.buf, g.err = g.r.ReadBytes('\n')
+		if bytes.HasPrefix(bytes.TrimSpace(g.buf), []byte("@")) {
+			g.buf, g.err = nil, errBodyFile
+		}
+	}
+	n := copy(p, g.buf)
+	g.buf = g.buf[n:]
+	return n, nil
+}
+
+// Err returns the error reading failed with, other than io.EOF. vegeta's
+// http targeter reports any read error as the end of the targets.
+func (g *bodyFileGuard) Err() error {
+	if g == nil || g.err == io.EOF {
+		return nil
+	}
+	return g.err
+}
+
+// newFormatTargeter returns vegeta's targeter of the format reading from r,
+// along with the guard of http format targets
+func newFormatTargeter(format string, r io.Reader) (vegeta.Targeter, *bodyFileGuard) {
+	if format == models.TargetFormatJSON {
+		// vegeta's json targeter drops a last line without a line break
+		r = io.MultiReader(r, strings.NewReader("\n"))
+		return vegeta.NewJSONTargeter(r, nil, nil), nil
+	}
+	guard := &bodyFileGuard{r: bufio.NewReader(r)}
+	return vegeta.NewHTTPTargeter(guard, nil, nil), guard
+}
+
+// ValidateTargetFile reads all targets of the format from r, one at a time,
+// and returns their number
+func ValidateTargetFile(format string, r io.Reader) (int, error) {
+	tr, guard := newFormatTargeter(format, r)
+
+	n := 0
+	for {
+		var tgt vegeta.Target
+		err := tr(&tgt)
+		if err == vegeta.ErrNoTargets {
+			break
+		}
+		if err != nil {
+			return n, errors.Wrap(err, "failed to read target")
+		}
+		n++
+	}
+	if err := guard.Err(); err != nil {
+		return n, errors.Wrap(err, "failed to read targets")
+	}
+	if n == 0 {
+		return 0, vegeta.ErrNoTargets
+	}
+	return n, nil
+}
+
+// fileTargeter reads the targets of a target file one at a time, opening
+// the file anew whenever all of its targets were read, so that large files
+// are streamed rather than held in memory
+type fileTargeter struct {
+	mu     sync.Mutex
+	format string
+	open   func() (io.ReadCloser, error)
+	rc     io.ReadCloser
+	tr     vegeta.Targeter
+	guard  *bodyFileGuard
+	read   int
+	closed bool
+}
+
+// newFileTargeter returns a targeter of the target file opened by open
+func newFileTargeter(format string, open func() (io.ReadCloser, error)) *fileTargeter {
+	return &fileTargeter{format: format, open: open}
+}
+
+// Next fills tgt with the next target of the file
+func (t *fileTargeter) Next(tgt *vegeta.Target) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for !t.closed {
+		if t.tr == nil {
+			rc, err := t.open()
+			if err != nil {
+				return errors.Wrap(err, "failed to open targets")
+			}
+			t.rc, t.read = rc, 0
+			t.tr, t.guard = newFormatTargeter(t.format, rc)
+		}
+
+		err := t.tr(tgt)
+		if err == vegeta.ErrNoTargets {
+			empty := t.read == 0
+			if err := t.guard.Err(); err != nil {
+				return err
+			}
+			t.rewind()
+			if empty {
+				return vegeta.ErrNoTargets
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		t.read++
+		return nil
+	}
+	return vegeta.ErrNoTargets
+}
+
+// rewind closes the file, to be opened again by the next call to Next
+func (t *fileTargeter) rewind() {
+	if t.rc != nil {
+		t.rc.Close() // nolint: errcheck
+	}
+	t.rc, t.tr, t.guard = nil, nil, nil
+}
+
+// Close the target file, ending the targets
+func (t *fileTargeter) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rewind()
+	t.closed = true
+}
@@ -0,0 +1,146 @@
+package vegeta
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestValidateTargetFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "http",
+			format: models.TargetFormatHTTP,
+			data:   "GET http://localhost/a\nX-Header: 1\n\n# comment\nPOST http://localhost/b\n",
+			want:   2,
+		},
+		{
+			name:   "json",
+			format: models.TargetFormatJSON,
+			data:   `{"method": "GET", "url": "http://localhost/a"}` + "\n\n" + `{"method": "POST", "url": "http://localhost/b", "body": "e30="}`,
+			want:   2,
+		},
+		{
+			name:    "http body file",
+			format:  models.TargetFormatHTTP,
+			data:    "POST http://localhost/a\nX-Header: 1\n@/etc/passwd\n",
+			wantErr: true,
+		},
+		{
+			name:    "http bad method",
+			format:  models.TargetFormatHTTP,
+			data:    "get http://localhost/a\n",
+			wantErr: true,
+		},
+		{
+			name:    "json missing method",
+			format:  models.TargetFormatJSON,
+			data:    `{"url": "http://localhost/a"}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			format:  models.TargetFormatHTTP,
+			data:    "\n# no targets\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateTargetFile(tt.format, strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateTargetFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ValidateTargetFile() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileTargeter(t *testing.T) {
+	data := "GET http://localhost/a\n\nGET http://localhost/b\n"
+	opened := 0
+	tr := newFileTargeter(models.TargetFormatHTTP, func() (io.ReadCloser, error) {
+		opened++
+		return ioutil.NopCloser(strings.NewReader(data)), nil
+	})
+
+	// The file is read again from the start after its last target
+	for i, want := range []string{"/a", "/b", "/a", "/b", "/a"} {
+		var tgt vegeta.Target
+		if err := tr.Next(&tgt); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if tgt.URL != "http://localhost"+want {
+			t.Errorf("Next() %d URL = %s, want %s", i, tgt.URL, want)
+		}
+	}
+	if opened != 3 {
+		t.Errorf("Next() opened the file %d times, want 3", opened)
+	}
+
+	tr.Close()
+	var tgt vegeta.Target
+	if err := tr.Next(&tgt); err != vegeta.ErrNoTargets {
+		t.Errorf("Next() after Close() error = %v, want %v", err, vegeta.ErrNoTargets)
+	}
+
+	empty := newFileTargeter(models.TargetFormatJSON, func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	})
+	if err := empty.Next(&tgt); err != vegeta.ErrNoTargets {
+		t.Errorf("Next() of an empty file error = %v, want %v", err, vegeta.ErrNoTargets)
+	}
+}
+
+func TestAttack_TargetFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Header") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	params := models.AttackParams{
+		Rate:     20,
+		Duration: "200ms",
+		TargetFile: &models.TargetFile{
+			Data: "GET " + srv.URL + "/a\nX-Header: 1\n\nPOST " + srv.URL + "/b\nX-Header: 1\n",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Attack("file", params, models.AttackInputs{}, &buf, make(chan struct{})); err != nil {
+		t.Fatalf("Attack() error = %v", err)
+	}
+
+	n := 0
+	dec := vegeta.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		var r vegeta.Result
+		if err := dec.Decode(&r); err != nil {
+			break
+		}
+		if r.Error != "" || r.Attack != "file" {
+			t.Errorf("Attack() result = %s: %s, want a success of attack file", r.Attack, r.Error)
+		}
+		n++
+	}
+	if n == 0 {
+		t.Error("Attack() sent no requests")
+	}
+}
@@ -0,0 +1,265 @@
+package vegeta
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"vegeta-server/models"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+// templateFuncs are available in templated targets besides the feeder fields
+var templateFuncs = template.FuncMap{
+	"uuid": func() string {
+		return uuid.NewV4().String()
+	},
+	"randInt": func(min, max int) (int, error) {
+		if max < min {
+			return 0, fmt.Errorf("randInt max %d is less than min %d", max, min)
+		}
+		return min + rand.Intn(max-min+1), nil // nolint: gosec
+	},
+	"now": func() string {
+		return time.Now().Format(time.RFC3339)
+	},
+}
+
+// isTemplate reports whether s contains template actions
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+// parseTemplate parses s as a target template. Feeder fields missing from a
+// record fail the request rather than rendering as "<no value>".
+func parseTemplate(name, s string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to parse %s template", name))
+	}
+	return t, nil
+}
+
+// headerTemplate is a target header whose value is templated
+type headerTemplate struct {
+	key   string
+	value *template.Template
+}
+
+// targetTemplate renders a target for every request, nil templates are
+// taken from the static target as is
+type targetTemplate struct {
+	static  vegeta.Target
+	url     *template.Template
+	body    *template.Template
+	headers []headerTemplate
+}
+
+// newTargetTemplate parses the templated URL, header values and body of a target
+func newTargetTemplate(tgt vegeta.Target) (*targetTemplate, error) {
+	t := &targetTemplate{static: tgt}
+
+	var err error
+	if isTemplate(tgt.URL) {
+		if t.url, err = parseTemplate("URL", tgt.URL); err != nil {
+			return nil, err
+		}
+	}
+	if isTemplate(string(tgt.Body)) {
+		if t.body, err = parseTemplate("body", string(tgt.Body)); err != nil {
+			return nil, err
+		}
+	}
+	for key, values := range tgt.Header {
+		for _, value := range values {
+			if !isTemplate(value) {
+				continue
+			}
+			tmpl, err := parseTemplate("header "+key, value)
+			if err != nil {
+				return nil, err
+			}
+			t.headers = append(t.headers, headerTemplate{key, tmpl})
+		}
+	}
+
+	return t, nil
+}
+
+// templated reports whether anything in the target is rendered per request
+func (t *targetTemplate) templated() bool {
+	return t.url != nil || t.body != nil || len(t.headers) > 0
+}
+
+// render fills tgt with the target rendered for the feeder record
+func (t *targetTemplate) render(tgt *vegeta.Target, record map[string]string) error {
+	*tgt = t.static
+	if !t.templated() {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if t.url != nil {
+		if err := t.url.Execute(&buf, record); err != nil {
+			return err
+		}
+		tgt.URL = buf.String()
+	}
+	if t.body != nil {
+		buf.Reset()
+		if err := t.body.Execute(&buf, record); err != nil {
+			return err
+		}
+		tgt.Body = append([]byte(nil), buf.Bytes()...)
+	}
+	if len(t.headers) > 0 {
+		tgt.Header = make(http.Header, len(t.static.Header))
+		for key, values := range t.static.Header {
+			for _, value := range values {
+				if !isTemplate(value) {
+					tgt.Header.Add(key, value)
+				}
+			}
+		}
+		for _, h := range t.headers {
+			buf.Reset()
+			if err := h.value.Execute(&buf, record); err != nil {
+				return err
+			}
+			tgt.Header.Add(h.key, buf.String())
+		}
+	}
+	return nil
+}
+
+// feeder hands out the records of a feed in the configured order
+type feeder struct {
+	mu      sync.Mutex
+	records models.Feed
+	random  bool
+	next    int
+	rnd     *rand.Rand
+}
+
+// newFeeder returns a feeder over the records, shuffling them once if asked to
+func newFeeder(records models.Feed, order string) *feeder {
+	f := &feeder{
+		records: records,
+		random:  order == models.FeederOrderRandom,
+		rnd:     rand.New(rand.NewSource(time.Now().UnixNano())), // nolint: gosec
+	}
+	if order == models.FeederOrderShuffle {
+		f.records = append(models.Feed(nil), records...)
+		f.rnd.Shuffle(len(f.records), func(i, j int) {
+			f.records[i], f.records[j] = f.records[j], f.records[i]
+		})
+	}
+	return f
+}
+
+// Next returns the next record, nil if the feed is empty
+func (f *feeder) Next() map[string]string {
+	if f == nil || len(f.records) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.random {
+		return f.records[f.rnd.Intn(len(f.records))]
+	}
+	record := f.records[f.next]
+	f.next = (f.next + 1) % len(f.records)
+	return record
+}
+
+// Targeter hands out the targets of an attack in proportion to their
+// weights, rendering the templated URL, header values and body of every
+// target with the next record of the feed
+type Targeter struct {
+	templates []*targetTemplate
+	feeder    *feeder
+
+	// Targets are picked by smooth weighted round-robin, which spreads the
+	// picks of every target evenly instead of sending them in bursts
+	mu      sync.Mutex
+	weights []int
+	current []int
+	total   int
+}
+
+// NewTargeter returns a targeter of the targets with the given weights, a
+// missing or zero weight counting as 1. Targets of equal weight are handed
+// out in turn, like vegeta's static targeter does.
+func NewTargeter(targets []vegeta.Target, weights []int, feed models.Feed, order string) (*Targeter, error) {
+	t := &Targeter{
+		templates: make([]*targetTemplate, len(targets)),
+		feeder:    newFeeder(feed, order),
+		weights:   make([]int, len(targets)),
+		current:   make([]int, len(targets)),
+	}
+	for i, tgt := range targets {
+		tmpl, err := newTargetTemplate(tgt)
+		if err != nil {
+			return nil, err
+		}
+		t.templates[i] = tmpl
+
+		t.weights[i] = 1
+		if i < len(weights) && weights[i] > 0 {
+			t.weights[i] = weights[i]
+		}
+		t.total += t.weights[i]
+	}
+	return t, nil
+}
+
+// Next fills tgt with the next target and returns its index
+func (t *Targeter) Next(tgt *vegeta.Target) (int, error) {
+	if tgt == nil {
+		return 0, vegeta.ErrNilTarget
+	}
+	if len(t.templates) == 0 {
+		return 0, vegeta.ErrNoTargets
+	}
+
+	t.mu.Lock()
+	next := 0
+	for i, w := range t.weights {
+		t.current[i] += w
+		if t.current[i] > t.current[next] {
+			next = i
+		}
+	}
+	t.current[next] -= t.total
+	t.mu.Unlock()
+
+	tmpl := t.templates[next]
+	var record map[string]string
+	if tmpl.templated() {
+		record = t.feeder.Next()
+	}
+	return next, tmpl.render(tgt, record)
+}
+
+// ValidateTemplates checks the template syntax of the attack targets
+func ValidateTemplates(params models.AttackParams) error {
+	targets, err := targetsFromParams(params)
+	if err != nil {
+		return err
+	}
+	for _, tgt := range targets {
+		if _, err := newTargetTemplate(tgt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
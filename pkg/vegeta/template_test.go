@@ -0,0 +1,123 @@
+package vegeta
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+	"vegeta-server/models"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
+
+func TestNewTargeter(t *testing.T) {
+	targets := []vegeta.Target{
+		{
+			Method: "POST",
+			URL:    "http://localhost/users/{{.user_id}}",
+			Header: http.Header{"X-Request-Id": []string{"{{uuid}}"}, "Accept": []string{"application/json"}},
+			Body:   []byte(`{"name": "{{.name}}", "n": {{randInt 1 3}}}`),
+		},
+		{
+			Method: "GET",
+			URL:    "http://localhost/health",
+		},
+	}
+	feed := models.Feed{
+		{"user_id": "1", "name": "alice"},
+		{"user_id": "2", "name": "bob"},
+	}
+
+	tr, err := NewTargeter(targets, nil, feed, models.FeederOrderSequential)
+	if err != nil {
+		t.Fatalf("NewTargeter() error = %v", err)
+	}
+
+	uuidPattern := regexp.MustCompile(`^[0-9a-f-]{36}$`)
+	bodyPattern := regexp.MustCompile(`^\{"name": "(alice|bob)", "n": [1-3]\}$`)
+	wantURLs := []string{
+		"http://localhost/users/1",
+		"http://localhost/health",
+		"http://localhost/users/2",
+		"http://localhost/health",
+		"http://localhost/users/1",
+	}
+	for i, want := range wantURLs {
+		var tgt vegeta.Target
+		if _, err := tr.Next(&tgt); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if tgt.URL != want {
+			t.Errorf("Next() URL = %v, want %v", tgt.URL, want)
+		}
+		if i%2 == 1 {
+			continue
+		}
+		if !uuidPattern.MatchString(tgt.Header.Get("X-Request-Id")) || tgt.Header.Get("Accept") != "application/json" {
+			t.Errorf("Next() header = %v", tgt.Header)
+		}
+		if !bodyPattern.Match(tgt.Body) {
+			t.Errorf("Next() body = %s", tgt.Body)
+		}
+	}
+
+	// The static target's header is left untouched
+	if targets[0].Header.Get("X-Request-Id") != "{{uuid}}" {
+		t.Errorf("Next() modified the target header: %v", targets[0].Header)
+	}
+}
+
+func TestNewTargeter_Shuffle(t *testing.T) {
+	feed := make(models.Feed, 0)
+	for i := 0; i < 10; i++ {
+		feed = append(feed, map[string]string{"id": strconv.Itoa(i)})
+	}
+
+	tr, err := NewTargeter([]vegeta.Target{{URL: "{{.id}}"}}, nil, feed, models.FeederOrderShuffle)
+	if err != nil {
+		t.Fatalf("NewTargeter() error = %v", err)
+	}
+
+	// Every record is used once per cycle through the feed
+	for cycle := 0; cycle < 2; cycle++ {
+		got := make([]string, 0)
+		for i := 0; i < len(feed); i++ {
+			var tgt vegeta.Target
+			if _, err := tr.Next(&tgt); err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+			got = append(got, tgt.URL)
+		}
+		sort.Slice(got, func(i, j int) bool {
+			a, _ := strconv.Atoi(got[i])
+			b, _ := strconv.Atoi(got[j])
+			return a < b
+		})
+		for i, url := range got {
+			if url != strconv.Itoa(i) {
+				t.Fatalf("Next() cycle %d = %v, want every record once", cycle, got)
+			}
+		}
+	}
+}
+
+func TestNewTargeter_Errors(t *testing.T) {
+	if _, err := NewTargeter([]vegeta.Target{{URL: "{{.id"}}, nil, nil, ""); err == nil {
+		t.Error("NewTargeter() error = nil, want a parse error")
+	}
+
+	tr, err := NewTargeter([]vegeta.Target{{URL: "/users/{{.id}}"}}, nil, models.Feed{{"name": "alice"}}, "")
+	if err != nil {
+		t.Fatalf("NewTargeter() error = %v", err)
+	}
+	var tgt vegeta.Target
+	if _, err := tr.Next(&tgt); err == nil {
+		t.Error("Next() error = nil, want a missing key error")
+	}
+
+	tr, _ = NewTargeter(nil, nil, nil, "")
+	if _, err := tr.Next(&tgt); err != vegeta.ErrNoTargets {
+		t.Errorf("Next() error = %v, want %v", err, vegeta.ErrNoTargets)
+	}
+}
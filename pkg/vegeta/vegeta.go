@@ -1,7 +1,7 @@
 package vegeta
 
 import (
-	"bytes"
+	"bufio"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -13,6 +13,10 @@ import (
 	vegeta "github.com/tsenart/vegeta/lib"
 )
 
+// resultChunkSize is the size of the chunks in which encoded results are
+// written to the result writer
+const resultChunkSize = 64 * 1024
+
 func tlsConfig(insecure bool, key, cert string, rootCerts []string) (*tls.Config, error) {
 	c := tls.Config{InsecureSkipVerify: insecure} // nolint: gosec
 	certificate, err := tls.X509KeyPair([]byte(cert), []byte(key))
@@ -35,7 +39,7 @@ func tlsConfig(insecure bool, key, cert string, rootCerts []string) (*tls.Config
 	return &c, nil
 }
 
-func attackWithOpts(opts *AttackOpts) (*vegeta.Attacker, <-chan *vegeta.Result) {
+func attackWithOpts(opts *AttackOpts) (attacker, <-chan *vegeta.Result) {
 	var c *tls.Config
 
 	if opts.Cert != "" && opts.Key != "" {
@@ -46,40 +50,61 @@ func attackWithOpts(opts *AttackOpts) (*vegeta.Attacker, <-chan *vegeta.Result)
 		c = tlsConfig
 	}
 
-	atk := vegeta.NewAttacker(
-		vegeta.Redirects(opts.Redirects),
-		vegeta.Timeout(opts.Timeout),
-		vegeta.Workers(opts.Workers),
-		vegeta.KeepAlive(opts.Keepalive),
-		vegeta.Connections(opts.Connections),
-		vegeta.HTTP2(opts.HTTP2),
-		vegeta.H2C(opts.H2c),
-		vegeta.MaxBody(opts.MaxBody),
-		vegeta.TLSConfig(c),
-		vegeta.LocalAddr(*opts.Laddr.IPAddr),
-	)
+	if opts.Scenario != nil {
+		atk, err := newScenarioAttacker(opts, c)
+		if err != nil {
+			log.WithError(err).Error("Vegeta scenario failed")
+			return nil, nil
+		}
+		return atk, atk.Attack(opts.Rate, opts.Duration)
+	}
 
-	tr := vegeta.NewStaticTargeter(opts.Target...)
+	if opts.Replay != nil {
+		atk, err := newReplayAttacker(opts, c)
+		if err != nil {
+			log.WithError(err).Error("Vegeta replay failed")
+			return nil, nil
+		}
+		return atk, atk.Attack(opts.Rate, opts.Duration)
+	}
 
-	return atk, atk.Attack(tr, opts.Rate, opts.Duration, opts.Name)
+	atk, err := newTargetAttacker(opts, c)
+	if err != nil {
+		log.WithError(err).Error("Vegeta targeter failed")
+		return nil, nil
+	}
+	return atk, atk.Attack(opts.Rate, opts.Duration)
 }
 
-// Attack implements the AttackFunc type for a vegeta based attacker
-func Attack(name string, params models.AttackParams, quit chan struct{}) (io.Reader, error) {
+// Attack implements the AttackFunc type for a vegeta based attacker.
+// Templated targets are rendered with the records of the feed, if any, and
+// uploaded target files and access logs are read from the inputs, which
+// receive the samples of the attack as well.
+// Results are encoded and written to w in chunks as they arrive, so memory use
+// does not grow with the length of the attack.
+func Attack(name string, params models.AttackParams, inputs models.AttackInputs, w io.Writer, quit chan struct{}) error {
 	opts, err := NewAttackOptsFromAttackParams(name, params)
 	if err != nil {
 		log.WithError(err).Error("vegeta attack failed")
-		return nil, errors.Wrap(err, "vegeta attack failed")
+		return errors.Wrap(err, "vegeta attack failed")
+	}
+	opts.Feed = inputs.Feed
+	if inputs.Targets != nil {
+		opts.OpenTargets = inputs.Targets
 	}
+	if inputs.Log != nil {
+		opts.OpenLog = inputs.Log
+	}
+	opts.SampleWriter = inputs.Samples
 
 	atk, result := attackWithOpts(opts)
 	if result == nil {
 		err := fmt.Errorf("empty channel returned")
 		log.WithError(err).Error("vegeta attack failed")
-		return nil, errors.Wrap(err, "vegeta attack failed")
+		return errors.Wrap(err, "vegeta attack failed")
 	}
 
-	buf := bytes.NewBuffer(nil)
+	buf := bufio.NewWriterSize(w, resultChunkSize)
 	enc := vegeta.NewEncoder(buf)
 loop:
 	for {
@@ -89,14 +114,18 @@ loop:
 				break loop
 			}
 			if err := enc.Encode(r); err != nil {
+				atk.Stop()
 				log.WithError(err).Error("Vegeta attack failed")
-				return nil, errors.Wrap(err, "failed to encode result, vegeta attack failed")
+				return errors.Wrap(err, "failed to encode result, vegeta attack failed")
 			}
 		case <-quit:
 			atk.Stop()
-			return nil, nil
+			return nil
 		}
 	}
 
-	return buf, nil
+	if err := buf.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush results, vegeta attack failed")
+	}
+	return nil
 }